@@ -96,6 +96,9 @@ func (at *agentTool) InvokableRun(ctx context.Context, argumentsInJSON string, o
 		bResume = false
 	}
 
+	// interrupt points of the agent are nested in the address of this tool call
+	ctx = withInterruptAddrPrefix(ctx, getInterruptAddr(ctx))
+
 	var ms *mockStore
 	var iter *AsyncIterator[*AgentEvent]
 	if bResume {
@@ -165,6 +168,7 @@ func (at *agentTool) InvokableRun(ctx context.Context, argumentsInJSON string, o
 		if err != nil {
 			return "", fmt.Errorf("failed to save agent tool checkpoint to state: %w", err)
 		}
+		recordAgentToolInterruptPoints(ctx, compose.GetToolCallID(ctx), lastEvent.Action.Interrupted.Points())
 		return "", compose.InterruptAndRerun
 	}
 
@@ -184,6 +188,12 @@ func (at *agentTool) InvokableRun(ctx context.Context, argumentsInJSON string, o
 				}
 				ret = msg.Content
 			}
+		} else if lastEvent.Output.CustomizedOutput != nil {
+			// e.g. structured final answer of ChatModelAgent
+			ret, err = sonic.MarshalString(lastEvent.Output.CustomizedOutput)
+			if err != nil {
+				return "", err
+			}
 		}
 	}
 
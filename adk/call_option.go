@@ -16,8 +16,12 @@
 
 package adk
 
+import "github.com/cloudwego/eino/compose"
+
 type options struct {
-	checkPointID *string
+	checkPointID    *string
+	threadID        *string
+	interruptHandle *compose.InterruptHandle
 }
 
 // AgentRunOption is the call option for adk Agent.
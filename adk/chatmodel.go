@@ -30,10 +30,10 @@ import (
 
 	"github.com/cloudwego/eino/callbacks"
 	"github.com/cloudwego/eino/components/model"
-	"github.com/cloudwego/eino/components/prompt"
 	"github.com/cloudwego/eino/components/tool"
 	"github.com/cloudwego/eino/compose"
 	"github.com/cloudwego/eino/internal/safe"
+	"github.com/cloudwego/eino/internal/toolloop"
 	"github.com/cloudwego/eino/schema"
 	ub "github.com/cloudwego/eino/utils/callbacks"
 )
@@ -78,28 +78,35 @@ type ToolsConfig struct {
 	// Names of the tools that will make agent return directly when the tool is called.
 	// When multiple tools are called and more than one tool is in the return directly list, only the first one will be returned.
 	ReturnDirectly map[string]bool
+
+	// ToolSelector picks the tools bound to the model for each model step, optional, defaults to binding all the tools.
+	// Useful when there are too many tools to bind at once, see NewEmbeddingToolSelector.
+	// The tools added by the agent itself, such as transfer_to_agent and the exit tool, are always bound.
+	ToolSelector ToolSelector
 }
 
 type GenModelInput func(ctx context.Context, instruction string, input *AgentInput) ([]Message, error)
 
-func defaultGenModelInput(ctx context.Context, instruction string, input *AgentInput) ([]Message, error) {
-	msgs := make([]Message, 0, len(input.Messages)+1)
-
-	if instruction != "" {
-		sp := schema.SystemMessage(instruction)
+// instructionRenderer renders the instruction with the session values.
+type instructionRenderer func(ctx context.Context, instruction string) (string, error)
 
+// newInstructionRenderer renders the instruction in the format, the instruction is kept as is if there is no session value.
+func newInstructionRenderer(format schema.FormatType, lenient bool) instructionRenderer {
+	return func(ctx context.Context, instruction string) (string, error) {
 		vs := GetSessionValues(ctx)
-		if len(vs) > 0 {
-			ct := prompt.FromMessages(schema.FString, sp)
-			ms, err := ct.Format(ctx, vs)
-			if err != nil {
-				return nil, err
-			}
-
-			sp = ms[0]
+		if instruction == "" || len(vs) == 0 {
+			return instruction, nil
 		}
+		return renderInstruction(ctx, instruction, vs, format, lenient)
+	}
+}
+
+// genModelInputWithoutRendering is the GenModelInput used when the agent has rendered the instruction itself.
+func genModelInputWithoutRendering(_ context.Context, instruction string, input *AgentInput) ([]Message, error) {
+	msgs := make([]Message, 0, len(input.Messages)+1)
 
-		msgs = append(msgs, sp)
+	if instruction != "" {
+		msgs = append(msgs, schema.SystemMessage(instruction))
 	}
 
 	msgs = append(msgs, input.Messages...)
@@ -112,11 +119,22 @@ type ChatModelAgentConfig struct {
 	Description string
 	Instruction string
 
+	// InstructionFormat is how the instruction is rendered with the session values unless GenModelInput is set,
+	// optional, defaults to schema.FString. The instruction isn't rendered if there is no session value.
+	InstructionFormat schema.FormatType
+	// LenientInstruction renders the variables missing from the session values as empty strings, optional.
+	// By default, a missing variable fails the run.
+	LenientInstruction bool
+	// InstructionProvider computes the instruction for each run instead of Instruction, optional.
+	// The instruction it gives is rendered the same as Instruction.
+	InstructionProvider InstructionProvider
+
 	Model model.ToolCallingChatModel
 
 	ToolsConfig ToolsConfig
 
-	// optional
+	// optional, if set, it's given the unrendered instruction followed by the instructions the agent generates,
+	// e.g. for the transfer and the output.
 	GenModelInput GenModelInput
 
 	// Exit tool. Optional, defaults to nil, which will generate an Exit Action.
@@ -127,6 +145,28 @@ type ChatModelAgentConfig struct {
 	OutputKey string
 
 	MaxStep int
+
+	// Output describes the structured final answer, optional.
+	// If set, the valid final answer is set to CustomizedOutput of the final AgentEvent,
+	// and to the session under OutputKey instead of the message content.
+	Output *OutputConfig
+
+	// ModelFallback makes the agent fall back to other models when Model fails, optional.
+	ModelFallback *ModelFallbackConfig
+
+	// Middlewares hook around the model and tool calls, optional.
+	Middlewares []AgentMiddleware
+
+	// Memory gives the agent long-term memories, optional.
+	// The memories relevant to the latest user message are injected into the model input,
+	// and the agent gets the save_memory and search_memory tools.
+	Memory *MemoryConfig
+
+	// ToolCallLoop detects and breaks the loops of repeated tool calls, optional.
+	ToolCallLoop *ToolCallLoopConfig
+
+	// MaxStepAnswer gives the final answer when MaxStep is exhausted instead of failing, optional.
+	MaxStepAnswer *MaxStepAnswerConfig
 }
 
 type ChatModelAgent struct {
@@ -134,14 +174,25 @@ type ChatModelAgent struct {
 	description string
 	instruction string
 
+	instructionProvider InstructionProvider
+
 	model       model.ToolCallingChatModel
 	toolsConfig ToolsConfig
 
 	genModelInput GenModelInput
+	// instructionRenderer is nil if the instruction is left to a custom GenModelInput.
+	instructionRenderer instructionRenderer
 
 	outputKey string
 	maxStep   int
 
+	output *outputHandler
+
+	middlewares middlewares
+
+	toolCallLoop  *ToolCallLoopConfig
+	maxStepAnswer *MaxStepAnswerConfig
+
 	subAgents   []Agent
 	parentAgent Agent
 
@@ -150,9 +201,10 @@ type ChatModelAgent struct {
 	exit tool.BaseTool
 
 	// runner
-	once   sync.Once
-	run    runFunc
-	frozen uint32
+	once      sync.Once
+	run       runFunc
+	compileMu sync.Mutex
+	frozen    uint32
 }
 
 type runFunc func(ctx context.Context, input *AgentInput, generator *AsyncGenerator[*AgentEvent], store *mockStore, opts ...compose.Option)
@@ -193,21 +245,73 @@ func NewChatModelAgent(_ context.Context, config *ChatModelAgentConfig) (*ChatMo
 		return nil, err
 	}
 
-	genInput := defaultGenModelInput
+	switch config.InstructionFormat {
+	case schema.FString, schema.GoTemplate, schema.Jinja2:
+	default:
+		return nil, fmt.Errorf("unknown instruction format: %v", config.InstructionFormat)
+	}
+	// the agent renders its own instruction before appending the instructions it generates, such as the JSON schema
+	// of the output, which aren't templates
+	render := newInstructionRenderer(config.InstructionFormat, config.LenientInstruction)
+	genInput := GenModelInput(genModelInputWithoutRendering)
 	if config.GenModelInput != nil {
 		genInput = config.GenModelInput
+		render = nil
+	}
+
+	cm := config.Model
+	if config.ModelFallback != nil {
+		cm, err = newFallbackChatModel(config.Model, config.ModelFallback)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	toolsConfig := config.ToolsConfig
+	if config.Memory != nil {
+		mm, err := newMemoryManager(config.Name, config.Memory)
+		if err != nil {
+			return nil, err
+		}
+		if !config.Memory.DisableInjection {
+			genInput = mm.wrapGenModelInput(genInput)
+		}
+		if !config.Memory.DisableTools {
+			tools := toolsConfig.Tools
+			toolsConfig.Tools = append(tools[:len(tools):len(tools)], mm.tools()...)
+		}
+	}
+
+	var output *outputHandler
+	if config.Output != nil {
+		output, err = newOutputHandler(config.Output)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if config.ToolCallLoop != nil {
+		if err = toolloop.Validate(config.ToolCallLoop); err != nil {
+			return nil, err
+		}
 	}
 
 	return &ChatModelAgent{
-		name:          config.Name,
-		description:   config.Description,
-		instruction:   config.Instruction,
-		model:         config.Model,
-		toolsConfig:   config.ToolsConfig,
-		genModelInput: genInput,
-		exit:          config.Exit,
-		outputKey:     config.OutputKey,
-		maxStep:       config.MaxStep,
+		name:                config.Name,
+		description:         config.Description,
+		instruction:         config.Instruction,
+		instructionProvider: config.InstructionProvider,
+		model:               cm,
+		toolsConfig:         toolsConfig,
+		genModelInput:       genInput,
+		instructionRenderer: render,
+		exit:                config.Exit,
+		outputKey:           config.OutputKey,
+		maxStep:             config.MaxStep,
+		output:              output,
+		middlewares:         config.Middlewares,
+		toolCallLoop:        config.ToolCallLoop,
+		maxStepAnswer:       config.MaxStepAnswer,
 	}, nil
 }
 
@@ -342,18 +446,32 @@ func (a *ChatModelAgent) OnDisallowTransferToParent(_ context.Context) error {
 	return nil
 }
 
+// AddTools adds the tools to the agent before it runs, e.g. the tools built from the agents it works with.
+func (a *ChatModelAgent) AddTools(_ context.Context, tools ...tool.BaseTool) error {
+	if atomic.LoadUint32(&a.frozen) == 1 {
+		return errors.New("agent has been frozen after run")
+	}
+
+	existing := a.toolsConfig.Tools
+	a.toolsConfig.Tools = append(existing[:len(existing):len(existing)], tools...)
+	return nil
+}
+
 type cbHandler struct {
 	*AsyncGenerator[*AgentEvent]
 	agentName string
 
 	enableStreaming bool
 	store           *mockStore
+
+	agentToolPoints *agentToolInterruptPoints
 }
 
 func (h *cbHandler) onChatModelEnd(ctx context.Context,
 	_ *callbacks.RunInfo, output *model.CallbackOutput) context.Context {
 
 	event := EventFromMessage(output.Message, nil, schema.Assistant, "")
+	event.Output.MaxStepExhausted = isMaxStepExhausted(ctx)
 	h.Send(event)
 	return ctx
 }
@@ -366,11 +484,18 @@ func (h *cbHandler) onChatModelEndWithStreamOutput(ctx context.Context,
 	}
 	out := schema.StreamReaderWithConvert(output, cvt)
 	event := EventFromMessage(nil, out, schema.Assistant, "")
+	event.Output.MaxStepExhausted = isMaxStepExhausted(ctx)
 	h.Send(event)
 
 	return ctx
 }
 
+func (h *cbHandler) onToolStart(ctx context.Context,
+	_ *callbacks.RunInfo, _ *tool.CallbackInput) context.Context {
+
+	return withToolInterruptScope(ctx, compose.GetToolCallID(ctx))
+}
+
 func (h *cbHandler) onToolEnd(ctx context.Context,
 	runInfo *callbacks.RunInfo, output *tool.CallbackOutput) context.Context {
 
@@ -380,6 +505,7 @@ func (h *cbHandler) onToolEnd(ctx context.Context,
 
 	action := popToolGenAction(ctx, runInfo.Name)
 	event.Action = action
+	skipFinalAnswer(ctx, action)
 
 	h.Send(event)
 
@@ -425,25 +551,67 @@ func (h *cbHandler) onGraphError(ctx context.Context,
 	}
 	h.Send(&AgentEvent{AgentName: h.agentName, Action: &AgentAction{
 		Interrupted: &InterruptInfo{
-			Data: &tempInterruptInfo{data: data, info: info},
+			Data:   &tempInterruptInfo{data: data, info: info},
+			points: genInterruptPoints(ctx, info, h.agentToolPoints),
 		},
 	}})
 
 	return ctx
 }
 
+// genInterruptPoints generates one interrupt point for each interrupted tool call,
+// or a single point for the agent itself if no tool call is interrupted.
+// The tool calls of the agent tools are replaced by the points of the nested agents in agentToolPoints, which is optional.
+func genInterruptPoints(ctx context.Context, info *compose.InterruptInfo, agentToolPoints *agentToolInterruptPoints) []*InterruptPoint {
+	addr := getInterruptAddr(ctx)
+
+	var points []*InterruptPoint
+	for _, extra := range info.RerunNodesExtra {
+		toolsExtra, ok := extra.(*compose.ToolsInterruptAndRerunExtra)
+		if !ok {
+			continue
+		}
+
+		for _, toolCallID := range toolsExtra.RerunTools {
+			if agentToolPoints != nil {
+				if nested := agentToolPoints.get(toolCallID); len(nested) > 0 {
+					points = append(points, nested...)
+					continue
+				}
+			}
+
+			points = append(points, &InterruptPoint{
+				ID:   genInterruptID(append(addr[:len(addr):len(addr)], toolCallID)),
+				Info: toolsExtra.RerunExtraMap[toolCallID],
+			})
+		}
+	}
+
+	if len(points) == 0 {
+		points = append(points, &InterruptPoint{
+			ID:   genInterruptID(addr),
+			Info: info,
+		})
+	}
+
+	return points
+}
+
 func genReactCallbacks(agentName string,
 	generator *AsyncGenerator[*AgentEvent],
 	enableStreaming bool,
-	store *mockStore) compose.Option {
+	store *mockStore,
+	agentToolPoints *agentToolInterruptPoints) compose.Option {
 
-	h := &cbHandler{AsyncGenerator: generator, agentName: agentName, store: store, enableStreaming: enableStreaming}
+	h := &cbHandler{AsyncGenerator: generator, agentName: agentName, store: store, enableStreaming: enableStreaming,
+		agentToolPoints: agentToolPoints}
 
 	cmHandler := &ub.ModelCallbackHandler{
 		OnEnd:                 h.onChatModelEnd,
 		OnEndWithStreamOutput: h.onChatModelEndWithStreamOutput,
 	}
 	toolHandler := &ub.ToolCallbackHandler{
+		OnStart:               h.onToolStart,
 		OnEnd:                 h.onToolEnd,
 		OnEndWithStreamOutput: h.onToolEndWithStreamOutput,
 	}
@@ -477,9 +645,10 @@ func errFunc(err error) runFunc {
 
 func (a *ChatModelAgent) buildRunFunc(ctx context.Context) runFunc {
 	a.once.Do(func() {
-		instruction := a.instruction
+		var transferInstruction, outputInstruction string
 		toolsNodeConf := a.toolsConfig.ToolsNodeConfig
 		returnDirectly := copyMap(a.toolsConfig.ReturnDirectly)
+		pinnedTools := make(map[string]bool)
 
 		transferToAgents := a.subAgents
 		if a.parentAgent != nil && !a.disallowTransferToParent {
@@ -487,11 +656,11 @@ func (a *ChatModelAgent) buildRunFunc(ctx context.Context) runFunc {
 		}
 
 		if len(transferToAgents) > 0 {
-			transferInstruction := genTransferToAgentInstruction(ctx, transferToAgents)
-			instruction = concatInstructions(instruction, transferInstruction)
+			transferInstruction = genTransferToAgentInstruction(ctx, transferToAgents)
 
 			toolsNodeConf.Tools = append(toolsNodeConf.Tools, &transferToAgent{})
 			returnDirectly[TransferToAgentToolName] = true
+			pinnedTools[TransferToAgentToolName] = true
 		}
 
 		if a.exit != nil {
@@ -502,11 +671,62 @@ func (a *ChatModelAgent) buildRunFunc(ctx context.Context) runFunc {
 				return
 			}
 			returnDirectly[exitInfo.Name] = true
+			pinnedTools[exitInfo.Name] = true
 		}
 
-		if len(toolsNodeConf.Tools) == 0 {
-			a.run = func(ctx context.Context, input *AgentInput, generator *AsyncGenerator[*AgentEvent], store *mockStore, opts ...compose.Option) {
+		if a.output != nil {
+			var err error
+			outputInstruction, err = a.output.instruction()
+			if err != nil {
+				a.run = errFunc(err)
+				return
+			}
+
+			if t := a.output.tool(); t != nil {
+				toolsNodeConf.Tools = append(toolsNodeConf.Tools, t)
+				returnDirectly[a.output.toolName] = true
+				pinnedTools[a.output.toolName] = true
+			}
+		}
+
+		genInstruction := func(ctx context.Context, input *AgentInput) (string, error) {
+			instruction := a.instruction
+			if a.instructionProvider != nil {
+				var err error
+				instruction, err = a.instructionProvider(ctx, input)
+				if err != nil {
+					return "", fmt.Errorf("failed to provide instruction: %w", err)
+				}
+			}
+			if a.instructionRenderer != nil {
 				var err error
+				instruction, err = a.instructionRenderer(ctx, instruction)
+				if err != nil {
+					return "", err
+				}
+			}
+
+			if transferInstruction != "" {
+				instruction = concatInstructions(instruction, transferInstruction)
+			}
+			if a.output != nil {
+				if instruction == "" {
+					instruction = outputInstruction
+				} else {
+					instruction = concatInstructions(instruction, outputInstruction)
+				}
+			}
+			return instruction, nil
+		}
+
+		if len(toolsNodeConf.Tools) == 0 && a.output == nil && len(a.middlewares) == 0 {
+			a.run = func(ctx context.Context, input *AgentInput, generator *AsyncGenerator[*AgentEvent], store *mockStore, opts ...compose.Option) {
+				instruction, err := genInstruction(ctx, input)
+				if err != nil {
+					generator.Send(&AgentEvent{AgentName: a.name, Err: err})
+					return
+				}
+
 				var msgs []Message
 				msgs, err = a.genModelInput(ctx, instruction, input)
 				if err != nil {
@@ -560,6 +780,13 @@ func (a *ChatModelAgent) buildRunFunc(ctx context.Context) runFunc {
 			toolsConfig:         &toolsNodeConf,
 			toolsReturnDirectly: returnDirectly,
 			agentName:           a.name,
+			output:              a.output,
+			middlewares:         a.middlewares,
+			toolSelector:        a.toolsConfig.ToolSelector,
+			pinnedTools:         pinnedTools,
+			toolCallLoop:        a.toolCallLoop,
+			maxStep:             a.maxStep,
+			maxStepAnswer:       a.maxStepAnswer,
 		}
 
 		g, err := newReact(ctx, conf)
@@ -572,22 +799,41 @@ func (a *ChatModelAgent) buildRunFunc(ctx context.Context) runFunc {
 			var compileOptions []compose.GraphCompileOption
 			compileOptions = append(compileOptions, compose.WithGraphName("React"), compose.WithCheckPointStore(store), compose.WithSerializer(&gobSerializer{}))
 			if a.maxStep > 0 {
-				compileOptions = append(compileOptions, compose.WithMaxRunSteps(a.maxStep))
+				maxStep := a.maxStep
+				if a.maxStepAnswer != nil {
+					maxStep += maxStepAnswerReservedSteps
+				}
+				compileOptions = append(compileOptions, compose.WithMaxRunSteps(maxStep))
 			}
+			// compiling mutates the graph, so concurrent runs of the agent compile one by one
+			a.compileMu.Lock()
 			runnable, err_ := g.Compile(ctx, compileOptions...)
-			if err != nil {
-				generator.Send(&AgentEvent{AgentName: a.name, Err: err})
+			a.compileMu.Unlock()
+			if err_ != nil {
+				generator.Send(&AgentEvent{AgentName: a.name, Err: err_})
+				return
+			}
+
+			instruction, err_ := genInstruction(ctx, input)
+			if err_ != nil {
+				generator.Send(&AgentEvent{AgentName: a.name, Err: err_})
 				return
 			}
 
 			var msgs []Message
 			msgs, err_ = a.genModelInput(ctx, instruction, input)
 			if err_ != nil {
-				generator.Send(&AgentEvent{Err: err_})
+				generator.Send(&AgentEvent{AgentName: a.name, Err: err_})
 				return
 			}
 
-			callOpt := genReactCallbacks(a.name, generator, input.EnableStreaming, store)
+			var agentToolPoints *agentToolInterruptPoints
+			ctx, agentToolPoints = withAgentToolInterruptPoints(ctx)
+
+			var answer *finalAnswer
+			ctx, answer = withFinalAnswer(ctx)
+
+			callOpt := genReactCallbacks(a.name, generator, input.EnableStreaming, store, agentToolPoints)
 
 			var msg Message
 			var msgStream MessageStream
@@ -597,11 +843,26 @@ func (a *ChatModelAgent) buildRunFunc(ctx context.Context) runFunc {
 				msg, err_ = runnable.Invoke(ctx, msgs, append(opts, callOpt)...)
 			}
 
-			if err_ == nil {
+			if err_ == nil && a.output != nil {
+				if msgStream != nil {
+					// the final answer is only available after the stream is fully consumed
+					_, err_ = schema.ConcatMessageStream(msgStream)
+				}
+				if err_ != nil {
+					generator.Send(&AgentEvent{AgentName: a.name, Err: err_})
+				} else if value, ok := answer.get(); ok {
+					generator.Send(&AgentEvent{Output: &AgentOutput{CustomizedOutput: value}})
+					if a.outputKey != "" {
+						SetSessionValue(ctx, a.outputKey, value)
+					}
+				} else if !answer.isSkipped() {
+					generator.Send(&AgentEvent{AgentName: a.name, Err: ErrFinalAnswerMissing})
+				}
+			} else if err_ == nil {
 				if a.outputKey != "" {
 					err_ = setOutputToSession(ctx, msg, msgStream, a.outputKey)
 					if err_ != nil {
-						generator.Send(&AgentEvent{Err: err_})
+						generator.Send(&AgentEvent{AgentName: a.name, Err: err_})
 					}
 				} else if msgStream != nil {
 					msgStream.Close()
@@ -623,7 +884,7 @@ func (a *ChatModelAgent) Run(ctx context.Context, input *AgentInput, opts ...Age
 	co := getComposeOptions(opts)
 	co = append(co, compose.WithCheckPointID(mockCheckPointID))
 
-	iterator, generator := NewAsyncIteratorPair[*AgentEvent]()
+	iterator, generator := newEventIteratorPair(ctx)
 	go func() {
 		defer func() {
 			panicErr := recover()
@@ -647,7 +908,7 @@ func (a *ChatModelAgent) Resume(ctx context.Context, info *ResumeInfo, opts ...A
 	co := getComposeOptions(opts)
 	co = append(co, compose.WithCheckPointID(mockCheckPointID))
 
-	iterator, generator := NewAsyncIteratorPair[*AgentEvent]()
+	iterator, generator := newEventIteratorPair(ctx)
 	go func() {
 		defer func() {
 			panicErr := recover()
@@ -667,7 +928,7 @@ func (a *ChatModelAgent) Resume(ctx context.Context, info *ResumeInfo, opts ...A
 
 func getComposeOptions(opts []AgentRunOption) []compose.Option {
 	o := GetImplSpecificOptions[chatModelAgentRunOptions](nil, opts...)
-	var co []compose.Option
+	co := appendInterruptHandle(nil, opts)
 	if len(o.chatModelOptions) > 0 {
 		co = append(co, compose.WithChatModelOption(o.chatModelOptions...))
 	}
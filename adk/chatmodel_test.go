@@ -19,11 +19,13 @@ package adk
 import (
 	"context"
 	"errors"
+	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/mock/gomock"
 
+	"github.com/cloudwego/eino/components/model"
 	"github.com/cloudwego/eino/components/tool"
 	"github.com/cloudwego/eino/compose"
 	mockModel "github.com/cloudwego/eino/internal/mock/components/model"
@@ -343,3 +345,81 @@ func TestExitTool(t *testing.T) {
 	_, ok = iterator.Next()
 	assert.False(t, ok)
 }
+
+func TestRenderInstruction(t *testing.T) {
+	ctx := context.Background()
+	vs := map[string]any{"name": "eino", "user": map[string]any{"age": 3}}
+
+	cases := []struct {
+		name        string
+		instruction string
+		format      schema.FormatType
+		lenient     bool
+		want        string
+		wantErr     bool
+	}{
+		{name: "fstring", instruction: "hi {name}, {{literal}}", format: schema.FString, want: "hi eino, {literal}"},
+		{name: "fstring missing", instruction: "hi {name} {role}", format: schema.FString, wantErr: true},
+		{name: "fstring lenient", instruction: "hi {name}{role:>5}", format: schema.FString, lenient: true, want: "hi eino     "},
+		{name: "go template", instruction: "hi {{.name}}, {{.user.age}}", format: schema.GoTemplate, want: "hi eino, 3"},
+		{name: "go template missing", instruction: "hi {{.role}}", format: schema.GoTemplate, wantErr: true},
+		{name: "go template lenient", instruction: "hi {{.name}}{{if .role}} {{.role}}{{end}}[{{.role}}]", format: schema.GoTemplate, lenient: true, want: "hi eino[]"},
+		{name: "jinja2", instruction: "hi {{ name }}, {{ user.age }}", format: schema.Jinja2, want: "hi eino, 3"},
+		{name: "jinja2 missing", instruction: "hi {{ role }}", format: schema.Jinja2, wantErr: true},
+		{name: "jinja2 lenient", instruction: "hi {{ name }}[{{ role }}]", format: schema.Jinja2, lenient: true, want: "hi eino[]"},
+		{name: "jinja2 include", instruction: "{% include 'a.txt' %}", format: schema.Jinja2, wantErr: true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := renderInstruction(ctx, c.instruction, vs, c.format, c.lenient)
+			if c.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, c.want, got)
+		})
+	}
+}
+
+func TestInstructionProvider(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	cm := mockModel.NewMockToolCallingChatModel(ctrl)
+
+	var instructions []string
+	cm.EXPECT().Generate(gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, input []*schema.Message, _ ...model.Option) (*schema.Message, error) {
+			assert.Equal(t, schema.System, input[0].Role)
+			instructions = append(instructions, input[0].Content)
+			return schema.AssistantMessage("ok", nil), nil
+		}).Times(2)
+
+	a, err := NewChatModelAgent(ctx, &ChatModelAgentConfig{
+		Name:              "agent",
+		Description:       "agent",
+		Instruction:       "ignored",
+		InstructionFormat: schema.GoTemplate,
+		InstructionProvider: func(ctx context.Context, input *AgentInput) (string, error) {
+			SetSessionValue(ctx, "user", "eino")
+			return fmt.Sprintf("{{.user}} asks %d question(s)", len(input.Messages)), nil
+		},
+		Model: cm,
+	})
+	assert.NoError(t, err)
+
+	for _, query := range []string{"hi", "hello"} {
+		iter := NewRunner(ctx, RunnerConfig{Agent: a}).Query(ctx, query)
+		for {
+			event, ok := iter.Next()
+			if !ok {
+				break
+			}
+			assert.NoError(t, event.Err)
+		}
+	}
+	assert.Equal(t, []string{"eino asks 1 question(s)", "eino asks 1 question(s)"}, instructions)
+
+	_, err = NewChatModelAgent(ctx, &ChatModelAgentConfig{Name: "agent", Description: "agent", Model: cm, InstructionFormat: 3})
+	assert.Error(t, err)
+}
@@ -0,0 +1,279 @@
+/*
+ * Copyright 2025 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package adk
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+type AgentKind string
+
+const (
+	AgentKindChatModel  AgentKind = "ChatModel"
+	AgentKindSequential AgentKind = "Sequential"
+	AgentKindParallel   AgentKind = "Parallel"
+	AgentKindLoop       AgentKind = "Loop"
+	AgentKindRouter     AgentKind = "Router"
+	AgentKindCustom     AgentKind = "Custom"
+)
+
+// AgentNode describes an agent and the agents reachable from it, see DescribeAgent.
+type AgentNode struct {
+	Name        string
+	Description string
+	Kind        AgentKind
+
+	// MaxIterations of AgentKindLoop, 0 means no limit.
+	MaxIterations int
+
+	// DisallowTransferToParent is true if the agent can't transfer back to its parent agent.
+	DisallowTransferToParent bool
+
+	// SubAgents are the transfer targets of the agent, the agents run by the workflow agents, or the routes of AgentKindRouter.
+	SubAgents []*AgentNode
+
+	// Tools of AgentKindChatModel.
+	Tools []*AgentNodeTool
+}
+
+// AgentNodeTool describes a tool of ChatModelAgent.
+type AgentNodeTool struct {
+	Name           string
+	Description    string
+	ReturnDirectly bool
+	// Agent is set if the tool is created by NewAgentTool.
+	Agent *AgentNode
+}
+
+// AgentUnwrapper is implemented by the agents wrapping another agent, so that DescribeAgent can look through them.
+type AgentUnwrapper interface {
+	Unwrap() Agent
+}
+
+// DescribeAgent walks the agent and returns its hierarchy, including sub-agents, tools and agent tools.
+// Render the result with AgentNode.Mermaid or AgentNode.DOT.
+func DescribeAgent(ctx context.Context, agent Agent) (*AgentNode, error) {
+	switch a := agent.(type) {
+	case *flowAgent:
+		node, err := DescribeAgent(ctx, a.Agent)
+		if err != nil {
+			return nil, err
+		}
+		node.DisallowTransferToParent = node.DisallowTransferToParent || a.disallowTransferToParent
+		for _, sa := range a.subAgents {
+			sn, err := DescribeAgent(ctx, sa)
+			if err != nil {
+				return nil, err
+			}
+			node.SubAgents = append(node.SubAgents, sn)
+		}
+		return node, nil
+	case AgentUnwrapper:
+		node, err := DescribeAgent(ctx, a.Unwrap())
+		if err != nil {
+			return nil, err
+		}
+		node.Name = agent.Name(ctx)
+		return node, nil
+	}
+
+	node := &AgentNode{
+		Name:        agent.Name(ctx),
+		Description: agent.Description(ctx),
+		Kind:        AgentKindCustom,
+	}
+
+	switch a := agent.(type) {
+	case *workflowAgent:
+		switch a.mode {
+		case workflowAgentModeSequential:
+			node.Kind = AgentKindSequential
+		case workflowAgentModeParallel:
+			node.Kind = AgentKindParallel
+		case workflowAgentModeLoop:
+			node.Kind = AgentKindLoop
+			node.MaxIterations = a.maxIterations
+		case workflowAgentModeRouter:
+			node.Kind = AgentKindRouter
+		}
+	case *ChatModelAgent:
+		node.Kind = AgentKindChatModel
+		tools := a.toolsConfig.Tools
+		if a.exit != nil {
+			tools = append(tools[:len(tools):len(tools)], a.exit)
+		}
+		for i, t := range tools {
+			info, err := t.Info(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get info of tool in agent '%s': %w", node.Name, err)
+			}
+			nt := &AgentNodeTool{
+				Name:           info.Name,
+				Description:    info.Desc,
+				ReturnDirectly: a.toolsConfig.ReturnDirectly[info.Name] || (a.exit != nil && i == len(tools)-1),
+			}
+			if at, ok := t.(*agentTool); ok {
+				nt.Agent, err = DescribeAgent(ctx, at.agent)
+				if err != nil {
+					return nil, err
+				}
+			}
+			node.Tools = append(node.Tools, nt)
+		}
+	}
+
+	return node, nil
+}
+
+// Mermaid renders the hierarchy as a Mermaid flowchart.
+// Solid edges are transfers or workflow steps, dotted edges are transfers back to the parent and agent tool calls.
+func (n *AgentNode) Mermaid() string {
+	r := &diagramRenderer{}
+	r.line("flowchart TD")
+	r.walk(n, func(id string, node *AgentNode) {
+		r.line("    %s[\"%s\"]", id, mermaidEscape(node.label()))
+	}, func(id, toolID string, t *AgentNodeTool) {
+		r.line("    %s[/\"%s\"/]", toolID, mermaidEscape(t.label()))
+		r.line("    %s -.- %s", id, toolID)
+	}, func(from, to, label string, dotted bool) {
+		arrow := "-->"
+		if dotted {
+			arrow = "-.->"
+		}
+		if label == "" {
+			r.line("    %s %s %s", from, arrow, to)
+		} else {
+			r.line("    %s %s|\"%s\"| %s", from, arrow, mermaidEscape(label), to)
+		}
+	})
+	return r.String()
+}
+
+// DOT renders the hierarchy as a Graphviz digraph.
+// Solid edges are transfers or workflow steps, dashed edges are transfers back to the parent and agent tool calls.
+func (n *AgentNode) DOT() string {
+	r := &diagramRenderer{}
+	r.line("digraph agents {")
+	r.line("    node [shape=box];")
+	r.walk(n, func(id string, node *AgentNode) {
+		r.line("    %s [label=%s];", id, dotQuote(node.label()))
+	}, func(id, toolID string, t *AgentNodeTool) {
+		r.line("    %s [label=%s, shape=parallelogram];", toolID, dotQuote(t.label()))
+		r.line("    %s -> %s [arrowhead=none, style=dashed];", id, toolID)
+	}, func(from, to, label string, dotted bool) {
+		var attrs []string
+		if label != "" {
+			attrs = append(attrs, "label="+dotQuote(label))
+		}
+		if dotted {
+			attrs = append(attrs, "style=dashed")
+		}
+		if len(attrs) == 0 {
+			r.line("    %s -> %s;", from, to)
+		} else {
+			r.line("    %s -> %s [%s];", from, to, strings.Join(attrs, ", "))
+		}
+	})
+	r.line("}")
+	return r.String()
+}
+
+func (n *AgentNode) label() string {
+	kind := string(n.Kind)
+	if n.Kind == AgentKindLoop && n.MaxIterations > 0 {
+		kind = fmt.Sprintf("%s, max %d", kind, n.MaxIterations)
+	}
+	return fmt.Sprintf("%s\n(%s)", n.Name, kind)
+}
+
+func (t *AgentNodeTool) label() string {
+	if t.ReturnDirectly {
+		return t.Name + "\n(return directly)"
+	}
+	return t.Name
+}
+
+type diagramRenderer struct {
+	sb    strings.Builder
+	count int
+}
+
+func (r *diagramRenderer) line(format string, args ...any) {
+	r.sb.WriteString(fmt.Sprintf(format, args...))
+	r.sb.WriteString("\n")
+}
+
+func (r *diagramRenderer) String() string {
+	return r.sb.String()
+}
+
+func (r *diagramRenderer) nextID() string {
+	id := fmt.Sprintf("n%d", r.count)
+	r.count++
+	return id
+}
+
+// walk assigns ids to the agents and tools in depth first order, and reports them with the edges between them.
+func (r *diagramRenderer) walk(n *AgentNode, onAgent func(id string, n *AgentNode),
+	onTool func(id, toolID string, t *AgentNodeTool), onEdge func(from, to, label string, dotted bool)) string {
+
+	id := r.nextID()
+	onAgent(id, n)
+
+	for _, t := range n.Tools {
+		if t.Agent == nil {
+			onTool(id, r.nextID(), t)
+			continue
+		}
+		to := r.walk(t.Agent, onAgent, onTool, onEdge)
+		onEdge(id, to, "agent tool: "+t.Name, true)
+	}
+
+	for i, sa := range n.SubAgents {
+		to := r.walk(sa, onAgent, onTool, onEdge)
+		switch n.Kind {
+		case AgentKindSequential:
+			onEdge(id, to, fmt.Sprintf("step %d", i+1), false)
+		case AgentKindLoop:
+			onEdge(id, to, fmt.Sprintf("loop step %d", i+1), false)
+		case AgentKindParallel:
+			onEdge(id, to, "parallel", false)
+		case AgentKindRouter:
+			onEdge(id, to, "route", false)
+		default:
+			onEdge(id, to, "transfer", false)
+			if !sa.DisallowTransferToParent {
+				onEdge(to, id, "transfer back", true)
+			}
+		}
+	}
+
+	return id
+}
+
+func mermaidEscape(s string) string {
+	s = strings.ReplaceAll(s, `"`, "#quot;")
+	return strings.ReplaceAll(s, "\n", "<br/>")
+}
+
+func dotQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	return `"` + strings.ReplaceAll(s, "\n", `\n`) + `"`
+}
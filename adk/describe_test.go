@@ -0,0 +1,167 @@
+/*
+ * Copyright 2025 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package adk
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/compose"
+	mockModel "github.com/cloudwego/eino/internal/mock/components/model"
+)
+
+func TestDescribeAgent(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	cm := mockModel.NewMockToolCallingChatModel(ctrl)
+
+	newAgent := func(name string, tools ...tool.BaseTool) *ChatModelAgent {
+		a, err := NewChatModelAgent(ctx, &ChatModelAgentConfig{
+			Name:        name,
+			Description: name + " desc",
+			Model:       cm,
+			ToolsConfig: ToolsConfig{ToolsNodeConfig: compose.ToolsNodeConfig{Tools: tools}},
+		})
+		assert.NoError(t, err)
+		return a
+	}
+
+	helper := newAgent("helper")
+	loop, err := NewLoopAgent(ctx, &LoopAgentConfig{
+		Name:          "refine",
+		Description:   "refine desc",
+		SubAgents:     []Agent{newAgent("writer"), newAgent("critic")},
+		MaxIterations: 3,
+	})
+	assert.NoError(t, err)
+
+	worker := newAgent("worker", &fakeToolForTest{}, NewAgentTool(ctx, helper))
+	root, err := SetSubAgents(ctx, newAgent("root"), []Agent{
+		worker,
+		AgentWithOptions(ctx, loop, WithDisallowTransferToParent()),
+	})
+	assert.NoError(t, err)
+
+	node, err := DescribeAgent(ctx, root)
+	assert.NoError(t, err)
+	assert.Equal(t, &AgentNode{
+		Name:        "root",
+		Description: "root desc",
+		Kind:        AgentKindChatModel,
+		SubAgents: []*AgentNode{
+			{
+				Name:        "worker",
+				Description: "worker desc",
+				Kind:        AgentKindChatModel,
+				Tools: []*AgentNodeTool{
+					{Name: "test_tool", Description: "test tool for unit testing"},
+					{Name: "helper", Description: "helper desc", Agent: &AgentNode{Name: "helper", Description: "helper desc", Kind: AgentKindChatModel}},
+				},
+			},
+			{
+				Name:                     "refine",
+				Description:              "refine desc",
+				Kind:                     AgentKindLoop,
+				MaxIterations:            3,
+				DisallowTransferToParent: true,
+				SubAgents: []*AgentNode{
+					{Name: "writer", Description: "writer desc", Kind: AgentKindChatModel, DisallowTransferToParent: true},
+					{Name: "critic", Description: "critic desc", Kind: AgentKindChatModel, DisallowTransferToParent: true},
+				},
+			},
+		},
+	}, node)
+
+	assert.Equal(t, `flowchart TD
+    n0["root<br/>(ChatModel)"]
+    n1["worker<br/>(ChatModel)"]
+    n2[/"test_tool"/]
+    n1 -.- n2
+    n3["helper<br/>(ChatModel)"]
+    n1 -.->|"agent tool: helper"| n3
+    n0 -->|"transfer"| n1
+    n1 -.->|"transfer back"| n0
+    n4["refine<br/>(Loop, max 3)"]
+    n5["writer<br/>(ChatModel)"]
+    n4 -->|"loop step 1"| n5
+    n6["critic<br/>(ChatModel)"]
+    n4 -->|"loop step 2"| n6
+    n0 -->|"transfer"| n4
+`, node.Mermaid())
+
+	assert.Equal(t, `digraph agents {
+    node [shape=box];
+    n0 [label="root\n(ChatModel)"];
+    n1 [label="worker\n(ChatModel)"];
+    n2 [label="test_tool", shape=parallelogram];
+    n1 -> n2 [arrowhead=none, style=dashed];
+    n3 [label="helper\n(ChatModel)"];
+    n1 -> n3 [label="agent tool: helper", style=dashed];
+    n0 -> n1 [label="transfer"];
+    n1 -> n0 [label="transfer back", style=dashed];
+    n4 [label="refine\n(Loop, max 3)"];
+    n5 [label="writer\n(ChatModel)"];
+    n4 -> n5 [label="loop step 1"];
+    n6 [label="critic\n(ChatModel)"];
+    n4 -> n6 [label="loop step 2"];
+    n0 -> n4 [label="transfer"];
+}
+`, node.DOT())
+}
+
+func TestDescribeRouterAgent(t *testing.T) {
+	ctx := context.Background()
+	cm := mockModel.NewMockToolCallingChatModel(gomock.NewController(t))
+
+	var subAgents []Agent
+	for _, name := range []string{"billing", "support"} {
+		a, err := NewChatModelAgent(ctx, &ChatModelAgentConfig{Name: name, Description: name + " desc", Model: cm})
+		assert.NoError(t, err)
+		subAgents = append(subAgents, a)
+	}
+	router, err := NewRouterAgent(ctx, &RouterAgentConfig{
+		Name:        "router",
+		Description: "router desc",
+		SubAgents:   subAgents,
+		Classifier:  cm,
+	})
+	assert.NoError(t, err)
+
+	node, err := DescribeAgent(ctx, router)
+	assert.NoError(t, err)
+	assert.Equal(t, &AgentNode{
+		Name:        "router",
+		Description: "router desc",
+		Kind:        AgentKindRouter,
+		SubAgents: []*AgentNode{
+			{Name: "billing", Description: "billing desc", Kind: AgentKindChatModel, DisallowTransferToParent: true},
+			{Name: "support", Description: "support desc", Kind: AgentKindChatModel, DisallowTransferToParent: true},
+		},
+	}, node)
+
+	assert.Equal(t, `flowchart TD
+    n0["router<br/>(Router)"]
+    n1["billing<br/>(ChatModel)"]
+    n0 -->|"route"| n1
+    n2["support<br/>(ChatModel)"]
+    n0 -->|"route"| n2
+`, node.Mermaid())
+}
@@ -0,0 +1,378 @@
+/*
+ * Copyright 2025 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package eval runs adk agents over datasets and scores the results with metrics,
+// so that the regressions of agents can be measured, e.g. in CI with scripted models.
+package eval
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bytedance/sonic"
+
+	"github.com/cloudwego/eino/adk"
+	"github.com/cloudwego/eino/internal/safe"
+	"github.com/cloudwego/eino/schema"
+)
+
+// Case is an input of the dataset, with the optional expectations checked by the metrics.
+type Case struct {
+	ID string `json:"id"`
+
+	// Query is the user message, ignored if Messages is set.
+	Query    string        `json:"query,omitempty"`
+	Messages []adk.Message `json:"messages,omitempty"`
+
+	// ExpectedOutput is the expected content of the final assistant message, for ExactMatch and LLMJudge.
+	ExpectedOutput string `json:"expected_output,omitempty"`
+	// ExpectedPattern is the regular expression the final assistant message should match, for RegexMatch.
+	ExpectedPattern string `json:"expected_pattern,omitempty"`
+	// ExpectedToolCalls is the expected trajectory of the tool calls, for ToolTrajectory.
+	ExpectedToolCalls []*ToolCall `json:"expected_tool_calls,omitempty"`
+
+	Metadata map[string]any `json:"metadata,omitempty"`
+}
+
+// ToolCall is a tool call in the trajectory of the agent.
+type ToolCall struct {
+	Name string `json:"name"`
+	// Arguments in JSON, compared as JSON values by ToolTrajectory, and not compared if expected is empty.
+	Arguments string `json:"arguments,omitempty"`
+}
+
+// Run is what the agent does in a case, which is scored by the metrics.
+type Run struct {
+	Case *Case
+
+	// Output is the content of the last assistant message without tool calls.
+	Output    string
+	ToolCalls []*ToolCall
+	// Events of the run, with the message streams concatenated.
+	Events []*adk.AgentEvent
+	Err    error
+}
+
+// LoadCases reads the cases from JSON lines, one case per line.
+func LoadCases(r io.Reader) ([]*Case, error) {
+	var cases []*Case
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+
+		c := &Case{}
+		if err := sonic.UnmarshalString(text, c); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal case at line %d: %w", line, err)
+		}
+		if c.ID == "" {
+			c.ID = fmt.Sprintf("%d", line)
+		}
+		cases = append(cases, c)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return cases, nil
+}
+
+type Config struct {
+	// Agent to evaluate, required. Each case runs the agent with a new Runner.
+	Agent adk.Agent
+	// EnableStreaming of the Runner.
+	EnableStreaming bool
+	// RunOptions are passed to each run of the agent.
+	RunOptions []adk.AgentRunOption
+
+	// Metrics score each case, required.
+	Metrics []Metric
+
+	// Concurrency is the max number of cases running at the same time, optional, defaults to 1.
+	Concurrency int
+
+	// CaseTimeout limits the run of each case, optional, defaults to no limit.
+	CaseTimeout time.Duration
+}
+
+// Report is the result of an evaluation, which can be marshaled to JSON.
+type Report struct {
+	Cases   []*CaseResult             `json:"cases"`
+	Metrics map[string]*MetricSummary `json:"metrics"`
+
+	// Passed is the number of the cases passing all the applicable metrics without error.
+	Passed int `json:"passed"`
+	Failed int `json:"failed"`
+}
+
+type CaseResult struct {
+	ID       string  `json:"id"`
+	Output   string  `json:"output"`
+	Error    string  `json:"error,omitempty"`
+	Passed   bool    `json:"passed"`
+	Duration float64 `json:"duration_seconds"`
+
+	ToolCalls []*ToolCall `json:"tool_calls,omitempty"`
+	// Scores by the names of the metrics, the metrics not applicable to the case are absent.
+	Scores map[string]*Score `json:"scores"`
+	// Events in the JSON wire format of adk.EventEncoder, one frame for each event.
+	Events []json.RawMessage `json:"events"`
+}
+
+type MetricSummary struct {
+	// Cases is the number of the cases the metric applies to.
+	Cases    int     `json:"cases"`
+	Passed   int     `json:"passed"`
+	Mean     float64 `json:"mean"`
+	PassRate float64 `json:"pass_rate"`
+}
+
+// Evaluate runs the agent over the cases and scores them, the cases in the report are in the order of cases.
+// An error running the agent fails the case rather than the evaluation, while an error of a metric fails the evaluation.
+func Evaluate(ctx context.Context, conf *Config, cases []*Case) (*Report, error) {
+	if conf.Agent == nil {
+		return nil, errors.New("'Agent' is required")
+	}
+	if len(conf.Metrics) == 0 {
+		return nil, errors.New("'Metrics' is required")
+	}
+	names := make(map[string]bool, len(conf.Metrics))
+	for _, m := range conf.Metrics {
+		if names[m.Name()] {
+			return nil, fmt.Errorf("duplicate metric name: %s", m.Name())
+		}
+		names[m.Name()] = true
+	}
+
+	concurrency := conf.Concurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make([]*CaseResult, len(cases))
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		firstErr error
+	)
+	sem := make(chan struct{}, concurrency)
+	for i, c := range cases {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+		wg.Add(1)
+		go func(i int, c *Case) {
+			defer func() {
+				if panicErr := recover(); panicErr != nil {
+					once.Do(func() {
+						firstErr = safe.NewPanicErr(panicErr, debug.Stack())
+						cancel()
+					})
+				}
+				<-sem
+				wg.Done()
+			}()
+
+			result, err := evaluateCase(ctx, conf, c)
+			if err != nil {
+				once.Do(func() {
+					firstErr = fmt.Errorf("failed to evaluate case '%s': %w", c.ID, err)
+					cancel()
+				})
+				return
+			}
+			results[i] = result
+		}(i, c)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return newReport(conf.Metrics, results), nil
+}
+
+func evaluateCase(ctx context.Context, conf *Config, c *Case) (*CaseResult, error) {
+	start := time.Now()
+	run := runCase(ctx, conf, c)
+
+	result := &CaseResult{
+		ID:        c.ID,
+		Output:    run.Output,
+		Passed:    run.Err == nil,
+		Duration:  time.Since(start).Seconds(),
+		ToolCalls: run.ToolCalls,
+		Scores:    make(map[string]*Score),
+	}
+	if run.Err != nil {
+		result.Error = run.Err.Error()
+	}
+
+	encoder := adk.NewEventEncoder(func(_ string, frame []byte) error {
+		result.Events = append(result.Events, frame)
+		return nil
+	})
+	for _, event := range run.Events {
+		if err := encoder.Encode(event); err != nil {
+			return nil, fmt.Errorf("failed to encode event: %w", err)
+		}
+	}
+
+	for _, m := range conf.Metrics {
+		score, err := m.Score(ctx, run)
+		if err != nil {
+			return nil, fmt.Errorf("metric '%s' failed: %w", m.Name(), err)
+		}
+		if score == nil {
+			continue
+		}
+		result.Scores[m.Name()] = score
+		result.Passed = result.Passed && score.Passed
+	}
+
+	return result, nil
+}
+
+func runCase(ctx context.Context, conf *Config, c *Case) *Run {
+	if conf.CaseTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, conf.CaseTimeout)
+		defer cancel()
+	}
+
+	messages := c.Messages
+	if len(messages) == 0 {
+		messages = []adk.Message{schema.UserMessage(c.Query)}
+	}
+
+	run := &Run{Case: c}
+	iter := adk.NewRunner(ctx, adk.RunnerConfig{Agent: conf.Agent, EnableStreaming: conf.EnableStreaming}).
+		Run(ctx, messages, conf.RunOptions...)
+	defer iter.Close()
+
+	for {
+		event, ok := iter.Next()
+		if !ok {
+			break
+		}
+		if event.Err != nil {
+			run.Err = event.Err
+		}
+
+		if mv := getMessageVariant(event); mv != nil && mv.IsStreaming {
+			msg, err := mv.GetMessage()
+			if err != nil {
+				run.Err = err
+				continue
+			}
+			event.Output.MessageOutput = &adk.MessageVariant{Message: msg, Role: mv.Role, ToolName: mv.ToolName}
+		}
+		run.Events = append(run.Events, event)
+
+		mv := getMessageVariant(event)
+		if mv == nil || mv.Message == nil || mv.Message.Role != schema.Assistant {
+			continue
+		}
+		for _, tc := range mv.Message.ToolCalls {
+			run.ToolCalls = append(run.ToolCalls, &ToolCall{Name: tc.Function.Name, Arguments: tc.Function.Arguments})
+		}
+		if len(mv.Message.ToolCalls) == 0 {
+			run.Output = mv.Message.Content
+		}
+	}
+
+	if run.Err == nil && ctx.Err() != nil {
+		run.Err = ctx.Err()
+	}
+
+	return run
+}
+
+func getMessageVariant(event *adk.AgentEvent) *adk.MessageVariant {
+	if event.Output == nil {
+		return nil
+	}
+	return event.Output.MessageOutput
+}
+
+func newReport(metrics []Metric, results []*CaseResult) *Report {
+	r := &Report{Cases: results, Metrics: make(map[string]*MetricSummary, len(metrics))}
+	for _, m := range metrics {
+		r.Metrics[m.Name()] = &MetricSummary{}
+	}
+
+	for _, c := range results {
+		if c.Passed {
+			r.Passed++
+		} else {
+			r.Failed++
+		}
+
+		for name, score := range c.Scores {
+			s := r.Metrics[name]
+			s.Cases++
+			s.Mean += score.Value
+			if score.Passed {
+				s.Passed++
+			}
+		}
+	}
+
+	for _, s := range r.Metrics {
+		if s.Cases > 0 {
+			s.Mean /= float64(s.Cases)
+			s.PassRate = float64(s.Passed) / float64(s.Cases)
+		}
+	}
+
+	return r
+}
+
+// FailedCases returns the ids of the failed cases, sorted.
+func (r *Report) FailedCases() []string {
+	var ids []string
+	for _, c := range r.Cases {
+		if !c.Passed {
+			ids = append(ids, c.ID)
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
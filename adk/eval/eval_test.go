@@ -0,0 +1,175 @@
+/*
+ * Copyright 2025 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package eval
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/bytedance/sonic"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"github.com/cloudwego/eino/adk"
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/compose"
+	mockModel "github.com/cloudwego/eino/internal/mock/components/model"
+	"github.com/cloudwego/eino/schema"
+)
+
+type weatherTool struct{}
+
+func (t *weatherTool) Info(_ context.Context) (*schema.ToolInfo, error) {
+	return &schema.ToolInfo{Name: "get_weather", Desc: "get the weather of a city"}, nil
+}
+
+func (t *weatherTool) InvokableRun(_ context.Context, _ string, _ ...tool.Option) (string, error) {
+	return "sunny", nil
+}
+
+const dataset = `
+{"id": "weather", "query": "weather in Paris", "expected_output": "It's sunny in Paris.", "expected_tool_calls": [{"name": "get_weather", "arguments": "{\"city\": \"Paris\"}"}]}
+{"id": "greeting", "query": "hello", "expected_pattern": "^(hi|hello)"}
+{"id": "broken", "query": "break", "expected_output": "never"}
+`
+
+func TestEvaluate(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+
+	cm := mockModel.NewMockToolCallingChatModel(ctrl)
+	cm.EXPECT().WithTools(gomock.Any()).Return(cm, nil).AnyTimes()
+	cm.EXPECT().Generate(gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, input []*schema.Message, _ ...model.Option) (*schema.Message, error) {
+			last := input[len(input)-1]
+			switch {
+			case last.Role == schema.Tool:
+				return schema.AssistantMessage("It's sunny in Paris.", nil), nil
+			case last.Content == "weather in Paris":
+				return schema.AssistantMessage("", []schema.ToolCall{{
+					ID:       "1",
+					Function: schema.FunctionCall{Name: "get_weather", Arguments: `{"city":"Paris"}`},
+				}}), nil
+			case last.Content == "hello":
+				return schema.AssistantMessage("hi there", nil), nil
+			}
+			return nil, errors.New("model is down")
+		}).AnyTimes()
+
+	agent, err := adk.NewChatModelAgent(ctx, &adk.ChatModelAgentConfig{
+		Name:        "assistant",
+		Description: "assistant",
+		Model:       cm,
+		ToolsConfig: adk.ToolsConfig{ToolsNodeConfig: compose.ToolsNodeConfig{Tools: []tool.BaseTool{&weatherTool{}}}},
+	})
+	assert.NoError(t, err)
+
+	judge := mockModel.NewMockToolCallingChatModel(ctrl)
+	judge.EXPECT().Generate(gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, input []*schema.Message, _ ...model.Option) (*schema.Message, error) {
+			if strings.Contains(input[0].Content, "sunny") {
+				return schema.AssistantMessage("```json\n{\"score\": 0.9, \"reason\": \"correct\"}\n```", nil), nil
+			}
+			return schema.AssistantMessage(`{"score": 0.2, "reason": "no answer"}`, nil), nil
+		}).AnyTimes()
+	llmJudge, err := LLMJudge(&LLMJudgeConfig{Model: judge})
+	assert.NoError(t, err)
+
+	cases, err := LoadCases(strings.NewReader(dataset))
+	assert.NoError(t, err)
+	assert.Len(t, cases, 3)
+
+	report, err := Evaluate(ctx, &Config{
+		Agent:       agent,
+		Metrics:     []Metric{ExactMatch(), RegexMatch(), ToolTrajectory(TrajectoryExact), llmJudge},
+		Concurrency: 2,
+	}, cases)
+	assert.NoError(t, err)
+
+	assert.Equal(t, 1, report.Passed)
+	assert.Equal(t, []string{"broken", "greeting"}, report.FailedCases())
+
+	weather := report.Cases[0]
+	assert.Equal(t, "weather", weather.ID)
+	assert.Equal(t, "It's sunny in Paris.", weather.Output)
+	assert.Equal(t, []*ToolCall{{Name: "get_weather", Arguments: `{"city":"Paris"}`}}, weather.ToolCalls)
+	assert.Equal(t, map[string]*Score{
+		"exact_match":     {Value: 1, Passed: true},
+		"tool_trajectory": {Value: 1, Passed: true},
+		"llm_judge":       {Value: 0.9, Passed: true, Reason: "correct"},
+	}, weather.Scores)
+	assert.Len(t, weather.Events, 3)
+
+	greeting := report.Cases[1]
+	assert.True(t, greeting.Scores["regex_match"].Passed)
+	assert.Equal(t, &Score{Value: 0.2, Reason: "no answer"}, greeting.Scores["llm_judge"])
+	assert.False(t, greeting.Passed)
+
+	broken := report.Cases[2]
+	assert.Contains(t, broken.Error, "model is down")
+	assert.False(t, broken.Scores["exact_match"].Passed)
+
+	assert.Equal(t, &MetricSummary{Cases: 2, Passed: 1, Mean: 0.5, PassRate: 0.5}, report.Metrics["exact_match"])
+
+	b, err := sonic.Marshal(report)
+	assert.NoError(t, err)
+	assert.Contains(t, string(b), `"tool_trajectory":{"value":1,"passed":true}`)
+}
+
+func TestToolTrajectory(t *testing.T) {
+	ctx := context.Background()
+	run := &Run{
+		Case: &Case{ExpectedToolCalls: []*ToolCall{{Name: "search"}, {Name: "book", Arguments: `{"id": 1}`}}},
+		ToolCalls: []*ToolCall{
+			{Name: "search", Arguments: `{"q": "hotel"}`},
+			{Name: "search", Arguments: `{"q": "flight"}`},
+			{Name: "book", Arguments: `{"id":1}`},
+		},
+	}
+
+	s, err := ToolTrajectory(TrajectoryExact).Score(ctx, run)
+	assert.NoError(t, err)
+	assert.False(t, s.Passed)
+	assert.InDelta(t, 1.0/3, s.Value, 1e-9)
+
+	s, err = ToolTrajectory(TrajectoryInOrder).Score(ctx, run)
+	assert.NoError(t, err)
+	assert.Equal(t, &Score{Value: 1, Passed: true}, s)
+
+	run.ToolCalls = []*ToolCall{{Name: "book", Arguments: `{"id":2}`}, {Name: "search"}}
+	s, err = ToolTrajectory(TrajectoryAnyOrder).Score(ctx, run)
+	assert.NoError(t, err)
+	assert.Equal(t, 0.5, s.Value)
+	assert.Equal(t, `expected tool calls [search, book{"id": 1}], got [book{"id":2}, search]`, s.Reason)
+}
+
+func TestLLMJudgeUnparsableVerdict(t *testing.T) {
+	ctx := context.Background()
+	judge := mockModel.NewMockToolCallingChatModel(gomock.NewController(t))
+	judge.EXPECT().Generate(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(schema.AssistantMessage("looks good to me", nil), nil)
+	m, err := LLMJudge(&LLMJudgeConfig{Model: judge})
+	assert.NoError(t, err)
+
+	s, err := m.Score(ctx, &Run{Case: &Case{ExpectedOutput: "sunny"}, Output: "sunny"})
+	assert.NoError(t, err)
+	assert.False(t, s.Passed)
+	assert.Contains(t, s.Reason, "failed to parse verdict of judge")
+}
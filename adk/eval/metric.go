@@ -0,0 +1,295 @@
+/*
+ * Copyright 2025 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package eval
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/bytedance/sonic"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/internal"
+	"github.com/cloudwego/eino/schema"
+)
+
+// Metric scores the runs of the cases.
+type Metric interface {
+	Name() string
+	// Score returns nil if the metric isn't applicable to the case, e.g. the expectation it checks is absent.
+	Score(ctx context.Context, run *Run) (*Score, error)
+}
+
+type Score struct {
+	// Value between 0 and 1.
+	Value  float64 `json:"value"`
+	Passed bool    `json:"passed"`
+	Reason string  `json:"reason,omitempty"`
+}
+
+func boolScore(passed bool, reason string) *Score {
+	s := &Score{Passed: passed, Reason: reason}
+	if passed {
+		s.Value = 1
+	}
+	return s
+}
+
+// ExactMatch checks the output equals Case.ExpectedOutput, ignoring the leading and trailing spaces.
+func ExactMatch() Metric {
+	return &exactMatch{}
+}
+
+type exactMatch struct{}
+
+func (m *exactMatch) Name() string {
+	return "exact_match"
+}
+
+func (m *exactMatch) Score(_ context.Context, run *Run) (*Score, error) {
+	if run.Case.ExpectedOutput == "" {
+		return nil, nil
+	}
+
+	if strings.TrimSpace(run.Output) == strings.TrimSpace(run.Case.ExpectedOutput) {
+		return boolScore(true, ""), nil
+	}
+	return boolScore(false, fmt.Sprintf("expected %q, got %q", run.Case.ExpectedOutput, run.Output)), nil
+}
+
+// RegexMatch checks the output matches the regular expression Case.ExpectedPattern.
+func RegexMatch() Metric {
+	return &regexMatch{}
+}
+
+type regexMatch struct{}
+
+func (m *regexMatch) Name() string {
+	return "regex_match"
+}
+
+func (m *regexMatch) Score(_ context.Context, run *Run) (*Score, error) {
+	if run.Case.ExpectedPattern == "" {
+		return nil, nil
+	}
+
+	re, err := regexp.Compile(run.Case.ExpectedPattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid expected pattern of case '%s': %w", run.Case.ID, err)
+	}
+	if re.MatchString(run.Output) {
+		return boolScore(true, ""), nil
+	}
+	return boolScore(false, fmt.Sprintf("%q doesn't match %q", run.Output, run.Case.ExpectedPattern)), nil
+}
+
+type TrajectoryMatchMode string
+
+const (
+	// TrajectoryExact requires the tool calls to be the same as the expected ones.
+	TrajectoryExact TrajectoryMatchMode = "exact"
+	// TrajectoryInOrder requires the expected tool calls to appear in order, other tool calls are allowed in between.
+	TrajectoryInOrder TrajectoryMatchMode = "in_order"
+	// TrajectoryAnyOrder requires the expected tool calls to appear in any order.
+	TrajectoryAnyOrder TrajectoryMatchMode = "any_order"
+)
+
+// ToolTrajectory checks the tool calls against Case.ExpectedToolCalls.
+// The value of the score is the fraction of the tool calls matched.
+func ToolTrajectory(mode TrajectoryMatchMode) Metric {
+	return &toolTrajectory{mode: mode}
+}
+
+type toolTrajectory struct {
+	mode TrajectoryMatchMode
+}
+
+func (m *toolTrajectory) Name() string {
+	return "tool_trajectory"
+}
+
+func (m *toolTrajectory) Score(_ context.Context, run *Run) (*Score, error) {
+	expected := run.Case.ExpectedToolCalls
+	if len(expected) == 0 {
+		return nil, nil
+	}
+	actual := run.ToolCalls
+
+	matched, total := 0, len(expected)
+	switch m.mode {
+	case TrajectoryExact, "":
+		for i := 0; i < len(expected) && i < len(actual); i++ {
+			if toolCallMatches(expected[i], actual[i]) {
+				matched++
+			}
+		}
+		// the extra tool calls are mismatches too
+		total = max(len(expected), len(actual))
+	case TrajectoryInOrder:
+		j := 0
+		for _, tc := range actual {
+			if j < len(expected) && toolCallMatches(expected[j], tc) {
+				j++
+			}
+		}
+		matched = j
+	case TrajectoryAnyOrder:
+		used := make([]bool, len(actual))
+		for _, e := range expected {
+			for i, tc := range actual {
+				if !used[i] && toolCallMatches(e, tc) {
+					used[i] = true
+					matched++
+					break
+				}
+			}
+		}
+	default:
+		return nil, fmt.Errorf("unknown trajectory match mode: %s", m.mode)
+	}
+
+	s := &Score{Value: float64(matched) / float64(total), Passed: matched == total}
+	if !s.Passed {
+		s.Reason = fmt.Sprintf("expected tool calls %s, got %s", formatToolCalls(expected), formatToolCalls(actual))
+	}
+	return s, nil
+}
+
+func toolCallMatches(expected, actual *ToolCall) bool {
+	if expected.Name != actual.Name {
+		return false
+	}
+	if expected.Arguments == "" {
+		return true
+	}
+
+	var e, a any
+	if sonic.UnmarshalString(expected.Arguments, &e) != nil || sonic.UnmarshalString(actual.Arguments, &a) != nil {
+		return expected.Arguments == actual.Arguments
+	}
+	return reflect.DeepEqual(e, a)
+}
+
+func formatToolCalls(calls []*ToolCall) string {
+	parts := make([]string, len(calls))
+	for i, c := range calls {
+		parts[i] = c.Name
+		if c.Arguments != "" {
+			parts[i] += c.Arguments
+		}
+	}
+	return "[" + strings.Join(parts, ", ") + "]"
+}
+
+const (
+	defaultJudgePassThreshold = 0.5
+
+	judgePrompt = `You are evaluating the answer of an AI assistant.
+
+Criteria:
+%s
+
+Conversation:
+%s
+
+Reference answer:
+%s
+
+Assistant's answer:
+%s
+
+Rate how well the assistant's answer meets the criteria, from 0 (not at all) to 1 (perfectly).
+Respond with a JSON object ONLY: {"score": <number between 0 and 1>, "reason": "<short explanation>"}`
+	defaultJudgeCriteria = "The answer is correct, complete and consistent with the reference answer if any."
+)
+
+type LLMJudgeConfig struct {
+	// Model is the judge, required.
+	Model model.BaseChatModel
+	// Name of the metric, optional, defaults to "llm_judge".
+	Name string
+	// Criteria to judge the answer by, optional, defaults to correctness against Case.ExpectedOutput.
+	Criteria string
+	// PassThreshold is the min score to pass, optional, defaults to 0.5.
+	PassThreshold float64
+}
+
+// LLMJudge asks the model to score the output with the criteria.
+// The case fails if the reply of the model can't be parsed, with the parse error as the reason.
+func LLMJudge(conf *LLMJudgeConfig) (Metric, error) {
+	if conf.Model == nil {
+		return nil, errors.New("judge 'Model' is required")
+	}
+
+	m := &llmJudge{conf: *conf}
+	if m.conf.Name == "" {
+		m.conf.Name = "llm_judge"
+	}
+	if m.conf.Criteria == "" {
+		m.conf.Criteria = defaultJudgeCriteria
+	}
+	if m.conf.PassThreshold <= 0 {
+		m.conf.PassThreshold = defaultJudgePassThreshold
+	}
+	return m, nil
+}
+
+type llmJudge struct {
+	conf LLMJudgeConfig
+}
+
+func (m *llmJudge) Name() string {
+	return m.conf.Name
+}
+
+func (m *llmJudge) Score(ctx context.Context, run *Run) (*Score, error) {
+	var conversation strings.Builder
+	if len(run.Case.Messages) == 0 {
+		conversation.WriteString("user: " + run.Case.Query)
+	}
+	for _, msg := range run.Case.Messages {
+		conversation.WriteString(fmt.Sprintf("%s: %s\n", msg.Role, msg.Content))
+	}
+
+	reference := run.Case.ExpectedOutput
+	if reference == "" {
+		reference = "(none)"
+	}
+
+	out, err := m.conf.Model.Generate(ctx, []*schema.Message{schema.UserMessage(
+		fmt.Sprintf(judgePrompt, m.conf.Criteria, strings.TrimSpace(conversation.String()), reference, run.Output))})
+	if err != nil {
+		return nil, err
+	}
+
+	content := internal.TrimJSONCodeBlock(out.Content)
+
+	var verdict struct {
+		Score  float64 `json:"score"`
+		Reason string  `json:"reason"`
+	}
+	if err = sonic.UnmarshalString(content, &verdict); err != nil {
+		// an unparsable reply fails the case only, instead of the whole evaluation
+		return boolScore(false, fmt.Sprintf("failed to parse verdict of judge: %v", err)), nil
+	}
+
+	return &Score{Value: verdict.Score, Passed: verdict.Score >= m.conf.PassThreshold, Reason: verdict.Reason}, nil
+}
@@ -0,0 +1,588 @@
+/*
+ * Copyright 2025 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package adk
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sync"
+
+	"github.com/bytedance/sonic"
+
+	"github.com/cloudwego/eino/compose"
+	"github.com/cloudwego/eino/schema"
+)
+
+// The JSON wire format of AgentEvent is a sequence of frames, each frame is a JSON object:
+//
+//	{"type":"event","seq":1,"event":{"agent_name":"a","run_path":["a"],"output":{...},"action":{...},"error":{...}}}
+//	{"type":"chunk","seq":1,"chunk":{"role":"assistant","content":"hel"}}
+//	{"type":"stream_end","seq":1,"error":{"kind":"canceled","message":"context canceled"}}
+//
+// Every AgentEvent is encoded to an 'event' frame, seq increases by one for each event.
+// If the event contains a MessageStream, the 'event' frame is followed by 'chunk' frames of the same seq,
+// one for each chunk, and finally a 'stream_end' frame, whose error is set if the stream ended with an error.
+// CustomizedOutput, CustomizedAction, InterruptInfo.Data and InterruptPoint.Info are encoded as
+// {"type":"registered name","ptr":true,"value":...}, see RegisterEventValueType.
+const (
+	EventFrameTypeEvent     = "event"
+	EventFrameTypeChunk     = "chunk"
+	EventFrameTypeStreamEnd = "stream_end"
+)
+
+type EventErrorKind string
+
+const (
+	EventErrorKindUnknown          EventErrorKind = "unknown"
+	EventErrorKindCanceled         EventErrorKind = "canceled"
+	EventErrorKindDeadlineExceeded EventErrorKind = "deadline_exceeded"
+)
+
+// EventError is the error decoded from the JSON wire format of AgentEvent.
+type EventError struct {
+	Kind    EventErrorKind `json:"kind"`
+	Message string         `json:"message"`
+}
+
+func (e *EventError) Error() string {
+	return e.Message
+}
+
+func (e *EventError) Is(target error) bool {
+	switch e.Kind {
+	case EventErrorKindCanceled:
+		return target == context.Canceled
+	case EventErrorKindDeadlineExceeded:
+		return target == context.DeadlineExceeded
+	default:
+		return false
+	}
+}
+
+func toEventError(err error) *EventError {
+	if err == nil {
+		return nil
+	}
+
+	var ee *EventError
+	if errors.As(err, &ee) {
+		return ee
+	}
+
+	kind := EventErrorKindUnknown
+	if errors.Is(err, context.Canceled) {
+		kind = EventErrorKindCanceled
+	} else if errors.Is(err, context.DeadlineExceeded) {
+		kind = EventErrorKindDeadlineExceeded
+	}
+
+	return &EventError{Kind: kind, Message: err.Error()}
+}
+
+var (
+	eventValueTypes     = map[string]reflect.Type{}
+	eventValueTypeNames = map[reflect.Type]string{}
+	eventValueTypesMu   sync.RWMutex
+)
+
+func init() {
+	_ = RegisterEventValueType[string]("_eino_string")
+	_ = RegisterEventValueType[compose.InterruptInfo]("_eino_compose_interrupt_info")
+}
+
+// RegisterEventValueType registers a type of CustomizedOutput, CustomizedAction or interrupt info for the JSON wire format of AgentEvent,
+// so that the decoder can rebuild the value with the same type.
+// Both T and *T are handled after registration, and registering the same type with the same name again is a no-op.
+// Values of unregistered types are still encoded, but are decoded to generic JSON values, e.g. map[string]any.
+func RegisterEventValueType[T any](name string) error {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	eventValueTypesMu.Lock()
+	defer eventValueTypesMu.Unlock()
+
+	if nt, ok := eventValueTypes[name]; ok {
+		if nt == t {
+			return nil
+		}
+		return fmt.Errorf("name[%s] already registered to %s", name, nt.String())
+	}
+	if nn, ok := eventValueTypeNames[t]; ok {
+		return fmt.Errorf("type[%s] already registered to %s", t.String(), nn)
+	}
+
+	eventValueTypes[name] = t
+	eventValueTypeNames[t] = name
+	return nil
+}
+
+type typedValue struct {
+	Type  string          `json:"type,omitempty"`
+	Ptr   bool            `json:"ptr,omitempty"`
+	Value json.RawMessage `json:"value"`
+}
+
+func encodeTypedValue(v any) (*typedValue, error) {
+	if v == nil {
+		return nil, nil
+	}
+
+	raw, err := sonic.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal value of type %T: %w", v, err)
+	}
+
+	tv := &typedValue{Value: raw}
+	t := reflect.TypeOf(v)
+	if t.Kind() == reflect.Ptr {
+		tv.Ptr = true
+		t = t.Elem()
+	}
+
+	eventValueTypesMu.RLock()
+	tv.Type = eventValueTypeNames[t]
+	eventValueTypesMu.RUnlock()
+
+	return tv, nil
+}
+
+func decodeTypedValue(tv *typedValue) (any, error) {
+	if tv == nil {
+		return nil, nil
+	}
+
+	eventValueTypesMu.RLock()
+	t, ok := eventValueTypes[tv.Type]
+	eventValueTypesMu.RUnlock()
+
+	if !ok {
+		var v any
+		if err := sonic.Unmarshal(tv.Value, &v); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal value: %w", err)
+		}
+		return v, nil
+	}
+
+	pv := reflect.New(t)
+	if err := sonic.Unmarshal(tv.Value, pv.Interface()); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal value of type %s: %w", tv.Type, err)
+	}
+	if tv.Ptr {
+		return pv.Interface(), nil
+	}
+	return pv.Elem().Interface(), nil
+}
+
+type eventFrame struct {
+	Type  string          `json:"type"`
+	Seq   int             `json:"seq"`
+	Event *jsonEvent      `json:"event,omitempty"`
+	Chunk *schema.Message `json:"chunk,omitempty"`
+	Error *EventError     `json:"error,omitempty"`
+}
+
+type jsonEvent struct {
+	AgentName string      `json:"agent_name,omitempty"`
+	RunPath   []string    `json:"run_path,omitempty"`
+	Output    *jsonOutput `json:"output,omitempty"`
+	Action    *jsonAction `json:"action,omitempty"`
+	Error     *EventError `json:"error,omitempty"`
+}
+
+type jsonOutput struct {
+	MessageOutput    *jsonMessageVariant `json:"message_output,omitempty"`
+	CustomizedOutput *typedValue         `json:"customized_output,omitempty"`
+	MaxStepExhausted bool                `json:"max_step_exhausted,omitempty"`
+}
+
+type jsonMessageVariant struct {
+	IsStreaming bool            `json:"is_streaming,omitempty"`
+	Message     *schema.Message `json:"message,omitempty"`
+	Role        schema.RoleType `json:"role,omitempty"`
+	ToolName    string          `json:"tool_name,omitempty"`
+}
+
+type jsonAction struct {
+	Exit             bool             `json:"exit,omitempty"`
+	Interrupted      *jsonInterrupt   `json:"interrupted,omitempty"`
+	TransferToAgent  string           `json:"transfer_to_agent,omitempty"`
+	Guardrail        *GuardrailAction `json:"guardrail,omitempty"`
+	Route            *RouteAction     `json:"route,omitempty"`
+	CustomizedAction *typedValue      `json:"customized_action,omitempty"`
+}
+
+type jsonInterrupt struct {
+	Data   *typedValue           `json:"data,omitempty"`
+	Points []*jsonInterruptPoint `json:"points,omitempty"`
+}
+
+type jsonInterruptPoint struct {
+	ID   string      `json:"id"`
+	Info *typedValue `json:"info,omitempty"`
+}
+
+// EventEncoder encodes AgentEvents into frames of the JSON wire format.
+// It's not safe for concurrent use.
+type EventEncoder struct {
+	seq  int
+	emit func(frameType string, frame []byte) error
+}
+
+// NewEventEncoder creates an EventEncoder, emit is called once for each encoded frame with the type of the frame,
+// e.g. writing the frame as a line of ndjson, or as the data of a Server-Sent Event named by the type.
+func NewEventEncoder(emit func(frameType string, frame []byte) error) *EventEncoder {
+	return &EventEncoder{emit: emit}
+}
+
+// Encode encodes the event and emits its frames.
+// If the event contains a MessageStream, the stream is received until EOF, and one frame is emitted for each chunk,
+// so the event should not be consumed elsewhere. The stream is always closed after Encode.
+func (e *EventEncoder) Encode(event *AgentEvent) error {
+	e.seq++
+
+	je, err := toJSONEvent(event)
+	if err != nil {
+		if s := getEventMessageStream(event); s != nil {
+			s.Close()
+		}
+		return err
+	}
+
+	if err = e.emitFrame(&eventFrame{Type: EventFrameTypeEvent, Seq: e.seq, Event: je}); err != nil {
+		if s := getEventMessageStream(event); s != nil {
+			s.Close()
+		}
+		return err
+	}
+
+	s := getEventMessageStream(event)
+	if s == nil {
+		return nil
+	}
+	defer s.Close()
+
+	var streamErr error
+	for {
+		chunk, err_ := s.Recv()
+		if err_ == io.EOF {
+			break
+		}
+		if err_ != nil {
+			streamErr = err_
+			break
+		}
+
+		if err = e.emitFrame(&eventFrame{Type: EventFrameTypeChunk, Seq: e.seq, Chunk: chunk}); err != nil {
+			return err
+		}
+	}
+
+	return e.emitFrame(&eventFrame{Type: EventFrameTypeStreamEnd, Seq: e.seq, Error: toEventError(streamErr)})
+}
+
+func (e *EventEncoder) emitFrame(frame *eventFrame) error {
+	b, err := sonic.Marshal(frame)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event frame: %w", err)
+	}
+	return e.emit(frame.Type, b)
+}
+
+func getEventMessageStream(event *AgentEvent) MessageStream {
+	if event.Output == nil || event.Output.MessageOutput == nil || !event.Output.MessageOutput.IsStreaming {
+		return nil
+	}
+	return event.Output.MessageOutput.MessageStream
+}
+
+func toJSONEvent(event *AgentEvent) (*jsonEvent, error) {
+	je := &jsonEvent{
+		AgentName: event.AgentName,
+		RunPath:   event.RunPath,
+		Error:     toEventError(event.Err),
+	}
+
+	var err error
+	if o := event.Output; o != nil {
+		je.Output = &jsonOutput{MaxStepExhausted: o.MaxStepExhausted}
+		if mv := o.MessageOutput; mv != nil {
+			je.Output.MessageOutput = &jsonMessageVariant{
+				IsStreaming: mv.IsStreaming,
+				Role:        mv.Role,
+				ToolName:    mv.ToolName,
+			}
+			if !mv.IsStreaming {
+				je.Output.MessageOutput.Message = mv.Message
+			}
+		}
+		je.Output.CustomizedOutput, err = encodeTypedValue(o.CustomizedOutput)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if a := event.Action; a != nil {
+		je.Action = &jsonAction{Exit: a.Exit, Guardrail: a.Guardrail, Route: a.Route}
+		if a.TransferToAgent != nil {
+			je.Action.TransferToAgent = a.TransferToAgent.DestAgentName
+		}
+		if a.Interrupted != nil {
+			data := a.Interrupted.Data
+			if ti, ok := data.(*tempInterruptInfo); ok {
+				// the event of a ChatModelAgent run without the runner, encoded as the runner reports it
+				data = ti.info
+			}
+			ji := &jsonInterrupt{}
+			ji.Data, err = encodeTypedValue(data)
+			if err != nil {
+				return nil, err
+			}
+			for _, p := range a.Interrupted.Points() {
+				jp := &jsonInterruptPoint{ID: p.ID}
+				jp.Info, err = encodeTypedValue(p.Info)
+				if err != nil {
+					return nil, err
+				}
+				ji.Points = append(ji.Points, jp)
+			}
+			je.Action.Interrupted = ji
+		}
+		je.Action.CustomizedAction, err = encodeTypedValue(a.CustomizedAction)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return je, nil
+}
+
+func fromJSONEvent(je *jsonEvent) (*AgentEvent, error) {
+	event := &AgentEvent{
+		AgentName: je.AgentName,
+		RunPath:   je.RunPath,
+	}
+	if je.Error != nil {
+		event.Err = je.Error
+	}
+
+	var err error
+	if o := je.Output; o != nil {
+		event.Output = &AgentOutput{MaxStepExhausted: o.MaxStepExhausted}
+		if mv := o.MessageOutput; mv != nil {
+			event.Output.MessageOutput = &MessageVariant{
+				IsStreaming: mv.IsStreaming,
+				Message:     mv.Message,
+				Role:        mv.Role,
+				ToolName:    mv.ToolName,
+			}
+		}
+		event.Output.CustomizedOutput, err = decodeTypedValue(o.CustomizedOutput)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if a := je.Action; a != nil {
+		event.Action = &AgentAction{Exit: a.Exit, Guardrail: a.Guardrail, Route: a.Route}
+		if a.TransferToAgent != "" {
+			event.Action.TransferToAgent = &TransferToAgentAction{DestAgentName: a.TransferToAgent}
+		}
+		if a.Interrupted != nil {
+			info := &InterruptInfo{}
+			info.Data, err = decodeTypedValue(a.Interrupted.Data)
+			if err != nil {
+				return nil, err
+			}
+			for _, jp := range a.Interrupted.Points {
+				p := &InterruptPoint{ID: jp.ID}
+				p.Info, err = decodeTypedValue(jp.Info)
+				if err != nil {
+					return nil, err
+				}
+				info.points = append(info.points, p)
+			}
+			event.Action.Interrupted = info
+		}
+		event.Action.CustomizedAction, err = decodeTypedValue(a.CustomizedAction)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return event, nil
+}
+
+// EventDecoder rebuilds AgentEvents from frames of the JSON wire format.
+// It's not safe for concurrent use.
+type EventDecoder struct {
+	seq    int
+	stream *AsyncGenerator[*chunkOrErr]
+}
+
+type chunkOrErr struct {
+	chunk Message
+	err   error
+}
+
+func NewEventDecoder() *EventDecoder {
+	return &EventDecoder{}
+}
+
+// Decode decodes a frame.
+// For 'event' frames, the rebuilt AgentEvent is returned. If the event is streaming,
+// its MessageStream receives the chunks decoded from the following 'chunk' frames, and for other frames nil is returned.
+func (d *EventDecoder) Decode(frame []byte) (*AgentEvent, error) {
+	f := &eventFrame{}
+	if err := sonic.Unmarshal(frame, f); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal event frame: %w", err)
+	}
+
+	switch f.Type {
+	case EventFrameTypeEvent:
+		if f.Event == nil {
+			return nil, fmt.Errorf("event frame[%d] has no event", f.Seq)
+		}
+		d.closeStream(io.ErrUnexpectedEOF)
+
+		event, err := fromJSONEvent(f.Event)
+		if err != nil {
+			return nil, err
+		}
+		d.seq = f.Seq
+
+		if mv := event.Output; mv != nil && mv.MessageOutput != nil && mv.MessageOutput.IsStreaming {
+			mv.MessageOutput.MessageStream, d.stream = newDecodedMessageStream()
+		}
+
+		return event, nil
+	case EventFrameTypeChunk:
+		if d.stream == nil || f.Seq != d.seq {
+			return nil, fmt.Errorf("unexpected chunk frame[%d]", f.Seq)
+		}
+		d.stream.Send(&chunkOrErr{chunk: f.Chunk})
+		return nil, nil
+	case EventFrameTypeStreamEnd:
+		if d.stream == nil || f.Seq != d.seq {
+			return nil, fmt.Errorf("unexpected stream end frame[%d]", f.Seq)
+		}
+		if f.Error != nil {
+			d.closeStream(f.Error)
+		} else {
+			d.closeStream(nil)
+		}
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("unknown event frame type: %s", f.Type)
+	}
+}
+
+// Close ends the MessageStream of the last event with io.ErrUnexpectedEOF if its stream end frame has not been decoded.
+func (d *EventDecoder) Close() {
+	d.closeStream(io.ErrUnexpectedEOF)
+}
+
+func (d *EventDecoder) closeStream(err error) {
+	if d.stream == nil {
+		return
+	}
+	if err != nil {
+		d.stream.Send(&chunkOrErr{err: err})
+	}
+	d.stream.Close()
+	d.stream = nil
+}
+
+func newDecodedMessageStream() (MessageStream, *AsyncGenerator[*chunkOrErr]) {
+	iter, gen := NewAsyncIteratorPair[*chunkOrErr]()
+	sr, sw := schema.Pipe[Message](1)
+
+	go func() {
+		defer sw.Close()
+		for {
+			c, ok := iter.Next()
+			if !ok {
+				return
+			}
+			if closed := sw.Send(c.chunk, c.err); closed {
+				return
+			}
+		}
+	}()
+
+	return sr, gen
+}
+
+// DecodeEvents reads newline delimited frames from r, and rebuilds AgentEvents from them.
+func DecodeEvents(r io.Reader) *AsyncIterator[*AgentEvent] {
+	iterator, generator := NewAsyncIteratorPair[*AgentEvent]()
+
+	go func() {
+		d := NewEventDecoder()
+		defer func() {
+			d.Close()
+			generator.Close()
+		}()
+
+		dec := json.NewDecoder(r)
+		for {
+			var raw json.RawMessage
+			err := dec.Decode(&raw)
+			if err == io.EOF {
+				return
+			}
+			if err != nil {
+				generator.Send(&AgentEvent{Err: fmt.Errorf("failed to read event frame: %w", err)})
+				return
+			}
+
+			event, err := d.Decode(raw)
+			if err != nil {
+				generator.Send(&AgentEvent{Err: err})
+				return
+			}
+			if event != nil {
+				generator.Send(event)
+			}
+		}
+	}()
+
+	return iterator
+}
+
+// EncodeEvents encodes all events from iter as newline delimited frames to w.
+func EncodeEvents(w io.Writer, iter *AsyncIterator[*AgentEvent]) error {
+	enc := NewEventEncoder(func(_ string, frame []byte) error {
+		_, err := w.Write(append(frame, '\n'))
+		return err
+	})
+
+	for {
+		event, ok := iter.Next()
+		if !ok {
+			return nil
+		}
+		if err := enc.Encode(event); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,183 @@
+/*
+ * Copyright 2025 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package adk
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cloudwego/eino/compose"
+	"github.com/cloudwego/eino/schema"
+)
+
+type codecCustomizedOutput struct {
+	Score int `json:"score"`
+}
+
+func TestEventCodec(t *testing.T) {
+	assert.NoError(t, RegisterEventValueType[codecCustomizedOutput]("codec_customized_output"))
+	assert.NoError(t, RegisterEventValueType[codecCustomizedOutput]("codec_customized_output"))
+	assert.Error(t, RegisterEventValueType[string]("codec_customized_output"))
+	assert.Error(t, RegisterEventValueType[*codecCustomizedOutput]("codec_customized_output_2"))
+
+	sr, sw := schema.Pipe[Message](3)
+	sw.Send(schema.AssistantMessage("hel", nil), nil)
+	sw.Send(schema.AssistantMessage("lo", nil), nil)
+	sw.Send(nil, context.Canceled)
+	sw.Close()
+
+	events := []*AgentEvent{
+		{
+			AgentName: "a",
+			RunPath:   []string{"a"},
+			Output: &AgentOutput{
+				MessageOutput: &MessageVariant{
+					Message: schema.AssistantMessage("hi", nil),
+					Role:    schema.Assistant,
+				},
+				CustomizedOutput: &codecCustomizedOutput{Score: 1},
+				MaxStepExhausted: true,
+			},
+		},
+		{
+			AgentName: "a",
+			RunPath:   []string{"a"},
+			Output: &AgentOutput{
+				MessageOutput: &MessageVariant{
+					IsStreaming:   true,
+					MessageStream: sr,
+					Role:          schema.Assistant,
+				},
+			},
+		},
+		{
+			AgentName: "a",
+			RunPath:   []string{"a"},
+			Action: &AgentAction{
+				TransferToAgent:  &TransferToAgentAction{DestAgentName: "b"},
+				Guardrail:        &GuardrailAction{Guardrail: "g", Stage: GuardrailStageOutput, Verdict: GuardrailRewrite},
+				Route:            &RouteAction{Agents: []string{"a", "b"}, Reason: "r"},
+				CustomizedAction: map[string]any{"k": "v"},
+			},
+		},
+		{
+			AgentName: "b",
+			RunPath:   []string{"a", "b"},
+			Action: &AgentAction{
+				Interrupted: &InterruptInfo{
+					Data:   "need approval",
+					points: []*InterruptPoint{{ID: "a;b", Info: codecCustomizedOutput{Score: 2}}},
+				},
+			},
+		},
+		{
+			AgentName: "b",
+			RunPath:   []string{"a", "b"},
+			Action: &AgentAction{
+				Interrupted: &InterruptInfo{
+					Data: &tempInterruptInfo{
+						info: &compose.InterruptInfo{BeforeNodes: []string{"ToolNode"}},
+						data: []byte("checkpoint"),
+					},
+				},
+			},
+		},
+		{
+			AgentName: "b",
+			RunPath:   []string{"a", "b"},
+			Err:       context.DeadlineExceeded,
+		},
+	}
+
+	iter, gen := NewAsyncIteratorPair[*AgentEvent]()
+	for _, e := range events {
+		gen.Send(e)
+	}
+	gen.Close()
+
+	buf := &bytes.Buffer{}
+	assert.NoError(t, EncodeEvents(buf, iter))
+	assert.Equal(t, 9, strings.Count(buf.String(), "\n"))
+
+	decoded := DecodeEvents(buf)
+
+	e, ok := decoded.Next()
+	assert.True(t, ok)
+	assert.Equal(t, "hi", e.Output.MessageOutput.Message.Content)
+	assert.Equal(t, &codecCustomizedOutput{Score: 1}, e.Output.CustomizedOutput)
+	assert.True(t, e.Output.MaxStepExhausted)
+
+	e, ok = decoded.Next()
+	assert.True(t, ok)
+	assert.True(t, e.Output.MessageOutput.IsStreaming)
+	chunk, err := e.Output.MessageOutput.MessageStream.Recv()
+	assert.NoError(t, err)
+	assert.Equal(t, "hel", chunk.Content)
+	chunk, err = e.Output.MessageOutput.MessageStream.Recv()
+	assert.NoError(t, err)
+	assert.Equal(t, "lo", chunk.Content)
+	_, err = e.Output.MessageOutput.MessageStream.Recv()
+	assert.True(t, errors.Is(err, context.Canceled))
+
+	e, ok = decoded.Next()
+	assert.True(t, ok)
+	assert.Equal(t, "b", e.Action.TransferToAgent.DestAgentName)
+	assert.Equal(t, &GuardrailAction{Guardrail: "g", Stage: GuardrailStageOutput, Verdict: GuardrailRewrite}, e.Action.Guardrail)
+	assert.Equal(t, &RouteAction{Agents: []string{"a", "b"}, Reason: "r"}, e.Action.Route)
+	assert.Equal(t, map[string]any{"k": "v"}, e.Action.CustomizedAction)
+
+	e, ok = decoded.Next()
+	assert.True(t, ok)
+	assert.Equal(t, []string{"a", "b"}, e.RunPath)
+	assert.Equal(t, "need approval", e.Action.Interrupted.Data)
+	assert.Equal(t, []*InterruptPoint{{ID: "a;b", Info: codecCustomizedOutput{Score: 2}}}, e.Action.Interrupted.Points())
+
+	e, ok = decoded.Next()
+	assert.True(t, ok)
+	assert.Equal(t, &compose.InterruptInfo{BeforeNodes: []string{"ToolNode"}}, e.Action.Interrupted.Data)
+
+	e, ok = decoded.Next()
+	assert.True(t, ok)
+	assert.True(t, errors.Is(e.Err, context.DeadlineExceeded))
+
+	_, ok = decoded.Next()
+	assert.False(t, ok)
+}
+
+func TestEventDecoderUnexpectedEOF(t *testing.T) {
+	d := NewEventDecoder()
+	e, err := d.Decode([]byte(`{"type":"event","seq":1,"event":{"output":{"message_output":{"is_streaming":true,"role":"assistant"}}}}`))
+	assert.NoError(t, err)
+	_, err = d.Decode([]byte(`{"type":"chunk","seq":1,"chunk":{"role":"assistant","content":"a"}}`))
+	assert.NoError(t, err)
+	_, err = d.Decode([]byte(`{"type":"chunk","seq":2,"chunk":{"role":"assistant","content":"a"}}`))
+	assert.Error(t, err)
+	d.Close()
+
+	s := e.Output.MessageOutput.MessageStream
+	chunk, err := s.Recv()
+	assert.NoError(t, err)
+	assert.Equal(t, "a", chunk.Content)
+	_, err = s.Recv()
+	assert.Equal(t, io.ErrUnexpectedEOF, err)
+}
@@ -43,6 +43,7 @@ type flowAgent struct {
 
 	disallowTransferToParent bool
 	historyRewriter          HistoryRewriter
+	guardrails               *GuardrailsConfig
 
 	checkPointStore compose.CheckPointStore
 }
@@ -54,6 +55,7 @@ func (a *flowAgent) deepCopy() *flowAgent {
 		parentAgent:              a.parentAgent,
 		disallowTransferToParent: a.disallowTransferToParent,
 		historyRewriter:          a.historyRewriter,
+		guardrails:               a.guardrails,
 		checkPointStore:          a.checkPointStore,
 	}
 
@@ -281,20 +283,38 @@ func (a *flowAgent) Run(ctx context.Context, input *AgentInput, opts ...AgentRun
 
 	input, err := a.genAgentInput(ctx, runCtx)
 	if err != nil {
-		iterator, generator := NewAsyncIteratorPair[*AgentEvent]()
+		iterator, generator := newEventIteratorPair(ctx)
 		generator.Send(&AgentEvent{Err: err})
 		generator.Close()
 
 		return iterator
 	}
 
+	if a.guardrails != nil {
+		var blocked *AgentEvent
+		input, blocked, err = a.guardrails.checkInput(ctx, input)
+		if err != nil || blocked != nil {
+			iterator, generator := newEventIteratorPair(ctx)
+			if err != nil {
+				generator.Send(&AgentEvent{Err: err})
+			} else {
+				blocked.AgentName, blocked.RunPath = agentName, runCtx.RunPath
+				runCtx.Session.addEvent(blocked)
+				generator.Send(blocked)
+			}
+			generator.Close()
+
+			return iterator
+		}
+	}
+
 	if wf, ok := a.Agent.(*workflowAgent); ok {
-		return wf.Run(ctx, input, opts...)
+		return a.guardOutput(ctx, wf.Run(ctx, input, opts...))
 	}
 
-	aIter := a.Agent.Run(ctx, input, filterOptions(agentName, opts)...)
+	aIter := a.guardOutput(ctx, a.Agent.Run(ctx, input, filterOptions(agentName, opts)...))
 
-	iterator, generator := NewAsyncIteratorPair[*AgentEvent]()
+	iterator, generator := newEventIteratorPair(ctx)
 
 	go a.run(ctx, runCtx, aIter, generator, opts...)
 
@@ -313,7 +333,7 @@ func (a *flowAgent) Resume(ctx context.Context, info *ResumeInfo, opts ...AgentR
 		// go to target flow agent
 		targetAgent := recursiveGetAgent(ctx, a, targetName)
 		if targetAgent == nil {
-			iterator, generator := NewAsyncIteratorPair[*AgentEvent]()
+			iterator, generator := newEventIteratorPair(ctx)
 			generator.Send(&AgentEvent{Err: fmt.Errorf("failed to resume agent: cannot find agent: %s", agentName)})
 			generator.Close()
 			return iterator
@@ -321,26 +341,33 @@ func (a *flowAgent) Resume(ctx context.Context, info *ResumeInfo, opts ...AgentR
 		return targetAgent.Resume(ctx, info, opts...)
 	}
 	if wf, ok := a.Agent.(*workflowAgent); ok {
-		return wf.Resume(ctx, info, opts...)
+		return a.guardOutput(ctx, wf.Resume(ctx, info, opts...))
 	}
 
 	// resume current agent
 	ra, ok := a.Agent.(ResumableAgent)
 	if !ok {
-		iterator, generator := NewAsyncIteratorPair[*AgentEvent]()
+		iterator, generator := newEventIteratorPair(ctx)
 		generator.Send(&AgentEvent{Err: fmt.Errorf("failed to resume agent: target agent[%s] isn't resumable", agentName)})
 		generator.Close()
 
 		return iterator
 	}
-	iterator, generator := NewAsyncIteratorPair[*AgentEvent]()
-	aIter := ra.Resume(ctx, info, opts...)
+	iterator, generator := newEventIteratorPair(ctx)
+	aIter := a.guardOutput(ctx, ra.Resume(ctx, info, opts...))
 
 	go a.run(ctx, runCtx, aIter, generator, opts...)
 
 	return iterator
 }
 
+func (a *flowAgent) guardOutput(ctx context.Context, aIter *AsyncIterator[*AgentEvent]) *AsyncIterator[*AgentEvent] {
+	if a.guardrails == nil {
+		return aIter
+	}
+	return a.guardrails.guardOutput(ctx, aIter)
+}
+
 func (a *flowAgent) run(
 	ctx context.Context,
 	runCtx *runContext,
@@ -384,6 +411,12 @@ func (a *flowAgent) run(
 	if lastEvent != nil && lastEvent.Action != nil {
 		action := lastEvent.Action
 		if action.Interrupted != nil {
+			if len(action.Interrupted.points) == 0 {
+				action.Interrupted.points = []*InterruptPoint{{
+					ID:   GetInterruptID(ctx),
+					Info: action.Interrupted.Data,
+				}}
+			}
 			appendInterruptRunCtx(ctx, runCtx)
 			generator.Send(lastEvent)
 			return
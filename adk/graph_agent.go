@@ -0,0 +1,265 @@
+/*
+ * Copyright 2025 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package adk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+
+	"github.com/cloudwego/eino/callbacks"
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/compose"
+	"github.com/cloudwego/eino/internal/safe"
+	"github.com/cloudwego/eino/schema"
+	ub "github.com/cloudwego/eino/utils/callbacks"
+)
+
+// GraphCompiler is a graph to be compiled by the graph agent,
+// e.g. *compose.Graph[[]Message, Message], *compose.Chain[[]Message, Message] or *compose.Workflow[[]Message, Message].
+type GraphCompiler interface {
+	Compile(ctx context.Context, opts ...compose.GraphCompileOption) (compose.Runnable[[]Message, Message], error)
+}
+
+type GraphAgentConfig struct {
+	Name        string
+	Description string
+
+	// Graph is compiled once by the agent with CompileOptions, and its interrupts are supported.
+	Graph GraphCompiler
+	// CompileOptions of Graph, optional. The CheckPointStore in them is replaced by the one of the agent.
+	CompileOptions []compose.GraphCompileOption
+
+	// Runnable is the compiled graph, used if Graph is nil. One of Graph and Runnable is required.
+	// Its interrupts aren't supported, because its checkpoints can't be kept by the agent.
+	Runnable compose.Runnable[[]Message, Message]
+
+	// EmitOutput emits the output of the graph as the last event.
+	// Set it if the output isn't the message of the last ChatModel node, e.g. it's post-processed by a lambda.
+	EmitOutput bool
+}
+
+// NewGraphAgent wraps a graph taking the input messages and giving the answer as an agent,
+// so that it can be a sub-agent of the other agents, e.g. a supervisor or a sequential agent.
+// The messages of the ChatModel and the Tool nodes, including those in the subgraphs, are emitted as events,
+// interrupts of the graph become AgentAction.Interrupted, and Resume resumes the graph from its checkpoint.
+func NewGraphAgent(ctx context.Context, conf *GraphAgentConfig) (ResumableAgent, error) {
+	if conf.Name == "" {
+		return nil, errors.New("agent 'Name' is required")
+	}
+	if conf.Description == "" {
+		return nil, errors.New("agent 'Description' is required")
+	}
+
+	runnable := conf.Runnable
+	if conf.Graph != nil {
+		opts := append(conf.CompileOptions[:len(conf.CompileOptions):len(conf.CompileOptions)],
+			compose.WithCheckPointStore(&graphAgentCheckPointStore{}))
+
+		var err error
+		runnable, err = conf.Graph.Compile(ctx, opts...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compile graph of agent '%s': %w", conf.Name, err)
+		}
+	}
+	if runnable == nil {
+		return nil, errors.New("one of 'Graph' and 'Runnable' is required")
+	}
+
+	return &graphAgent{
+		name:          conf.Name,
+		description:   conf.Description,
+		runnable:      runnable,
+		interruptible: conf.Graph != nil,
+		emitOutput:    conf.EmitOutput,
+	}, nil
+}
+
+type graphAgent struct {
+	name          string
+	description   string
+	runnable      compose.Runnable[[]Message, Message]
+	interruptible bool
+	emitOutput    bool
+}
+
+type graphAgentRunOptions struct {
+	composeOptions []compose.Option
+}
+
+// WithGraphOptions passes the options to the runs of the graph agents, see NewGraphAgent,
+// and the agents wrapped by NewReactAgentAdapter and NewHostMultiAgentAdapter.
+// Use AgentRunOption.DesignateAgent to pass them to some of the graph agents only.
+func WithGraphOptions(opts ...compose.Option) AgentRunOption {
+	return WrapImplSpecificOptFn(func(o *graphAgentRunOptions) {
+		o.composeOptions = append(o.composeOptions, opts...)
+	})
+}
+
+func (a *graphAgent) Name(_ context.Context) string {
+	return a.name
+}
+
+func (a *graphAgent) Description(_ context.Context) string {
+	return a.description
+}
+
+func (a *graphAgent) Run(ctx context.Context, input *AgentInput, opts ...AgentRunOption) *AsyncIterator[*AgentEvent] {
+	return a.run(ctx, input.Messages, input.EnableStreaming, newEmptyStore(), opts)
+}
+
+func (a *graphAgent) Resume(ctx context.Context, info *ResumeInfo, opts ...AgentRunOption) *AsyncIterator[*AgentEvent] {
+	data, ok := info.Data.([]byte)
+	if !ok {
+		iterator, generator := newEventIteratorPair(ctx)
+		generator.Send(&AgentEvent{Err: fmt.Errorf("type of InterruptInfo.Data is expected to []byte, actual: %T", info.Data)})
+		generator.Close()
+		return iterator
+	}
+
+	return a.run(ctx, nil, info.EnableStreaming, newResumeStore(data), opts)
+}
+
+func (a *graphAgent) run(ctx context.Context, msgs []Message, enableStreaming bool, store *mockStore,
+	opts []AgentRunOption) *AsyncIterator[*AgentEvent] {
+
+	iterator, generator := newEventIteratorPair(ctx)
+
+	o := GetImplSpecificOptions(&graphAgentRunOptions{}, opts...)
+	co := append(o.composeOptions[:len(o.composeOptions):len(o.composeOptions)], genGraphAgentCallbacks(generator))
+	co = appendInterruptHandle(co, opts)
+	if a.interruptible {
+		co = append(co, compose.WithCheckPointID(mockCheckPointID))
+	}
+
+	go func() {
+		defer func() {
+			panicErr := recover()
+			if panicErr != nil {
+				e := safe.NewPanicErr(panicErr, debug.Stack())
+				generator.Send(&AgentEvent{Err: e})
+			}
+
+			generator.Close()
+		}()
+
+		ctx = context.WithValue(ctx, graphAgentStoreKey{}, store)
+
+		var (
+			msg       Message
+			msgStream MessageStream
+			err       error
+		)
+		if enableStreaming {
+			msgStream, err = a.runnable.Stream(ctx, msgs, co...)
+		} else {
+			msg, err = a.runnable.Invoke(ctx, msgs, co...)
+		}
+		if err != nil {
+			generator.Send(a.errorEvent(ctx, err, store))
+			return
+		}
+
+		if a.emitOutput {
+			generator.Send(EventFromMessage(msg, msgStream, schema.Assistant, ""))
+		} else if msgStream != nil {
+			msgStream.Close()
+		}
+	}()
+
+	return iterator
+}
+
+func (a *graphAgent) errorEvent(ctx context.Context, err error, store *mockStore) *AgentEvent {
+	info, ok := compose.ExtractInterruptInfo(err)
+	if !ok {
+		return &AgentEvent{Err: err}
+	}
+
+	if !a.interruptible {
+		return &AgentEvent{Err: fmt.Errorf("interrupt of agent '%s' isn't supported, set the graph to 'Graph' instead of 'Runnable': %w", a.name, err)}
+	}
+
+	data, existed, _ := store.Get(ctx, mockCheckPointID)
+	if !existed {
+		return &AgentEvent{Err: fmt.Errorf("interrupt has happened, but cannot find interrupt info: %w", err)}
+	}
+
+	return &AgentEvent{Action: &AgentAction{
+		Interrupted: &InterruptInfo{
+			Data:   &tempInterruptInfo{data: data, info: info},
+			points: genInterruptPoints(ctx, info, nil),
+		},
+	}}
+}
+
+type graphAgentStoreKey struct{}
+
+// graphAgentCheckPointStore keeps the checkpoint of the graph in the store of the running agent,
+// which is in turn kept in the checkpoint of the Runner.
+type graphAgentCheckPointStore struct{}
+
+func (s *graphAgentCheckPointStore) Get(ctx context.Context, checkPointID string) ([]byte, bool, error) {
+	store, ok := ctx.Value(graphAgentStoreKey{}).(*mockStore)
+	if !ok {
+		return nil, false, nil
+	}
+	return store.Get(ctx, checkPointID)
+}
+
+func (s *graphAgentCheckPointStore) Set(ctx context.Context, checkPointID string, checkPoint []byte) error {
+	store, ok := ctx.Value(graphAgentStoreKey{}).(*mockStore)
+	if !ok {
+		return errors.New("graph isn't running as an agent")
+	}
+	return store.Set(ctx, checkPointID, checkPoint)
+}
+
+func genGraphAgentCallbacks(generator *AsyncGenerator[*AgentEvent]) compose.Option {
+	cmHandler := &ub.ModelCallbackHandler{
+		OnEnd: func(ctx context.Context, _ *callbacks.RunInfo, output *model.CallbackOutput) context.Context {
+			generator.Send(EventFromMessage(output.Message, nil, schema.Assistant, ""))
+			return ctx
+		},
+		OnEndWithStreamOutput: func(ctx context.Context, _ *callbacks.RunInfo, output *schema.StreamReader[*model.CallbackOutput]) context.Context {
+			out := schema.StreamReaderWithConvert(output, func(in *model.CallbackOutput) (Message, error) {
+				return in.Message, nil
+			})
+			generator.Send(EventFromMessage(nil, out, schema.Assistant, ""))
+			return ctx
+		},
+	}
+	toolHandler := &ub.ToolCallbackHandler{
+		OnEnd: func(ctx context.Context, runInfo *callbacks.RunInfo, output *tool.CallbackOutput) context.Context {
+			msg := schema.ToolMessage(output.Response, compose.GetToolCallID(ctx), schema.WithToolName(runInfo.Name))
+			generator.Send(EventFromMessage(msg, nil, schema.Tool, runInfo.Name))
+			return ctx
+		},
+		OnEndWithStreamOutput: func(ctx context.Context, runInfo *callbacks.RunInfo, output *schema.StreamReader[*tool.CallbackOutput]) context.Context {
+			toolCallID := compose.GetToolCallID(ctx)
+			out := schema.StreamReaderWithConvert(output, func(in *tool.CallbackOutput) (Message, error) {
+				return schema.ToolMessage(in.Response, toolCallID, schema.WithToolName(runInfo.Name)), nil
+			})
+			generator.Send(EventFromMessage(nil, out, schema.Tool, runInfo.Name))
+			return ctx
+		},
+	}
+
+	return compose.WithCallbacks(ub.NewHandlerHelper().ChatModel(cmHandler).Tool(toolHandler).Handler())
+}
@@ -0,0 +1,131 @@
+/*
+ * Copyright 2025 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package adk
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/compose"
+	mockModel "github.com/cloudwego/eino/internal/mock/components/model"
+	"github.com/cloudwego/eino/schema"
+)
+
+func TestGraphAgent(t *testing.T) {
+	ctx := context.Background()
+
+	newGraph := func(cm model.BaseChatModel) *compose.Graph[[]Message, Message] {
+		g := compose.NewGraph[[]Message, Message]()
+		assert.NoError(t, g.AddChatModelNode("model", cm))
+		assert.NoError(t, g.AddLambdaNode("review", compose.InvokableLambda(func(ctx context.Context, msg Message) (Message, error) {
+			return schema.AssistantMessage("reviewed "+msg.Content, nil), nil
+		})))
+		assert.NoError(t, g.AddEdge(compose.START, "model"))
+		assert.NoError(t, g.AddEdge("model", "review"))
+		assert.NoError(t, g.AddEdge("review", compose.END))
+		return g
+	}
+
+	collect := func(iter *AsyncIterator[*AgentEvent]) (contents []string, interrupted bool) {
+		for {
+			event, ok := iter.Next()
+			if !ok {
+				return contents, interrupted
+			}
+			assert.NoError(t, event.Err)
+			if event.Action != nil && event.Action.Interrupted != nil {
+				interrupted = true
+			}
+			if event.Output != nil && event.Output.MessageOutput != nil {
+				msg, err := event.Output.MessageOutput.GetMessage()
+				assert.NoError(t, err)
+				contents = append(contents, event.AgentName+": "+msg.Content)
+			}
+		}
+	}
+
+	t.Run("in sequential agent with interrupt", func(t *testing.T) {
+		cm := &myModel{messages: []*schema.Message{schema.AssistantMessage("draft", nil)}}
+		ga, err := NewGraphAgent(ctx, &GraphAgentConfig{
+			Name:           "pipeline",
+			Description:    "pipeline",
+			Graph:          newGraph(cm),
+			CompileOptions: []compose.GraphCompileOption{compose.WithInterruptBeforeNodes([]string{"review"})},
+			EmitOutput:     true,
+		})
+		assert.NoError(t, err)
+
+		a, err := NewSequentialAgent(ctx, &SequentialAgentConfig{
+			Name:        "seq",
+			Description: "seq",
+			SubAgents: []Agent{ga, newMockAgent("next", "next", []*AgentEvent{
+				{Output: &AgentOutput{MessageOutput: &MessageVariant{Message: schema.AssistantMessage("next", nil)}}},
+			})},
+		})
+		assert.NoError(t, err)
+
+		runner := NewRunner(ctx, RunnerConfig{Agent: a, CheckPointStore: newMyStore()})
+		contents, interrupted := collect(runner.Query(ctx, "hi", WithCheckPointID("1")))
+		assert.True(t, interrupted)
+		assert.Equal(t, []string{"pipeline: draft"}, contents)
+
+		iter, err := runner.Resume(ctx, "1")
+		assert.NoError(t, err)
+		contents, interrupted = collect(iter)
+		assert.False(t, interrupted)
+		assert.Equal(t, []string{"pipeline: reviewed draft", "next: next"}, contents)
+		assert.Equal(t, 1, cm.times)
+	})
+
+	t.Run("runnable streaming", func(t *testing.T) {
+		cm := mockModel.NewMockToolCallingChatModel(gomock.NewController(t))
+		cm.EXPECT().Stream(gomock.Any(), gomock.Any(), gomock.Any()).
+			Return(schema.StreamReaderFromArray([]*schema.Message{schema.AssistantMessage("dr", nil), schema.AssistantMessage("aft", nil)}), nil)
+		r, err := newGraph(cm).Compile(ctx)
+		assert.NoError(t, err)
+		ga, err := NewGraphAgent(ctx, &GraphAgentConfig{Name: "pipeline", Description: "pipeline", Runnable: r})
+		assert.NoError(t, err)
+
+		runner := NewRunner(ctx, RunnerConfig{Agent: ga, EnableStreaming: true})
+		contents, interrupted := collect(runner.Query(ctx, "hi"))
+		assert.False(t, interrupted)
+		assert.Equal(t, []string{"pipeline: draft"}, contents)
+	})
+
+	t.Run("runnable without store", func(t *testing.T) {
+		cm := &myModel{messages: []*schema.Message{schema.AssistantMessage("draft", nil)}}
+		r, err := newGraph(cm).Compile(ctx, compose.WithInterruptBeforeNodes([]string{"review"}))
+		assert.NoError(t, err)
+		ga, err := NewGraphAgent(ctx, &GraphAgentConfig{Name: "pipeline", Description: "pipeline", Runnable: r})
+		assert.NoError(t, err)
+
+		iter := NewRunner(ctx, RunnerConfig{Agent: ga}).Query(ctx, "hi")
+		var lastErr error
+		for {
+			event, ok := iter.Next()
+			if !ok {
+				break
+			}
+			lastErr = event.Err
+		}
+		assert.ErrorContains(t, lastErr, "interrupt of agent 'pipeline' isn't supported")
+	})
+}
@@ -0,0 +1,446 @@
+/*
+ * Copyright 2025 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package adk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"runtime/debug"
+	"strings"
+
+	"github.com/cloudwego/eino/internal/safe"
+	"github.com/cloudwego/eino/schema"
+)
+
+type GuardrailStage string
+
+const (
+	// GuardrailStageInput checks the input messages before the agent runs.
+	GuardrailStageInput GuardrailStage = "input"
+	// GuardrailStageOutput checks the content of the assistant messages before they reach the consumer.
+	GuardrailStageOutput GuardrailStage = "output"
+)
+
+type GuardrailVerdict string
+
+const (
+	GuardrailPass GuardrailVerdict = "pass"
+	// GuardrailBlock stops the agent, and replaces the blocked content with GuardrailDecision.Message.
+	GuardrailBlock GuardrailVerdict = "block"
+	// GuardrailRewrite replaces the checked content with GuardrailDecision.Message, and goes on.
+	GuardrailRewrite GuardrailVerdict = "rewrite"
+)
+
+// Guardrail checks the input or the output of an agent against a policy, see WithGuardrails.
+type Guardrail interface {
+	Name() string
+	// Check returns the decision on the content, nil means pass.
+	Check(ctx context.Context, check *GuardrailCheck) (*GuardrailDecision, error)
+}
+
+type GuardrailCheck struct {
+	Stage GuardrailStage
+
+	// Messages are the input messages of the agent, for GuardrailStageInput.
+	Messages []Message
+
+	// Content is the content of the assistant message checked so far, including Pending, for GuardrailStageOutput.
+	Content string
+	// Pending is the part of Content which hasn't reached the consumer yet, and is what GuardrailRewrite replaces.
+	// It's the same as Content unless the message is streaming.
+	Pending string
+	// Final is true if Content is the complete content of the message.
+	Final bool
+}
+
+type GuardrailDecision struct {
+	Verdict GuardrailVerdict
+	// Message is the rewritten content for GuardrailRewrite, or the message shown instead for GuardrailBlock.
+	// For GuardrailStageInput, the rewritten content replaces the content of the last user message.
+	Message string
+	// Reason is reported in GuardrailAction.
+	Reason string
+}
+
+// GuardrailAction reports a decision other than pass, in AgentAction.Guardrail.
+type GuardrailAction struct {
+	Guardrail string           `json:"guardrail"`
+	Stage     GuardrailStage   `json:"stage"`
+	Verdict   GuardrailVerdict `json:"verdict"`
+	Reason    string           `json:"reason,omitempty"`
+}
+
+// NewGuardrail creates a Guardrail from a function.
+func NewGuardrail(name string, check func(ctx context.Context, check *GuardrailCheck) (*GuardrailDecision, error)) Guardrail {
+	return &funcGuardrail{name: name, check: check}
+}
+
+type funcGuardrail struct {
+	name  string
+	check func(ctx context.Context, check *GuardrailCheck) (*GuardrailDecision, error)
+}
+
+func (g *funcGuardrail) Name() string {
+	return g.name
+}
+
+func (g *funcGuardrail) Check(ctx context.Context, check *GuardrailCheck) (*GuardrailDecision, error) {
+	return g.check(ctx, check)
+}
+
+type GuardrailsConfig struct {
+	// Input guardrails check the input messages in order, before the agent runs.
+	Input []Guardrail
+	// Output guardrails check the content of the assistant messages of the agent in order.
+	Output []Guardrail
+
+	// CheckEveryChunk makes streaming outputs checked on every chunk with content,
+	// rather than on sentence boundaries by default.
+	// Either way, the content reaches the consumer only after it's checked, and the complete content is checked at last.
+	CheckEveryChunk bool
+}
+
+// WithGuardrails attaches the guardrails to the agent.
+// A blocked input stops the agent before it runs, and a blocked output stops the agent and cuts off the message,
+// both end with an event of AgentAction.Guardrail. A rewritten output is followed by such an event.
+func WithGuardrails(conf *GuardrailsConfig) AgentOption {
+	return func(fa *flowAgent) {
+		fa.guardrails = conf
+	}
+}
+
+type guardrailResult struct {
+	action  *GuardrailAction
+	message string
+}
+
+// check runs the guardrails in order, a rewrite is passed on to the next guardrail, and a block stops at once.
+func checkGuardrails(ctx context.Context, guardrails []Guardrail, check *GuardrailCheck) (*guardrailResult, string, error) {
+	var result *guardrailResult
+	for _, g := range guardrails {
+		d, err := g.Check(ctx, check)
+		if err != nil {
+			return nil, "", fmt.Errorf("guardrail '%s' failed: %w", g.Name(), err)
+		}
+		if d == nil || d.Verdict == GuardrailPass || d.Verdict == "" {
+			continue
+		}
+
+		result = &guardrailResult{
+			action:  &GuardrailAction{Guardrail: g.Name(), Stage: check.Stage, Verdict: d.Verdict, Reason: d.Reason},
+			message: d.Message,
+		}
+		switch d.Verdict {
+		case GuardrailBlock:
+			return result, "", nil
+		case GuardrailRewrite:
+			if check.Stage == GuardrailStageInput {
+				check.Messages = rewriteLastUserMessage(check.Messages, d.Message)
+			} else {
+				check.Content = strings.TrimSuffix(check.Content, check.Pending) + d.Message
+				check.Pending = d.Message
+			}
+		default:
+			return nil, "", fmt.Errorf("guardrail '%s' returns unknown verdict: %s", g.Name(), d.Verdict)
+		}
+	}
+
+	return result, check.Pending, nil
+}
+
+func rewriteLastUserMessage(messages []Message, content string) []Message {
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role == schema.User {
+			rewritten := make([]Message, len(messages))
+			copy(rewritten, messages)
+			msg := *messages[i]
+			msg.Content = content
+			rewritten[i] = &msg
+			return rewritten
+		}
+	}
+	return messages
+}
+
+func guardrailEvent(result *guardrailResult) *AgentEvent {
+	event := &AgentEvent{Action: &AgentAction{Guardrail: result.action}}
+	if result.action.Verdict == GuardrailBlock && result.message != "" {
+		event.Output = &AgentOutput{MessageOutput: &MessageVariant{
+			Role:    schema.Assistant,
+			Message: schema.AssistantMessage(result.message, nil),
+		}}
+	}
+	return event
+}
+
+// checkInput returns the input to run the agent with, or the event to end the run with if the input is blocked.
+func (c *GuardrailsConfig) checkInput(ctx context.Context, input *AgentInput) (*AgentInput, *AgentEvent, error) {
+	if len(c.Input) == 0 {
+		return input, nil, nil
+	}
+
+	check := &GuardrailCheck{Stage: GuardrailStageInput, Messages: input.Messages}
+	result, _, err := checkGuardrails(ctx, c.Input, check)
+	if err != nil {
+		return nil, nil, err
+	}
+	if result == nil {
+		return input, nil, nil
+	}
+	if result.action.Verdict == GuardrailBlock {
+		return nil, guardrailEvent(result), nil
+	}
+
+	return &AgentInput{Messages: check.Messages, EnableStreaming: input.EnableStreaming}, nil, nil
+}
+
+// guardOutput checks the assistant messages of the events, and stops the agent once a message is blocked.
+func (c *GuardrailsConfig) guardOutput(ctx context.Context, aIter *AsyncIterator[*AgentEvent]) *AsyncIterator[*AgentEvent] {
+	if len(c.Output) == 0 {
+		return aIter
+	}
+
+	iterator, generator := newEventIteratorPair(ctx)
+	iterator.ch.addOnCancel(aIter.Close)
+
+	go func() {
+		defer func() {
+			panicErr := recover()
+			if panicErr != nil {
+				e := safe.NewPanicErr(panicErr, debug.Stack())
+				generator.Send(&AgentEvent{Err: e})
+			}
+
+			generator.Close()
+		}()
+
+		for {
+			event, ok := aIter.Next()
+			if !ok {
+				return
+			}
+
+			mo := event.Output
+			if event.Err != nil || mo == nil || mo.MessageOutput == nil || mo.MessageOutput.Role != schema.Assistant {
+				generator.Send(event)
+				continue
+			}
+
+			var (
+				result *guardrailResult
+				err    error
+			)
+			if mo.MessageOutput.IsStreaming {
+				result, err = c.guardStream(ctx, event, generator)
+			} else {
+				result, err = c.guardMessage(ctx, event, generator)
+			}
+			if err != nil {
+				generator.Send(&AgentEvent{Err: err})
+				aIter.Close()
+				return
+			}
+			if result == nil {
+				continue
+			}
+
+			generator.Send(&AgentEvent{Action: &AgentAction{Guardrail: result.action}})
+			if result.action.Verdict == GuardrailBlock {
+				aIter.Close()
+				return
+			}
+		}
+	}()
+
+	return iterator
+}
+
+func (c *GuardrailsConfig) guardMessage(ctx context.Context, event *AgentEvent, generator *AsyncGenerator[*AgentEvent]) (*guardrailResult, error) {
+	msg := event.Output.MessageOutput.Message
+	if msg == nil || msg.Content == "" {
+		generator.Send(event)
+		return nil, nil
+	}
+
+	result, content, err := checkGuardrails(ctx, c.Output,
+		&GuardrailCheck{Stage: GuardrailStageOutput, Content: msg.Content, Pending: msg.Content, Final: true})
+	if err != nil {
+		return nil, err
+	}
+	if result == nil {
+		generator.Send(event)
+		return nil, nil
+	}
+
+	if result.action.Verdict == GuardrailBlock {
+		content = result.message
+		if content == "" {
+			// nothing to show instead
+			return result, nil
+		}
+	}
+
+	checked := *msg
+	checked.Content = content
+	generator.Send(&AgentEvent{
+		AgentName: event.AgentName,
+		RunPath:   event.RunPath,
+		Output: &AgentOutput{
+			MessageOutput:    &MessageVariant{Role: schema.Assistant, Message: &checked},
+			CustomizedOutput: event.Output.CustomizedOutput,
+		},
+		Action: event.Action,
+	})
+	return result, nil
+}
+
+// guardStream forwards the event with a stream in which the content is held back until it's checked,
+// and waits until the stream ends, so that the decision can be reported before the next event.
+func (c *GuardrailsConfig) guardStream(ctx context.Context, event *AgentEvent, generator *AsyncGenerator[*AgentEvent]) (*guardrailResult, error) {
+	sr, sw := schema.Pipe[Message](1)
+	// one copy goes to the consumer, which buffers the chunks no matter how slowly it's consumed,
+	// and the other is drained here to wait for the end of the stream
+	copies := sr.Copy(2)
+
+	mv := *event.Output.MessageOutput
+	src := mv.MessageStream
+	mv.MessageStream = copies[0]
+	generator.Send(&AgentEvent{
+		AgentName: event.AgentName,
+		RunPath:   event.RunPath,
+		Output:    &AgentOutput{MessageOutput: &mv, CustomizedOutput: event.Output.CustomizedOutput},
+		Action:    event.Action,
+	})
+
+	var (
+		result *guardrailResult
+		err    error
+	)
+	go func() {
+		defer func() {
+			panicErr := recover()
+			if panicErr != nil {
+				err = safe.NewPanicErr(panicErr, debug.Stack())
+			}
+			src.Close()
+			sw.Close()
+		}()
+		result, err = c.filterStream(ctx, src, sw)
+	}()
+
+	drain := copies[1]
+	defer drain.Close()
+	for {
+		if _, err_ := drain.Recv(); err_ != nil {
+			break
+		}
+	}
+
+	return result, err
+}
+
+func (c *GuardrailsConfig) filterStream(ctx context.Context, src MessageStream, sw *schema.StreamWriter[Message]) (*guardrailResult, error) {
+	var (
+		held    []Message
+		content strings.Builder
+		pending strings.Builder
+		rewrote *guardrailResult
+	)
+
+	flush := func(final bool) (bool, error) {
+		if pending.Len() == 0 && !(final && content.Len() > 0) {
+			for _, chunk := range held {
+				sw.Send(chunk, nil)
+			}
+			held = held[:0]
+			return false, nil
+		}
+
+		check := &GuardrailCheck{
+			Stage:   GuardrailStageOutput,
+			Content: content.String() + pending.String(),
+			Pending: pending.String(),
+			Final:   final,
+		}
+		result, checked, err := checkGuardrails(ctx, c.Output, check)
+		if err != nil {
+			return false, err
+		}
+
+		if result != nil && result.action.Verdict == GuardrailBlock {
+			if result.message != "" {
+				sw.Send(schema.AssistantMessage(result.message, nil), nil)
+			}
+			rewrote = result
+			return true, nil
+		}
+
+		if result != nil {
+			rewrote = result
+			if checked != "" {
+				sw.Send(schema.AssistantMessage(checked, nil), nil)
+			}
+			for _, chunk := range held {
+				if chunk.Content == "" {
+					sw.Send(chunk, nil)
+					continue
+				}
+				rest := *chunk
+				rest.Content = ""
+				sw.Send(&rest, nil)
+			}
+		} else {
+			for _, chunk := range held {
+				sw.Send(chunk, nil)
+			}
+		}
+
+		content.WriteString(checked)
+		pending.Reset()
+		held = held[:0]
+		return false, nil
+	}
+
+	for {
+		chunk, err := src.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			sw.Send(nil, err)
+			return nil, nil
+		}
+
+		held = append(held, chunk)
+		pending.WriteString(chunk.Content)
+		if chunk.Content == "" || !(c.CheckEveryChunk || strings.ContainsAny(chunk.Content, sentenceBoundaries)) {
+			continue
+		}
+
+		blocked, err := flush(false)
+		if err != nil || blocked {
+			return rewrote, err
+		}
+	}
+
+	_, err := flush(true)
+	return rewrote, err
+}
+
+const sentenceBoundaries = ".!?;\n。！？；"
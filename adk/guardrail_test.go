@@ -0,0 +1,156 @@
+/*
+ * Copyright 2025 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package adk
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+// guardrailTestAgent streams the chunks as one message, and then replies "bye".
+type guardrailTestAgent struct {
+	chunks []string
+	input  []Message
+}
+
+func (a *guardrailTestAgent) Name(_ context.Context) string {
+	return "guarded"
+}
+
+func (a *guardrailTestAgent) Description(_ context.Context) string {
+	return "guarded agent"
+}
+
+func (a *guardrailTestAgent) Run(_ context.Context, input *AgentInput, _ ...AgentRunOption) *AsyncIterator[*AgentEvent] {
+	a.input = input.Messages
+
+	var msgs []Message
+	for _, c := range a.chunks {
+		msgs = append(msgs, schema.AssistantMessage(c, nil))
+	}
+
+	iter, gen := NewAsyncIteratorPair[*AgentEvent]()
+	if input.EnableStreaming {
+		gen.Send(EventFromMessage(nil, schema.StreamReaderFromArray(msgs), schema.Assistant, ""))
+	} else {
+		gen.Send(EventFromMessage(schema.AssistantMessage(strings.Join(a.chunks, ""), nil), nil, schema.Assistant, ""))
+	}
+	gen.Send(EventFromMessage(schema.AssistantMessage("bye", nil), nil, schema.Assistant, ""))
+	gen.Close()
+	return iter
+}
+
+func TestGuardrails(t *testing.T) {
+	ctx := context.Background()
+
+	var checks []*GuardrailCheck
+	secret := NewGuardrail("secret", func(ctx context.Context, check *GuardrailCheck) (*GuardrailDecision, error) {
+		checks = append(checks, &GuardrailCheck{Content: check.Content, Pending: check.Pending, Final: check.Final})
+		if strings.Contains(check.Pending, "secret") {
+			return &GuardrailDecision{Verdict: GuardrailBlock, Message: "[blocked]", Reason: "leaks the secret"}, nil
+		}
+		return nil, nil
+	})
+	polite := NewGuardrail("polite", func(ctx context.Context, check *GuardrailCheck) (*GuardrailDecision, error) {
+		if check.Stage == GuardrailStageInput {
+			last := check.Messages[len(check.Messages)-1]
+			if strings.Contains(last.Content, "stupid") {
+				return &GuardrailDecision{Verdict: GuardrailRewrite, Message: strings.ReplaceAll(last.Content, "stupid", "***")}, nil
+			}
+			if strings.Contains(last.Content, "hack") {
+				return &GuardrailDecision{Verdict: GuardrailBlock, Message: "I can't help with that."}, nil
+			}
+			return nil, nil
+		}
+		if strings.Contains(check.Pending, "bye") {
+			return &GuardrailDecision{Verdict: GuardrailRewrite, Message: strings.ReplaceAll(check.Pending, "bye", "goodbye")}, nil
+		}
+		return nil, nil
+	})
+
+	newRunner := func(a Agent, conf *GuardrailsConfig, streaming bool) *Runner {
+		return NewRunner(ctx, RunnerConfig{Agent: AgentWithOptions(ctx, a, WithGuardrails(conf)), EnableStreaming: streaming})
+	}
+
+	t.Run("block stream on sentence boundary", func(t *testing.T) {
+		checks = nil
+		a := &guardrailTestAgent{chunks: []string{"Hello ", "there. ", "The secret ", "is 42. ", "Anyway."}}
+		events := collectEvents(t, newRunner(a, &GuardrailsConfig{Output: []Guardrail{secret}}, true).Query(ctx, "hi"))
+		assert.Len(t, events, 2)
+
+		var chunks []string
+		s := events[0].Output.MessageOutput.MessageStream
+		for {
+			chunk, err := s.Recv()
+			if err != nil {
+				break
+			}
+			chunks = append(chunks, chunk.Content)
+		}
+		assert.Equal(t, []string{"Hello ", "there. ", "[blocked]"}, chunks)
+		assert.Equal(t, []*GuardrailCheck{
+			{Content: "Hello there. ", Pending: "Hello there. "},
+			{Content: "Hello there. The secret is 42. ", Pending: "The secret is 42. "},
+		}, checks)
+
+		assert.Equal(t, "guarded", events[1].AgentName)
+		assert.Equal(t, &GuardrailAction{Guardrail: "secret", Stage: GuardrailStageOutput, Verdict: GuardrailBlock, Reason: "leaks the secret"},
+			events[1].Action.Guardrail)
+	})
+
+	t.Run("rewrite every chunk", func(t *testing.T) {
+		a := &guardrailTestAgent{chunks: []string{"ok ", "bye", " now"}}
+		events := collectEvents(t, newRunner(a, &GuardrailsConfig{Output: []Guardrail{secret, polite}, CheckEveryChunk: true}, true).Query(ctx, "hi"))
+		assert.Len(t, events, 4)
+
+		msg, err := events[0].Output.MessageOutput.GetMessage()
+		assert.NoError(t, err)
+		assert.Equal(t, "ok goodbye now", msg.Content)
+		assert.Equal(t, GuardrailRewrite, events[1].Action.Guardrail.Verdict)
+		assert.Equal(t, "goodbye", events[2].Output.MessageOutput.Message.Content)
+		assert.Equal(t, "polite", events[3].Action.Guardrail.Guardrail)
+	})
+
+	t.Run("block message", func(t *testing.T) {
+		a := &guardrailTestAgent{chunks: []string{"the secret is 42"}}
+		events := collectEvents(t, newRunner(a, &GuardrailsConfig{Output: []Guardrail{secret}}, false).Query(ctx, "hi"))
+		assert.Len(t, events, 2)
+		assert.Equal(t, "[blocked]", events[0].Output.MessageOutput.Message.Content)
+		assert.Equal(t, GuardrailBlock, events[1].Action.Guardrail.Verdict)
+	})
+
+	t.Run("input", func(t *testing.T) {
+		a := &guardrailTestAgent{chunks: []string{"fine"}}
+		r := newRunner(a, &GuardrailsConfig{Input: []Guardrail{polite}}, false)
+
+		events := collectEvents(t, r.Query(ctx, "you stupid bot"))
+		assert.Len(t, events, 2)
+		assert.Equal(t, "you *** bot", a.input[0].Content)
+
+		a.input = nil
+		events = collectEvents(t, r.Query(ctx, "hack the server"))
+		assert.Len(t, events, 1)
+		assert.Nil(t, a.input)
+		assert.Equal(t, "I can't help with that.", events[0].Output.MessageOutput.Message.Content)
+		assert.Equal(t, &GuardrailAction{Guardrail: "polite", Stage: GuardrailStageInput, Verdict: GuardrailBlock}, events[0].Action.Guardrail)
+	})
+}
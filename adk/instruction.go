@@ -19,7 +19,18 @@ package adk
 import (
 	"context"
 	"fmt"
+	"regexp"
 	"strings"
+	"sync"
+	"text/template"
+	"text/template/parse"
+
+	"github.com/nikolalohinski/gonja"
+	"github.com/nikolalohinski/gonja/config"
+	"github.com/nikolalohinski/gonja/nodes"
+	"github.com/nikolalohinski/gonja/parser"
+
+	"github.com/cloudwego/eino/schema"
 )
 
 const (
@@ -41,3 +52,167 @@ func genTransferToAgentInstruction(ctx context.Context, agents []Agent) string {
 
 	return fmt.Sprintf(TransferToAgentInstruction, sb.String(), TransferToAgentToolName)
 }
+
+// InstructionProvider computes the instruction of a run of ChatModelAgent,
+// e.g. from the context, the session values by GetSessionValues, and the input.
+type InstructionProvider func(ctx context.Context, input *AgentInput) (string, error)
+
+// fStringFieldRegexp matches the escaped braces and the replacement fields of FString.
+var fStringFieldRegexp = regexp.MustCompile(`\{\{|\}\}|\{([^{}]*)\}`)
+
+// renderInstruction renders the instruction with the session values in the format.
+// In lenient mode, the variables missing from the values are rendered as empty strings, otherwise they are errors.
+func renderInstruction(ctx context.Context, instruction string, vs map[string]any, format schema.FormatType, lenient bool) (string, error) {
+	switch {
+	case format == schema.Jinja2 && !lenient:
+		return renderStrictJinja2(instruction, vs)
+	case format == schema.GoTemplate && lenient:
+		return renderLenientGoTemplate(instruction, vs)
+	case format == schema.FString && lenient:
+		vs = withMissingFStringFields(instruction, vs)
+	}
+
+	// the Jinja2 of schema renders the missing variables as empty, and the others fail on them
+	ms, err := schema.SystemMessage(instruction).Format(ctx, vs, format)
+	if err != nil {
+		return "", err
+	}
+	return ms[0].Content, nil
+}
+
+func withMissingFStringFields(instruction string, vs map[string]any) map[string]any {
+	var filled map[string]any
+	for _, m := range fStringFieldRegexp.FindAllStringSubmatch(instruction, -1) {
+		if m[0] == "{{" || m[0] == "}}" {
+			continue
+		}
+		// the field name is followed by the attributes, the index, the conversion or the format spec
+		name := m[1]
+		if i := strings.IndexAny(name, ".[!:"); i >= 0 {
+			name = name[:i]
+		}
+		if name == "" {
+			continue
+		}
+		if _, ok := vs[name]; ok {
+			continue
+		}
+
+		if filled == nil {
+			filled = copyMap(vs)
+		}
+		filled[name] = ""
+	}
+
+	if filled == nil {
+		return vs
+	}
+	return filled
+}
+
+func renderLenientGoTemplate(instruction string, vs map[string]any) (string, error) {
+	tmpl, err := template.New("instruction").Option("missingkey=zero").Parse(instruction)
+	if err != nil {
+		return "", err
+	}
+
+	// a missing key of map[string]any is rendered as "<no value>" even with missingkey=zero, so fill the fields of dot
+	var filled map[string]any
+	var walk func(node parse.Node)
+	walk = func(node parse.Node) {
+		switch n := node.(type) {
+		case *parse.ListNode:
+			if n == nil {
+				return
+			}
+			for _, c := range n.Nodes {
+				walk(c)
+			}
+		case *parse.ActionNode:
+			walk(n.Pipe)
+		case *parse.IfNode:
+			walk(n.Pipe)
+			walk(n.List)
+			walk(n.ElseList)
+		case *parse.RangeNode:
+			walk(n.Pipe)
+			walk(n.List)
+			walk(n.ElseList)
+		case *parse.WithNode:
+			walk(n.Pipe)
+			walk(n.List)
+			walk(n.ElseList)
+		case *parse.TemplateNode:
+			walk(n.Pipe)
+		case *parse.PipeNode:
+			if n == nil {
+				return
+			}
+			for _, cmd := range n.Cmds {
+				for _, arg := range cmd.Args {
+					walk(arg)
+				}
+			}
+		case *parse.ChainNode:
+			walk(n.Node)
+		case *parse.FieldNode:
+			if _, ok := vs[n.Ident[0]]; ok {
+				return
+			}
+			if filled == nil {
+				filled = copyMap(vs)
+			}
+			filled[n.Ident[0]] = ""
+		}
+	}
+	walk(tmpl.Tree.Root)
+
+	data := vs
+	if filled != nil {
+		data = filled
+	}
+
+	sb := new(strings.Builder)
+	if err = tmpl.Execute(sb, data); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
+
+var (
+	strictJinjaEnvOnce sync.Once
+	strictJinjaEnv     *gonja.Environment
+	strictJinjaEnvErr  error
+)
+
+func renderStrictJinja2(instruction string, vs map[string]any) (string, error) {
+	strictJinjaEnvOnce.Do(func() {
+		cfg := config.NewConfig()
+		cfg.StrictUndefined = true
+		strictJinjaEnv = gonja.NewEnvironment(cfg, gonja.DefaultLoader)
+
+		// same as schema.Jinja2, the statements loading other templates are disabled
+		for _, statement := range []string{"include", "extends", "import", "from"} {
+			if !strictJinjaEnv.Statements.Exists(statement) {
+				continue
+			}
+			statement := statement
+			err := strictJinjaEnv.Statements.Replace(statement, func(_ *parser.Parser, _ *parser.Parser) (nodes.Statement, error) {
+				return nil, fmt.Errorf("keyword[%s] has been disabled", statement)
+			})
+			if err != nil {
+				strictJinjaEnvErr = fmt.Errorf("init jinja env fail: %w", err)
+				return
+			}
+		}
+	})
+	if strictJinjaEnvErr != nil {
+		return "", strictJinjaEnvErr
+	}
+
+	tpl, err := strictJinjaEnv.FromString(instruction)
+	if err != nil {
+		return "", err
+	}
+	return tpl.Execute(vs)
+}
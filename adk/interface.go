@@ -125,6 +125,9 @@ type AgentOutput struct {
 	MessageOutput *MessageVariant
 
 	CustomizedOutput any
+
+	// MaxStepExhausted is set on the final answer given after MaxStep is exhausted, see ChatModelAgentConfig.MaxStepAnswer.
+	MaxStepExhausted bool
 }
 
 func NewTransferToAgentAction(destAgentName string) *AgentAction {
@@ -142,6 +145,12 @@ type AgentAction struct {
 
 	TransferToAgent *TransferToAgentAction
 
+	// Guardrail reports a guardrail decision on the input or the output of the agent, see WithGuardrails.
+	Guardrail *GuardrailAction
+
+	// Route reports the sub-agents picked by a router agent, see NewRouterAgent.
+	Route *RouteAction
+
 	CustomizedAction any
 }
 
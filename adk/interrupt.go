@@ -21,6 +21,9 @@ import (
 	"context"
 	"encoding/gob"
 	"fmt"
+	"sort"
+	"strings"
+	"sync"
 
 	"github.com/cloudwego/eino/compose"
 )
@@ -32,6 +35,26 @@ type ResumeInfo struct {
 
 type InterruptInfo struct {
 	Data any
+
+	// points are not persisted in checkpoints, they are re-computed from the run context on resume.
+	points []*InterruptPoint
+}
+
+// InterruptPoint is a single place where the run has been interrupted,
+// e.g. an agent, or a tool called by a ChatModelAgent, including the ones nested in ParallelAgent or agent tools.
+type InterruptPoint struct {
+	// ID is stable between interrupt and resume, use it as the key of resume data passed to Runner.ResumeWithData.
+	ID string
+	// Info is the interrupt info emitted by the interrupt point.
+	Info any
+}
+
+// Points returns all interrupt points contained in this interrupt.
+func (ii *InterruptInfo) Points() []*InterruptPoint {
+	if ii == nil {
+		return nil
+	}
+	return ii.points
 }
 
 func WithCheckPointID(id string) AgentRunOption {
@@ -40,6 +63,147 @@ func WithCheckPointID(id string) AgentRunOption {
 	})
 }
 
+// WithInterruptHandle sets the handle to interrupt the run from outside, e.g. when the user pauses it.
+// The agents running graphs, e.g. ChatModelAgent, stop at the next step boundary and emit the interrupted event,
+// then the run can be resumed by Runner.Resume with the checkpoint ID as the other interrupts.
+// Pass a new handle to the resumed run, as the handle which has interrupted interrupts the runs it's passed to right away.
+func WithInterruptHandle(h *compose.InterruptHandle) AgentRunOption {
+	return WrapImplSpecificOptFn(func(t *options) {
+		t.interruptHandle = h
+	})
+}
+
+// appendInterruptHandle passes the interrupt handle of the run to the graph run by the agent.
+func appendInterruptHandle(co []compose.Option, opts []AgentRunOption) []compose.Option {
+	if h := getCommonOptions(nil, opts...).interruptHandle; h != nil {
+		co = append(co, compose.WithInterruptHandle(h))
+	}
+	return co
+}
+
+const interruptIDSeparator = ";"
+
+var interruptIDEscaper = strings.NewReplacer(`\`, `\\`, interruptIDSeparator, `\`+interruptIDSeparator)
+
+type interruptAddrPrefixKey struct{}
+type toolInterruptScopeKey struct{}
+type resumeDataKey struct{}
+
+type toolInterruptScope struct {
+	runCtx     *runContext
+	toolCallID string
+}
+
+// withInterruptAddrPrefix is used by nested runners(e.g. agent tool), so that ids of inner interrupt points contain the outer address.
+func withInterruptAddrPrefix(ctx context.Context, prefix []string) context.Context {
+	return context.WithValue(ctx, interruptAddrPrefixKey{}, prefix)
+}
+
+// withToolInterruptScope marks ctx as being inside the tool call of current agent.
+func withToolInterruptScope(ctx context.Context, toolCallID string) context.Context {
+	return context.WithValue(ctx, toolInterruptScopeKey{}, &toolInterruptScope{
+		runCtx:     getRunCtx(ctx),
+		toolCallID: toolCallID,
+	})
+}
+
+func getInterruptAddr(ctx context.Context) []string {
+	var addr []string
+	if prefix, ok := ctx.Value(interruptAddrPrefixKey{}).([]string); ok {
+		addr = append(addr, prefix...)
+	}
+
+	runCtx := getRunCtx(ctx)
+	if runCtx != nil {
+		addr = append(addr, runCtx.RunPath...)
+	}
+
+	// tool scope only takes effect in the agent which calls the tool, not in the agents nested in the tool
+	if ts, ok := ctx.Value(toolInterruptScopeKey{}).(*toolInterruptScope); ok && runCtx != nil && ts.runCtx == runCtx {
+		addr = append(addr, ts.toolCallID)
+	}
+
+	return addr
+}
+
+// genInterruptID joins the segments of the address, i.e. the agent names and the tool call IDs,
+// escaping the separator in them so that different addresses never share an ID.
+func genInterruptID(addr []string) string {
+	segments := make([]string, len(addr))
+	for i, seg := range addr {
+		segments[i] = interruptIDEscaper.Replace(seg)
+	}
+	return strings.Join(segments, interruptIDSeparator)
+}
+
+// GetInterruptID returns the id of the interrupt point which ctx belongs to.
+// It can be used in agents and tools to know the key of their resume data.
+func GetInterruptID(ctx context.Context) string {
+	return genInterruptID(getInterruptAddr(ctx))
+}
+
+// GetResumeData returns the resume data passed to Runner.ResumeWithData for the interrupt point which ctx belongs to.
+// It's expected to be called in ResumableAgent.Resume, or in tools that are rerun after interrupt.
+func GetResumeData(ctx context.Context) (any, bool) {
+	data, ok := ctx.Value(resumeDataKey{}).(map[string]any)
+	if !ok {
+		return nil, false
+	}
+
+	v, ok := data[GetInterruptID(ctx)]
+	return v, ok
+}
+
+func setResumeData(ctx context.Context, data map[string]any) context.Context {
+	if len(data) == 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, resumeDataKey{}, data)
+}
+
+// agentToolInterruptPoints collects interrupt points of agent tools called in a ChatModelAgent, keyed by tool call id.
+type agentToolInterruptPoints struct {
+	mu     sync.Mutex
+	points map[string][]*InterruptPoint
+}
+
+type agentToolInterruptPointsKey struct{}
+
+func withAgentToolInterruptPoints(ctx context.Context) (context.Context, *agentToolInterruptPoints) {
+	p := &agentToolInterruptPoints{points: make(map[string][]*InterruptPoint)}
+	return context.WithValue(ctx, agentToolInterruptPointsKey{}, p), p
+}
+
+func recordAgentToolInterruptPoints(ctx context.Context, toolCallID string, points []*InterruptPoint) {
+	p, ok := ctx.Value(agentToolInterruptPointsKey{}).(*agentToolInterruptPoints)
+	if !ok {
+		return
+	}
+	p.mu.Lock()
+	p.points[toolCallID] = points
+	p.mu.Unlock()
+}
+
+func (p *agentToolInterruptPoints) get(toolCallID string) []*InterruptPoint {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.points[toolCallID]
+}
+
+func mergeInterruptPoints(infos map[int]*InterruptInfo) []*InterruptPoint {
+	indexes := make([]int, 0, len(infos))
+	for idx := range infos {
+		indexes = append(indexes, idx)
+	}
+	sort.Ints(indexes)
+
+	var points []*InterruptPoint
+	for _, idx := range indexes {
+		points = append(points, infos[idx].Points()...)
+	}
+	return points
+}
+
 func init() {
 	gob.RegisterName("_eino_adk_serialization", &serialization{})
 	gob.RegisterName("_eino_adk_workflow_interrupt_info", &workflowInterruptInfo{})
@@ -19,13 +19,16 @@ package adk
 import (
 	"context"
 	"errors"
+	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
 
 	"github.com/cloudwego/eino/components/model"
 	"github.com/cloudwego/eino/components/tool"
 	"github.com/cloudwego/eino/compose"
+	mockModel "github.com/cloudwego/eino/internal/mock/components/model"
 	"github.com/cloudwego/eino/schema"
 )
 
@@ -277,10 +280,12 @@ func TestWorkflowInterrupt(t *testing.T) {
 						},
 						SequentialInterruptIndex: 0,
 						SequentialInterruptInfo: &InterruptInfo{
-							Data: "sa1 interrupt data",
+							Data:   "sa1 interrupt data",
+							points: []*InterruptPoint{{ID: "loop;sa1", Info: "sa1 interrupt data"}},
 						},
 						LoopIterations: 0,
 					},
+					points: []*InterruptPoint{{ID: "loop;sa1", Info: "sa1 interrupt data"}},
 				},
 			},
 		},
@@ -295,10 +300,12 @@ func TestWorkflowInterrupt(t *testing.T) {
 						},
 						SequentialInterruptIndex: 1,
 						SequentialInterruptInfo: &InterruptInfo{
-							Data: "sa2 interrupt data",
+							Data:   "sa2 interrupt data",
+							points: []*InterruptPoint{{ID: "loop;sa2", Info: "sa2 interrupt data"}},
 						},
 						LoopIterations: 0,
 					},
+					points: []*InterruptPoint{{ID: "loop;sa2", Info: "sa2 interrupt data"}},
 				},
 			},
 		},
@@ -331,10 +338,12 @@ func TestWorkflowInterrupt(t *testing.T) {
 						},
 						SequentialInterruptIndex: 0,
 						SequentialInterruptInfo: &InterruptInfo{
-							Data: "sa1 interrupt data",
+							Data:   "sa1 interrupt data",
+							points: []*InterruptPoint{{ID: "loop;sa1", Info: "sa1 interrupt data"}},
 						},
 						LoopIterations: 1,
 					},
+					points: []*InterruptPoint{{ID: "loop;sa1", Info: "sa1 interrupt data"}},
 				},
 			},
 		},
@@ -349,10 +358,12 @@ func TestWorkflowInterrupt(t *testing.T) {
 						},
 						SequentialInterruptIndex: 1,
 						SequentialInterruptInfo: &InterruptInfo{
-							Data: "sa2 interrupt data",
+							Data:   "sa2 interrupt data",
+							points: []*InterruptPoint{{ID: "loop;sa2", Info: "sa2 interrupt data"}},
 						},
 						LoopIterations: 1,
 					},
+					points: []*InterruptPoint{{ID: "loop;sa2", Info: "sa2 interrupt data"}},
 				},
 			},
 		},
@@ -569,6 +580,166 @@ func TestChatModelAgentToolInterrupt(t *testing.T) {
 	assert.False(t, ok)
 }
 
+func TestResumeWithData(t *testing.T) {
+	ctx := context.Background()
+
+	var mu sync.Mutex
+	newInterruptAgent := func(name string, resumed map[string]any) *myAgent {
+		return &myAgent{
+			name: name,
+			runner: func(ctx context.Context, input *AgentInput, options ...AgentRunOption) *AsyncIterator[*AgentEvent] {
+				iter, generator := NewAsyncIteratorPair[*AgentEvent]()
+				generator.Send(&AgentEvent{Action: &AgentAction{Interrupted: &InterruptInfo{Data: name + " data"}}})
+				generator.Close()
+				return iter
+			},
+			resumer: func(ctx context.Context, info *ResumeInfo, opts ...AgentRunOption) *AsyncIterator[*AgentEvent] {
+				data, ok := GetResumeData(ctx)
+				assert.True(t, ok)
+				mu.Lock()
+				resumed[GetInterruptID(ctx)] = data
+				mu.Unlock()
+				iter, generator := NewAsyncIteratorPair[*AgentEvent]()
+				generator.Close()
+				return iter
+			},
+		}
+	}
+
+	t.Run("parallel", func(t *testing.T) {
+		resumed := map[string]any{}
+		a, err := NewParallelAgent(ctx, &ParallelAgentConfig{
+			Name:      "parallel",
+			SubAgents: []Agent{newInterruptAgent("sa1", resumed), newInterruptAgent("sa2", resumed)},
+		})
+		assert.NoError(t, err)
+		runner := NewRunner(ctx, RunnerConfig{Agent: a, CheckPointStore: newMyStore()})
+		iter := runner.Query(ctx, "hello world", WithCheckPointID("1"))
+		event, ok := iter.Next()
+		assert.True(t, ok)
+		assert.Equal(t, []*InterruptPoint{
+			{ID: "parallel;sa1", Info: "sa1 data"},
+			{ID: "parallel;sa2", Info: "sa2 data"},
+		}, event.Action.Interrupted.Points())
+		_, ok = iter.Next()
+		assert.False(t, ok)
+
+		iter, err = runner.ResumeWithData(ctx, "1", map[string]any{
+			"parallel;sa1": "answer1",
+			"parallel;sa2": "answer2",
+		})
+		assert.NoError(t, err)
+		_, ok = iter.Next()
+		assert.False(t, ok)
+		assert.Equal(t, map[string]any{"parallel;sa1": "answer1", "parallel;sa2": "answer2"}, resumed)
+	})
+
+	t.Run("chat model agent tool", func(t *testing.T) {
+		tl := &resumeDataTool{}
+		a, err := NewChatModelAgent(ctx, &ChatModelAgentConfig{
+			Name:        "name",
+			Description: "description",
+			Model: &myModel{
+				messages: []*schema.Message{
+					schema.AssistantMessage("", []schema.ToolCall{{ID: "1", Function: schema.FunctionCall{Name: "resume_data_tool", Arguments: "{}"}}}),
+					schema.AssistantMessage("completed", nil),
+				},
+			},
+			ToolsConfig: ToolsConfig{
+				ToolsNodeConfig: compose.ToolsNodeConfig{
+					Tools: []tool.BaseTool{tl},
+				},
+			},
+		})
+		assert.NoError(t, err)
+		runner := NewRunner(ctx, RunnerConfig{Agent: a, CheckPointStore: newMyStore()})
+		iter := runner.Query(ctx, "hello world", WithCheckPointID("1"))
+		var interruptEvent *AgentEvent
+		for {
+			event, ok := iter.Next()
+			if !ok {
+				break
+			}
+			assert.NoError(t, event.Err)
+			interruptEvent = event
+		}
+		assert.Equal(t, []*InterruptPoint{{ID: "name;1", Info: "need approval"}}, interruptEvent.Action.Interrupted.Points())
+
+		iter, err = runner.ResumeWithData(ctx, "1", map[string]any{"name;1": "approved"})
+		assert.NoError(t, err)
+		event, ok := iter.Next()
+		assert.True(t, ok)
+		assert.NoError(t, event.Err)
+		assert.Equal(t, "approved", event.Output.MessageOutput.Message.Content)
+	})
+
+	t.Run("agent tool", func(t *testing.T) {
+		resumed := map[string]any{}
+		sa := newInterruptAgent("inner", resumed)
+		sa.resumer = func(ctx context.Context, info *ResumeInfo, opts ...AgentRunOption) *AsyncIterator[*AgentEvent] {
+			data, _ := GetResumeData(ctx)
+			resumed[GetInterruptID(ctx)] = data
+			iter, generator := NewAsyncIteratorPair[*AgentEvent]()
+			generator.Send(&AgentEvent{Output: &AgentOutput{MessageOutput: &MessageVariant{Message: schema.UserMessage("inner completed")}}})
+			generator.Close()
+			return iter
+		}
+		a, err := NewChatModelAgent(ctx, &ChatModelAgentConfig{
+			Name:        "outer",
+			Description: "description",
+			Model: &myModel{
+				messages: []*schema.Message{
+					schema.AssistantMessage("", []schema.ToolCall{{ID: "1", Function: schema.FunctionCall{Name: "inner", Arguments: "{\"request\":\"123\"}"}}}),
+					schema.AssistantMessage("completed", nil),
+				},
+			},
+			ToolsConfig: ToolsConfig{
+				ToolsNodeConfig: compose.ToolsNodeConfig{
+					Tools: []tool.BaseTool{NewAgentTool(ctx, sa)},
+				},
+			},
+		})
+		assert.NoError(t, err)
+		runner := NewRunner(ctx, RunnerConfig{Agent: a, CheckPointStore: newMyStore()})
+		iter := runner.Query(ctx, "hello world", WithCheckPointID("1"))
+		var interruptEvent *AgentEvent
+		for {
+			event, ok := iter.Next()
+			if !ok {
+				break
+			}
+			assert.NoError(t, event.Err)
+			interruptEvent = event
+		}
+		assert.Equal(t, []*InterruptPoint{{ID: "outer;1;inner", Info: "inner data"}}, interruptEvent.Action.Interrupted.Points())
+
+		iter, err = runner.ResumeWithData(ctx, "1", map[string]any{"outer;1;inner": "answer"})
+		assert.NoError(t, err)
+		for {
+			event, ok := iter.Next()
+			if !ok {
+				break
+			}
+			assert.NoError(t, event.Err)
+		}
+		assert.Equal(t, map[string]any{"outer;1;inner": "answer"}, resumed)
+	})
+}
+
+type resumeDataTool struct{}
+
+func (r *resumeDataTool) Info(ctx context.Context) (*schema.ToolInfo, error) {
+	return &schema.ToolInfo{Name: "resume_data_tool", Desc: "desc"}, nil
+}
+
+func (r *resumeDataTool) InvokableRun(ctx context.Context, argumentsInJSON string, opts ...tool.Option) (string, error) {
+	data, ok := GetResumeData(ctx)
+	if !ok {
+		return "", compose.NewInterruptAndRerunErr("need approval")
+	}
+	return data.(string), nil
+}
+
 func newMyStore() *myStore {
 	return &myStore{
 		m: map[string][]byte{},
@@ -668,3 +839,54 @@ func (m *myTool1) InvokableRun(ctx context.Context, argumentsInJSON string, opts
 	}
 	return "result", nil
 }
+
+func TestInterruptHandle(t *testing.T) {
+	ctx := context.Background()
+
+	h := compose.NewInterruptHandle()
+	cm := mockModel.NewMockToolCallingChatModel(gomock.NewController(t))
+	cm.EXPECT().WithTools(gomock.Any()).Return(cm, nil).AnyTimes()
+	times := 0
+	cm.EXPECT().Generate(gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+			times++
+			if times == 1 {
+				// the user pauses the run while the model is generating
+				h.Interrupt()
+				return toolCallMessage("1", "test_tool", `{"name": "tom"}`), nil
+			}
+			assert.Equal(t, schema.Tool, input[len(input)-1].Role)
+			return schema.AssistantMessage("done", nil), nil
+		}).Times(2)
+
+	ft := &fakeToolForTest{tarCount: 100}
+	a, err := NewChatModelAgent(ctx, &ChatModelAgentConfig{
+		Name:        "pausable",
+		Description: "pausable",
+		Model:       cm,
+		ToolsConfig: ToolsConfig{ToolsNodeConfig: compose.ToolsNodeConfig{Tools: []tool.BaseTool{ft}}},
+	})
+	assert.NoError(t, err)
+	runner := NewRunner(ctx, RunnerConfig{Agent: a, CheckPointStore: newMyStore()})
+
+	events := collectEvents(t, runner.Query(ctx, "hi", WithCheckPointID("1"), WithInterruptHandle(h)))
+	last := events[len(events)-1]
+	assert.NotNil(t, last.Action.Interrupted)
+	// stopped before the tools node
+	assert.Equal(t, 0, ft.curCount)
+
+	iter, err := runner.Resume(ctx, "1")
+	assert.NoError(t, err)
+	events = collectEvents(t, iter)
+	assert.Equal(t, 1, ft.curCount)
+	assert.Equal(t, "done", events[len(events)-1].Output.MessageOutput.Message.Content)
+}
+
+func TestGenInterruptID(t *testing.T) {
+	assert.Equal(t, "root;sub;call_1", genInterruptID([]string{"root", "sub", "call_1"}))
+	// the separator in the names doesn't make different addresses share an ID
+	assert.Equal(t, `a\;b;c`, genInterruptID([]string{"a;b", "c"}))
+	assert.Equal(t, `a;b\;c`, genInterruptID([]string{"a", "b;c"}))
+	assert.Equal(t, `a\\;b`, genInterruptID([]string{`a\`, "b"}))
+	assert.Equal(t, `a\\\;b`, genInterruptID([]string{`a\;b`}))
+}
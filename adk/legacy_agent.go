@@ -0,0 +1,280 @@
+/*
+ * Copyright 2025 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package adk
+
+import (
+	"context"
+	"encoding/gob"
+	"errors"
+	"io"
+	"runtime/debug"
+	"sync"
+
+	"github.com/cloudwego/eino/callbacks"
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/compose"
+	"github.com/cloudwego/eino/flow/agent"
+	"github.com/cloudwego/eino/flow/agent/multiagent/host"
+	"github.com/cloudwego/eino/flow/agent/react"
+	"github.com/cloudwego/eino/internal/safe"
+	"github.com/cloudwego/eino/schema"
+	ub "github.com/cloudwego/eino/utils/callbacks"
+)
+
+func init() {
+	// hand off events are kept in the session, which is saved in the checkpoints
+	gob.RegisterName("_eino_host_hand_off_info", &host.HandOffInfo{})
+	_ = RegisterEventValueType[host.HandOffInfo]("_eino_host_hand_off_info")
+}
+
+type ReactAgentAdapterConfig struct {
+	Name        string
+	Description string
+
+	// Agent is the react agent to run, required.
+	Agent *react.Agent
+}
+
+// NewReactAgentAdapter wraps a react agent of flow/agent/react as an adk Agent,
+// so that it can run by Runner or be a sub-agent of the other agents without rewriting.
+// The messages of the ChatModel and the tools are emitted as events step by step.
+// WithChatModelOptions, WithToolOptions, WithGraphOptions and WithFlowAgentOptions are passed to the react agent.
+func NewReactAgentAdapter(_ context.Context, conf *ReactAgentAdapterConfig) (Agent, error) {
+	if conf.Agent == nil {
+		return nil, errors.New("agent 'Agent' is required")
+	}
+
+	return newLegacyAgent(conf.Name, conf.Description, conf.Agent, "", false)
+}
+
+type HostMultiAgentAdapterConfig struct {
+	Name        string
+	Description string
+
+	// Agent is the host multi-agent to run, required.
+	Agent *host.MultiAgent
+
+	// EmitOutput emits the answer of the multi-agent as the last event.
+	// Set it if the answer isn't the message of a ChatModel specialist, e.g. the specialists are Invokable or Streamable,
+	// or the answers of several specialists are concatenated without a Summarizer.
+	EmitOutput bool
+}
+
+// NewHostMultiAgentAdapter wraps a host multi-agent of flow/agent/multiagent/host as an adk Agent,
+// so that it can run by Runner or be a sub-agent of the other agents without rewriting.
+// The messages of the host and the ChatModel specialists are emitted as events step by step,
+// and each hand off of the host is emitted as an event with AgentAction.CustomizedAction of *host.HandOffInfo.
+// WithChatModelOptions, WithGraphOptions and WithFlowAgentOptions are passed to the multi-agent.
+func NewHostMultiAgentAdapter(_ context.Context, conf *HostMultiAgentAdapterConfig) (Agent, error) {
+	if conf.Agent == nil {
+		return nil, errors.New("agent 'Agent' is required")
+	}
+
+	return newLegacyAgent(conf.Name, conf.Description, conf.Agent, conf.Agent.HostNodeKey(), conf.EmitOutput)
+}
+
+type legacyFlowAgent interface {
+	Generate(ctx context.Context, input []*schema.Message, opts ...agent.AgentOption) (*schema.Message, error)
+	Stream(ctx context.Context, input []*schema.Message, opts ...agent.AgentOption) (*schema.StreamReader[*schema.Message], error)
+}
+
+type legacyAgentRunOptions struct {
+	agentOptions []agent.AgentOption
+}
+
+// WithFlowAgentOptions passes the options to the agents wrapped by NewReactAgentAdapter and NewHostMultiAgentAdapter,
+// e.g. react.WithToolList or host.WithAgentCallbacks.
+// Use AgentRunOption.DesignateAgent to pass them to some of the agents only.
+func WithFlowAgentOptions(opts ...agent.AgentOption) AgentRunOption {
+	return WrapImplSpecificOptFn(func(o *legacyAgentRunOptions) {
+		o.agentOptions = append(o.agentOptions, opts...)
+	})
+}
+
+type legacyAgent struct {
+	name        string
+	description string
+	agent       legacyFlowAgent
+	// hostNodeKey is the node emitting the hand offs, empty if the agent doesn't hand off
+	hostNodeKey string
+	emitOutput  bool
+}
+
+func newLegacyAgent(name, description string, a legacyFlowAgent, hostNodeKey string, emitOutput bool) (*legacyAgent, error) {
+	if name == "" {
+		return nil, errors.New("agent 'Name' is required")
+	}
+	if description == "" {
+		return nil, errors.New("agent 'Description' is required")
+	}
+
+	return &legacyAgent{
+		name:        name,
+		description: description,
+		agent:       a,
+		hostNodeKey: hostNodeKey,
+		emitOutput:  emitOutput,
+	}, nil
+}
+
+func (a *legacyAgent) Name(_ context.Context) string {
+	return a.name
+}
+
+func (a *legacyAgent) Description(_ context.Context) string {
+	return a.description
+}
+
+func (a *legacyAgent) Run(ctx context.Context, input *AgentInput, opts ...AgentRunOption) *AsyncIterator[*AgentEvent] {
+	iterator, generator := newEventIteratorPair(ctx)
+
+	// handOffs tracks the hand offs being read from the streams of the host
+	var handOffs sync.WaitGroup
+	agentOpts := a.getAgentOptions(generator, &handOffs, opts)
+
+	go func() {
+		defer func() {
+			panicErr := recover()
+			if panicErr != nil {
+				e := safe.NewPanicErr(panicErr, debug.Stack())
+				generator.Send(&AgentEvent{Err: e})
+			}
+
+			handOffs.Wait()
+			generator.Close()
+		}()
+
+		if !input.EnableStreaming {
+			msg, err := a.agent.Generate(ctx, input.Messages, agentOpts...)
+			if err != nil {
+				generator.Send(&AgentEvent{Err: err})
+				return
+			}
+			if a.emitOutput {
+				generator.Send(EventFromMessage(msg, nil, schema.Assistant, ""))
+			}
+			return
+		}
+
+		msgStream, err := a.agent.Stream(ctx, input.Messages, agentOpts...)
+		if err != nil {
+			generator.Send(&AgentEvent{Err: err})
+			return
+		}
+
+		// the output is drained before closing the generator, so that all the events of the run have been sent
+		if a.emitOutput {
+			handOffs.Wait()
+			ss := msgStream.Copy(2)
+			generator.Send(EventFromMessage(nil, ss[0], schema.Assistant, ""))
+			msgStream = ss[1]
+		}
+		defer msgStream.Close()
+		for {
+			_, err = msgStream.Recv()
+			if err == io.EOF {
+				return
+			}
+			if err != nil {
+				if !a.emitOutput {
+					generator.Send(&AgentEvent{Err: err})
+				}
+				return
+			}
+		}
+	}()
+
+	return iterator
+}
+
+// getAgentOptions maps the AgentRunOptions to the options of the wrapped agent, along with the callbacks emitting the events.
+func (a *legacyAgent) getAgentOptions(generator *AsyncGenerator[*AgentEvent], handOffs *sync.WaitGroup, opts []AgentRunOption) []agent.AgentOption {
+	co := []compose.Option{genGraphAgentCallbacks(generator)}
+	if a.hostNodeKey != "" {
+		co = append(co, genHandOffCallbacks(generator, handOffs, a.hostNodeKey)...)
+	}
+
+	cmo := GetImplSpecificOptions[chatModelAgentRunOptions](nil, opts...)
+	if len(cmo.chatModelOptions) > 0 {
+		co = append(co, compose.WithChatModelOption(cmo.chatModelOptions...))
+	}
+	if len(cmo.toolOptions) > 0 {
+		co = append(co, compose.WithToolsNodeOption(compose.WithToolOption(cmo.toolOptions...)))
+	}
+	co = append(co, GetImplSpecificOptions(&graphAgentRunOptions{}, opts...).composeOptions...)
+	co = appendInterruptHandle(co, opts)
+
+	lo := GetImplSpecificOptions(&legacyAgentRunOptions{}, opts...)
+	return append([]agent.AgentOption{agent.WithComposeOptions(co...)}, lo.agentOptions...)
+}
+
+// genHandOffCallbacks emits the tool calls of the host as hand off events, after the message event of the host.
+// The streams of the host are read in goroutines tracked by handOffs, so as not to block the host,
+// and the other models wait for the hand offs before starting, so that their events follow the hand offs.
+func genHandOffCallbacks(generator *AsyncGenerator[*AgentEvent], handOffs *sync.WaitGroup, hostNodeKey string) []compose.Option {
+	sendHandOffs := func(msg Message) {
+		if msg == nil {
+			return
+		}
+		for _, tc := range msg.ToolCalls {
+			generator.Send(&AgentEvent{Action: &AgentAction{CustomizedAction: &host.HandOffInfo{
+				ToAgentName: tc.Function.Name,
+				Argument:    tc.Function.Arguments,
+			}}})
+		}
+	}
+
+	cmHandler := &ub.ModelCallbackHandler{
+		OnEnd: func(ctx context.Context, _ *callbacks.RunInfo, output *model.CallbackOutput) context.Context {
+			sendHandOffs(output.Message)
+			return ctx
+		},
+		OnEndWithStreamOutput: func(ctx context.Context, _ *callbacks.RunInfo, output *schema.StreamReader[*model.CallbackOutput]) context.Context {
+			handOffs.Add(1)
+			go func() {
+				defer func() {
+					panicErr := recover()
+					if panicErr != nil {
+						e := safe.NewPanicErr(panicErr, debug.Stack())
+						generator.Send(&AgentEvent{Err: e})
+					}
+					handOffs.Done()
+				}()
+
+				msg, err := schema.ConcatMessageStream(schema.StreamReaderWithConvert(output, func(in *model.CallbackOutput) (Message, error) {
+					return in.Message, nil
+				}))
+				if err == nil {
+					sendHandOffs(msg)
+				}
+			}()
+			return ctx
+		},
+	}
+
+	waitHandler := &ub.ModelCallbackHandler{
+		OnStart: func(ctx context.Context, _ *callbacks.RunInfo, _ *model.CallbackInput) context.Context {
+			handOffs.Wait()
+			return ctx
+		},
+	}
+
+	return []compose.Option{
+		compose.WithCallbacks(ub.NewHandlerHelper().ChatModel(cmHandler).Handler()).DesignateNode(hostNodeKey),
+		compose.WithCallbacks(ub.NewHandlerHelper().ChatModel(waitHandler).Handler()),
+	}
+}
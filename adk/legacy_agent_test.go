@@ -0,0 +1,169 @@
+/*
+ * Copyright 2025 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package adk
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/compose"
+	"github.com/cloudwego/eino/flow/agent"
+	"github.com/cloudwego/eino/flow/agent/multiagent/host"
+	"github.com/cloudwego/eino/flow/agent/react"
+	mockModel "github.com/cloudwego/eino/internal/mock/components/model"
+	"github.com/cloudwego/eino/schema"
+)
+
+func TestLegacyAgentAdapter(t *testing.T) {
+	ctx := context.Background()
+
+	describe := func(t *testing.T, iter *AsyncIterator[*AgentEvent]) []string {
+		var steps []string
+		for _, event := range collectEvents(t, iter) {
+			if !assert.NoError(t, event.Err) {
+				continue
+			}
+			if event.Action != nil {
+				handOff, ok := event.Action.CustomizedAction.(*host.HandOffInfo)
+				assert.True(t, ok)
+				steps = append(steps, "hand off: "+handOff.ToAgentName+" "+handOff.Argument)
+				continue
+			}
+			msg, err := event.Output.MessageOutput.GetMessage()
+			assert.NoError(t, err)
+			if len(msg.ToolCalls) > 0 {
+				steps = append(steps, event.AgentName+" calls: "+msg.ToolCalls[0].Function.Name)
+			} else {
+				steps = append(steps, event.AgentName+" "+string(msg.Role)+": "+msg.Content)
+			}
+		}
+		return steps
+	}
+
+	t.Run("react", func(t *testing.T) {
+		cm := mockModel.NewMockToolCallingChatModel(gomock.NewController(t))
+		cm.EXPECT().WithTools(gomock.Any()).Return(cm, nil).AnyTimes()
+		cm.EXPECT().Generate(gomock.Any(), gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+				assert.Equal(t, float32(0.5), *model.GetCommonOptions(nil, opts...).Temperature)
+				return toolCallMessage("1", "test_tool", `{"name": "tom"}`), nil
+			}).Times(1)
+		cm.EXPECT().Generate(gomock.Any(), gomock.Any(), gomock.Any()).Return(schema.AssistantMessage("done", nil), nil).Times(1)
+
+		ra, err := react.NewAgent(ctx, &react.AgentConfig{
+			ToolCallingModel: cm,
+			ToolsConfig:      compose.ToolsNodeConfig{Tools: []tool.BaseTool{&fakeToolForTest{}}},
+		})
+		assert.NoError(t, err)
+		a, err := NewReactAgentAdapter(ctx, &ReactAgentAdapterConfig{Name: "legacy", Description: "legacy", Agent: ra})
+		assert.NoError(t, err)
+
+		iter := NewRunner(ctx, RunnerConfig{Agent: a}).Query(ctx, "hi",
+			WithChatModelOptions([]model.Option{model.WithTemperature(0.5)}))
+		assert.Equal(t, []string{
+			"legacy calls: test_tool",
+			`legacy tool: {"say": "bye"}`,
+			"legacy assistant: done",
+		}, describe(t, iter))
+	})
+
+	newHost := func(t *testing.T, streaming bool) *mockModel.MockToolCallingChatModel {
+		hostModel := mockModel.NewMockToolCallingChatModel(gomock.NewController(t))
+		hostModel.EXPECT().WithTools(gomock.Any()).Return(hostModel, nil).AnyTimes()
+		handOff := toolCallMessage("1", "weather", `{"reason": "asked"}`)
+		if streaming {
+			index := 0
+			hostModel.EXPECT().Stream(gomock.Any(), gomock.Any(), gomock.Any()).Return(schema.StreamReaderFromArray([]*schema.Message{
+				{Role: schema.Assistant, ToolCalls: []schema.ToolCall{{Index: &index, ID: "1", Function: schema.FunctionCall{Name: "weather", Arguments: `{"reason": `}}}},
+				{Role: schema.Assistant, ToolCalls: []schema.ToolCall{{Index: &index, Function: schema.FunctionCall{Arguments: `"asked"}`}}}},
+			}), nil).Times(1)
+		} else {
+			hostModel.EXPECT().Generate(gomock.Any(), gomock.Any(), gomock.Any()).Return(handOff, nil).Times(1)
+		}
+		return hostModel
+	}
+
+	t.Run("host streaming", func(t *testing.T) {
+		specialist := mockModel.NewMockToolCallingChatModel(gomock.NewController(t))
+		specialist.EXPECT().Stream(gomock.Any(), gomock.Any(), gomock.Any()).
+			Return(schema.StreamReaderFromArray([]*schema.Message{schema.AssistantMessage("sun", nil), schema.AssistantMessage("ny", nil)}), nil).Times(1)
+
+		ma, err := host.NewMultiAgent(ctx, &host.MultiAgentConfig{
+			Host: host.Host{ToolCallingModel: newHost(t, true)},
+			Specialists: []*host.Specialist{
+				{AgentMeta: host.AgentMeta{Name: "weather", IntendedUse: "weather"}, ChatModel: specialist},
+				{AgentMeta: host.AgentMeta{Name: "news", IntendedUse: "news"}, ChatModel: specialist},
+			},
+		})
+		assert.NoError(t, err)
+		a, err := NewHostMultiAgentAdapter(ctx, &HostMultiAgentAdapterConfig{Name: "legacy", Description: "legacy", Agent: ma})
+		assert.NoError(t, err)
+
+		iter := NewRunner(ctx, RunnerConfig{Agent: a, EnableStreaming: true}).Query(ctx, "weather?")
+		assert.Equal(t, []string{
+			"legacy calls: weather",
+			`hand off: weather {"reason": "asked"}`,
+			"legacy assistant: sunny",
+		}, describe(t, iter))
+	})
+
+	t.Run("host invokable specialist", func(t *testing.T) {
+		var handOffs []*host.HandOffInfo
+		ma, err := host.NewMultiAgent(ctx, &host.MultiAgentConfig{
+			Host: host.Host{ToolCallingModel: newHost(t, false)},
+			Specialists: []*host.Specialist{
+				{
+					AgentMeta: host.AgentMeta{Name: "weather", IntendedUse: "weather"},
+					Invokable: func(ctx context.Context, input []*schema.Message, opts ...agent.AgentOption) (*schema.Message, error) {
+						return schema.AssistantMessage("rainy", nil), nil
+					},
+				},
+			},
+		})
+		assert.NoError(t, err)
+		a, err := NewHostMultiAgentAdapter(ctx, &HostMultiAgentAdapterConfig{Name: "legacy", Description: "legacy", Agent: ma, EmitOutput: true})
+		assert.NoError(t, err)
+
+		iter := NewRunner(ctx, RunnerConfig{Agent: a}).Query(ctx, "weather?",
+			WithFlowAgentOptions(host.WithAgentCallbacks(handOffCollector(func(info *host.HandOffInfo) {
+				handOffs = append(handOffs, info)
+			}))))
+		assert.Equal(t, []string{
+			"legacy calls: weather",
+			`hand off: weather {"reason": "asked"}`,
+			"legacy assistant: rainy",
+		}, describe(t, iter))
+		assert.Equal(t, []*host.HandOffInfo{{ToAgentName: "weather", Argument: `{"reason": "asked"}`}}, handOffs)
+	})
+
+	t.Run("config", func(t *testing.T) {
+		_, err := NewReactAgentAdapter(ctx, &ReactAgentAdapterConfig{Name: "legacy", Description: "legacy"})
+		assert.Error(t, err)
+	})
+}
+
+type handOffCollector func(info *host.HandOffInfo)
+
+func (c handOffCollector) OnHandOff(ctx context.Context, info *host.HandOffInfo) context.Context {
+	c(info)
+	return ctx
+}
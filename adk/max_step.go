@@ -0,0 +1,65 @@
+/*
+ * Copyright 2025 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package adk
+
+import (
+	"context"
+
+	"github.com/cloudwego/eino/compose"
+)
+
+const defaultMaxStepAnswerPrompt = "You have run out of steps and cannot call tools any more. " +
+	"Summarize what you have found so far and give the best final answer you can."
+
+// MaxStepAnswerConfig makes ChatModelAgent give a final answer when MaxStep is exhausted, instead of failing.
+// The model is called once more with the prompt and with tool calling disabled,
+// and its answer is the final output of the agent, with AgentOutput.MaxStepExhausted set.
+// It takes effect only if MaxStep is set.
+type MaxStepAnswerConfig struct {
+	// Prompt asks the model for the final answer with what it has found, optional.
+	Prompt string
+}
+
+func (conf *MaxStepAnswerConfig) prompt() string {
+	if conf.Prompt != "" {
+		return conf.Prompt
+	}
+	return defaultMaxStepAnswerPrompt
+}
+
+// maxStepAnswerReservedSteps are the steps after MaxStep for the final answer:
+// one to drop the tool calls that can't run, and one for the model.
+const maxStepAnswerReservedSteps = 2
+
+func isMaxStepExhausted(ctx context.Context) bool {
+	var exhausted bool
+	_ = compose.ProcessState(ctx, func(_ context.Context, st *State) error {
+		exhausted = st.MaxStepExhausted
+		return nil
+	})
+	return exhausted
+}
+
+// isToolCallingForbidden disables tool calling for the model calls asking for the final answer,
+// on a loop of tool calls or when MaxStep is exhausted, see toolloop.ToolsForbiddenChatModel.
+func isToolCallingForbidden(ctx context.Context) (forbidden, flag bool) {
+	_ = compose.ProcessState(ctx, func(_ context.Context, st *State) error {
+		forbidden = st.ToolCallLoop != nil || st.MaxStepExhausted
+		return nil
+	})
+	return forbidden, false
+}
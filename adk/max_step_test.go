@@ -0,0 +1,118 @@
+/*
+ * Copyright 2025 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package adk
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/compose"
+	mockModel "github.com/cloudwego/eino/internal/mock/components/model"
+	"github.com/cloudwego/eino/schema"
+)
+
+func TestMaxStepAnswer(t *testing.T) {
+	ctx := context.Background()
+
+	// run calls the tool until the tool calling is forbidden, or always if ignoreForbidden
+	run := func(t *testing.T, maxStep int, conf *MaxStepAnswerConfig, stream, ignoreForbidden bool) (events []*AgentEvent, inputs [][]Message, ft *fakeToolForTest) {
+		cm := mockModel.NewMockToolCallingChatModel(gomock.NewController(t))
+		cm.EXPECT().WithTools(gomock.Any()).Return(cm, nil).AnyTimes()
+		next := func(input []*schema.Message, opts []model.Option) *schema.Message {
+			inputs = append(inputs, input)
+			o := model.GetCommonOptions(nil, opts...)
+			if ignoreForbidden || o.ToolChoice == nil || *o.ToolChoice != schema.ToolChoiceForbidden {
+				return toolCallMessage("1", "test_tool", `{"name": "tom"}`)
+			}
+			return schema.AssistantMessage("summary", nil)
+		}
+		if stream {
+			cm.EXPECT().Stream(gomock.Any(), gomock.Any(), gomock.Any()).
+				DoAndReturn(func(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+					return schema.StreamReaderFromArray([]*schema.Message{next(input, opts)}), nil
+				}).AnyTimes()
+		} else {
+			cm.EXPECT().Generate(gomock.Any(), gomock.Any(), gomock.Any()).
+				DoAndReturn(func(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+					return next(input, opts), nil
+				}).AnyTimes()
+		}
+
+		ft = &fakeToolForTest{tarCount: 100}
+		a, err := NewChatModelAgent(ctx, &ChatModelAgentConfig{
+			Name:          "searcher",
+			Description:   "searcher",
+			Model:         cm,
+			ToolsConfig:   ToolsConfig{ToolsNodeConfig: compose.ToolsNodeConfig{Tools: []tool.BaseTool{ft}}},
+			MaxStep:       maxStep,
+			MaxStepAnswer: conf,
+		})
+		assert.NoError(t, err)
+
+		iter := NewRunner(ctx, RunnerConfig{Agent: a, EnableStreaming: stream}).Query(ctx, "hi")
+		for {
+			event, ok := iter.Next()
+			if !ok {
+				return events, inputs, ft
+			}
+			events = append(events, event)
+		}
+	}
+
+	t.Run("answer", func(t *testing.T) {
+		// the tool calls are run if the model is left a step to answer with the results
+		for maxStep, toolRuns := range map[int]int{3: 1, 4: 2} {
+			events, inputs, ft := run(t, maxStep, &MaxStepAnswerConfig{}, false, false)
+			last := events[len(events)-1]
+			assert.NoError(t, last.Err)
+			assert.True(t, last.Output.MaxStepExhausted)
+			assert.Equal(t, "summary", last.Output.MessageOutput.Message.Content)
+			assert.Equal(t, toolRuns, ft.curCount)
+			assert.Len(t, inputs, 3)
+			lastInput := inputs[len(inputs)-1]
+			assert.Equal(t, defaultMaxStepAnswerPrompt, lastInput[len(lastInput)-1].Content)
+			// the tool calls which can't run are dropped
+			assert.Equal(t, schema.Tool, lastInput[len(lastInput)-2].Role)
+
+			for _, e := range events[:len(events)-1] {
+				assert.False(t, e.Output.MaxStepExhausted)
+			}
+		}
+	})
+
+	t.Run("answer in stream", func(t *testing.T) {
+		events, inputs, _ := run(t, 2, &MaxStepAnswerConfig{Prompt: "wrap up"}, true, false)
+		last := events[len(events)-1]
+		assert.True(t, last.Output.MaxStepExhausted)
+		msg, err := last.Output.MessageOutput.GetMessage()
+		assert.NoError(t, err)
+		assert.Equal(t, "summary", msg.Content)
+		lastInput := inputs[len(inputs)-1]
+		assert.Equal(t, "wrap up", lastInput[len(lastInput)-1].Content)
+	})
+
+	t.Run("still calling tools", func(t *testing.T) {
+		events, _, _ := run(t, 3, &MaxStepAnswerConfig{}, false, true)
+		assert.True(t, errors.Is(events[len(events)-1].Err, compose.ErrExceedMaxSteps))
+	})
+}
@@ -0,0 +1,414 @@
+/*
+ * Copyright 2025 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package adk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/bytedance/sonic"
+
+	"github.com/cloudwego/eino/components/embedding"
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/schema"
+)
+
+// Memory is a piece of long-term memory, which outlives the runs.
+type Memory struct {
+	// Namespace isolates the memories, e.g. of different users.
+	Namespace string
+	// Key identifies the memory in the namespace, putting a memory with an existing key overwrites it.
+	// MemoryStore.Put generates a key not used in the namespace if it's empty.
+	Key      string
+	Content  string
+	Metadata map[string]any
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+
+	// Score is the relevance to the query, set by MemoryStore.Search.
+	Score float64
+}
+
+type MemorySearchRequest struct {
+	Namespace string
+	Query     string
+	// TopK is the max number of the memories returned, 0 means no limit.
+	TopK int
+}
+
+// MemoryStore persists the long-term memories.
+type MemoryStore interface {
+	// Put saves the memory, and sets its key if it's empty.
+	Put(ctx context.Context, m *Memory) error
+	// Search returns the memories of the namespace relevant to the query, most relevant first.
+	Search(ctx context.Context, req *MemorySearchRequest) ([]*Memory, error)
+}
+
+const (
+	SaveMemoryToolName   = "save_memory"
+	SearchMemoryToolName = "search_memory"
+
+	defaultMemoryTopK = 5
+)
+
+// MemoryConfig gives ChatModelAgent long-term memories, see ChatModelAgentConfig.Memory.
+type MemoryConfig struct {
+	// Store of the memories, required.
+	Store MemoryStore
+
+	// Namespace returns the namespace of the memories for the run, optional, defaults to the name of the agent.
+	// e.g. return the user id from the session values to keep the memories per user.
+	Namespace func(ctx context.Context) string
+
+	// TopK is the number of the memories injected into the model input, optional, defaults to 5.
+	TopK int
+
+	// DisableInjection disables injecting the memories relevant to the latest user message into the model input.
+	DisableInjection bool
+
+	// DisableTools disables the save_memory and search_memory tools of the agent.
+	DisableTools bool
+}
+
+type memoryManager struct {
+	store     MemoryStore
+	namespace func(ctx context.Context) string
+	topK      int
+}
+
+func newMemoryManager(agentName string, conf *MemoryConfig) (*memoryManager, error) {
+	if conf.Store == nil {
+		return nil, errors.New("memory 'Store' is required")
+	}
+
+	m := &memoryManager{store: conf.Store, namespace: conf.Namespace, topK: conf.TopK}
+	if m.namespace == nil {
+		m.namespace = func(context.Context) string { return agentName }
+	}
+	if m.topK <= 0 {
+		m.topK = defaultMemoryTopK
+	}
+	return m, nil
+}
+
+// wrapGenModelInput injects the memories relevant to the latest user message as a system message,
+// right after the leading system messages.
+func (m *memoryManager) wrapGenModelInput(gen GenModelInput) GenModelInput {
+	return func(ctx context.Context, instruction string, input *AgentInput) ([]Message, error) {
+		msgs, err := gen(ctx, instruction, input)
+		if err != nil {
+			return nil, err
+		}
+
+		var query string
+		for i := len(input.Messages) - 1; i >= 0; i-- {
+			if input.Messages[i].Role == schema.User {
+				query = input.Messages[i].Content
+				break
+			}
+		}
+		if query == "" {
+			return msgs, nil
+		}
+
+		memories, err := m.store.Search(ctx, &MemorySearchRequest{Namespace: m.namespace(ctx), Query: query, TopK: m.topK})
+		if err != nil {
+			return nil, fmt.Errorf("failed to search memories: %w", err)
+		}
+		if len(memories) == 0 {
+			return msgs, nil
+		}
+
+		var sb strings.Builder
+		sb.WriteString("Relevant memories from previous conversations:")
+		for _, mem := range memories {
+			sb.WriteString("\n- ")
+			sb.WriteString(mem.Content)
+		}
+
+		i := 0
+		for i < len(msgs) && msgs[i].Role == schema.System {
+			i++
+		}
+		injected := make([]Message, 0, len(msgs)+1)
+		injected = append(injected, msgs[:i]...)
+		injected = append(injected, schema.SystemMessage(sb.String()))
+		return append(injected, msgs[i:]...), nil
+	}
+}
+
+func (m *memoryManager) tools() []tool.BaseTool {
+	return []tool.BaseTool{&saveMemoryTool{m: m}, &searchMemoryTool{m: m}}
+}
+
+type saveMemoryTool struct {
+	m *memoryManager
+}
+
+func (t *saveMemoryTool) Info(_ context.Context) (*schema.ToolInfo, error) {
+	return &schema.ToolInfo{
+		Name: SaveMemoryToolName,
+		Desc: "Save a fact worth remembering in future conversations, such as the preferences of the user.",
+		ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+			"content": {
+				Type:     schema.String,
+				Desc:     "the fact to remember, self-contained without the context of the conversation",
+				Required: true,
+			},
+			"key": {
+				Type: schema.String,
+				Desc: "key of the memory to overwrite, omit it to save a new memory",
+			},
+		}),
+	}, nil
+}
+
+func (t *saveMemoryTool) InvokableRun(ctx context.Context, argumentsInJSON string, _ ...tool.Option) (string, error) {
+	var args struct {
+		Content string `json:"content"`
+		Key     string `json:"key"`
+	}
+	if err := sonic.UnmarshalString(argumentsInJSON, &args); err != nil {
+		return "", err
+	}
+	if args.Content == "" {
+		return "content is empty, nothing is saved", nil
+	}
+
+	mem := &Memory{Namespace: t.m.namespace(ctx), Key: args.Key, Content: args.Content}
+	if err := t.m.store.Put(ctx, mem); err != nil {
+		return "", fmt.Errorf("failed to save memory: %w", err)
+	}
+	return fmt.Sprintf("memory saved with key '%s'", mem.Key), nil
+}
+
+type searchMemoryTool struct {
+	m *memoryManager
+}
+
+func (t *searchMemoryTool) Info(_ context.Context) (*schema.ToolInfo, error) {
+	return &schema.ToolInfo{
+		Name: SearchMemoryToolName,
+		Desc: "Search the memories saved in previous conversations.",
+		ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+			"query": {
+				Type:     schema.String,
+				Desc:     "what to search for",
+				Required: true,
+			},
+		}),
+	}, nil
+}
+
+type memorySearchResult struct {
+	Key     string `json:"key"`
+	Content string `json:"content"`
+}
+
+func (t *searchMemoryTool) InvokableRun(ctx context.Context, argumentsInJSON string, _ ...tool.Option) (string, error) {
+	var args struct {
+		Query string `json:"query"`
+	}
+	if err := sonic.UnmarshalString(argumentsInJSON, &args); err != nil {
+		return "", err
+	}
+
+	memories, err := t.m.store.Search(ctx, &MemorySearchRequest{Namespace: t.m.namespace(ctx), Query: args.Query, TopK: t.m.topK})
+	if err != nil {
+		return "", fmt.Errorf("failed to search memories: %w", err)
+	}
+	if len(memories) == 0 {
+		return "no memory found", nil
+	}
+
+	results := make([]*memorySearchResult, len(memories))
+	for i, mem := range memories {
+		results[i] = &memorySearchResult{Key: mem.Key, Content: mem.Content}
+	}
+	return sonic.MarshalString(results)
+}
+
+type InMemoryMemoryStoreConfig struct {
+	// Embedder embeds the memories and the queries for the vector search, optional.
+	// Without it, the memories are scored by the words they share with the query.
+	Embedder embedding.Embedder
+}
+
+// NewInMemoryMemoryStore returns a MemoryStore keeping the memories in memory, for tests and local development.
+func NewInMemoryMemoryStore(_ context.Context, conf *InMemoryMemoryStoreConfig) (MemoryStore, error) {
+	s := &inMemoryMemoryStore{namespaces: make(map[string][]*storedMemory)}
+	if conf != nil {
+		s.embedder = conf.Embedder
+	}
+	return s, nil
+}
+
+type storedMemory struct {
+	Memory
+	vector []float64
+	words  map[string]bool
+}
+
+type inMemoryMemoryStore struct {
+	embedder embedding.Embedder
+
+	mu         sync.RWMutex
+	namespaces map[string][]*storedMemory
+	seq        int
+}
+
+func (s *inMemoryMemoryStore) Put(ctx context.Context, m *Memory) error {
+	sm := &storedMemory{Memory: *m}
+	if s.embedder != nil {
+		vectors, err := s.embedder.EmbedStrings(ctx, []string{m.Content})
+		if err != nil {
+			return fmt.Errorf("failed to embed memory: %w", err)
+		}
+		if len(vectors) != 1 {
+			return fmt.Errorf("embedder returns %d vectors for 1 memory", len(vectors))
+		}
+		sm.vector = vectors[0]
+	} else {
+		sm.words = splitWords(m.Content)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	sm.UpdatedAt = now
+	sm.Score = 0
+
+	memories := s.namespaces[m.Namespace]
+	if m.Key != "" {
+		for i, old := range memories {
+			if old.Key == m.Key {
+				sm.CreatedAt = old.CreatedAt
+				memories[i] = sm
+				m.CreatedAt, m.UpdatedAt = sm.CreatedAt, sm.UpdatedAt
+				return nil
+			}
+		}
+	} else {
+		sm.Key = s.nextKey(memories)
+		m.Key = sm.Key
+	}
+
+	sm.CreatedAt = now
+	m.CreatedAt, m.UpdatedAt = sm.CreatedAt, sm.UpdatedAt
+	s.namespaces[m.Namespace] = append(memories, sm)
+	return nil
+}
+
+// nextKey generates the key of a new memory, skipping the keys given by the callers, e.g. "memory-1",
+// so that the generated key doesn't overwrite another memory.
+func (s *inMemoryMemoryStore) nextKey(memories []*storedMemory) string {
+	for {
+		s.seq++
+		key := fmt.Sprintf("memory-%d", s.seq)
+		taken := false
+		for _, mem := range memories {
+			if mem.Key == key {
+				taken = true
+				break
+			}
+		}
+		if !taken {
+			return key
+		}
+	}
+}
+
+func (s *inMemoryMemoryStore) Search(ctx context.Context, req *MemorySearchRequest) ([]*Memory, error) {
+	var (
+		vector []float64
+		words  map[string]bool
+	)
+	if s.embedder != nil {
+		vectors, err := s.embedder.EmbedStrings(ctx, []string{req.Query})
+		if err != nil {
+			return nil, fmt.Errorf("failed to embed query: %w", err)
+		}
+		if len(vectors) != 1 {
+			return nil, fmt.Errorf("embedder returns %d vectors for 1 query", len(vectors))
+		}
+		vector = vectors[0]
+	} else {
+		words = splitWords(req.Query)
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var results []*Memory
+	for _, sm := range s.namespaces[req.Namespace] {
+		var score float64
+		if vector != nil {
+			score = cosineSimilarity(vector, sm.vector)
+		} else {
+			score = wordOverlap(words, sm.words)
+		}
+		if score <= 0 {
+			continue
+		}
+
+		m := sm.Memory
+		m.Metadata = copyMap(sm.Metadata)
+		m.Score = score
+		results = append(results, &m)
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+	if req.TopK > 0 && len(results) > req.TopK {
+		results = results[:req.TopK]
+	}
+	return results, nil
+}
+
+func splitWords(s string) map[string]bool {
+	words := make(map[string]bool)
+	for _, w := range strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	}) {
+		words[w] = true
+	}
+	return words
+}
+
+// wordOverlap is the fraction of the query words found in the memory.
+func wordOverlap(query, memory map[string]bool) float64 {
+	if len(query) == 0 {
+		return 0
+	}
+
+	var n int
+	for w := range query {
+		if memory[w] {
+			n++
+		}
+	}
+	return float64(n) / float64(len(query))
+}
@@ -0,0 +1,152 @@
+/*
+ * Copyright 2025 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package adk
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"github.com/cloudwego/eino/components/model"
+	mockModel "github.com/cloudwego/eino/internal/mock/components/model"
+	"github.com/cloudwego/eino/schema"
+)
+
+func TestInMemoryMemoryStore(t *testing.T) {
+	ctx := context.Background()
+
+	contents := func(memories []*Memory) []string {
+		var cs []string
+		for _, m := range memories {
+			cs = append(cs, m.Content)
+		}
+		return cs
+	}
+
+	t.Run("words", func(t *testing.T) {
+		store, err := NewInMemoryMemoryStore(ctx, nil)
+		assert.NoError(t, err)
+
+		m := &Memory{Namespace: "alice", Content: "Alice likes green tea"}
+		assert.NoError(t, store.Put(ctx, m))
+		assert.Equal(t, "memory-1", m.Key)
+		assert.NoError(t, store.Put(ctx, &Memory{Namespace: "alice", Content: "Alice lives in Paris"}))
+		assert.NoError(t, store.Put(ctx, &Memory{Namespace: "bob", Content: "Bob likes coffee"}))
+
+		memories, err := store.Search(ctx, &MemorySearchRequest{Namespace: "alice", Query: "what tea does she like"})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"Alice likes green tea"}, contents(memories))
+
+		// overwrite
+		assert.NoError(t, store.Put(ctx, &Memory{Namespace: "alice", Key: "memory-1", Content: "Alice likes black tea"}))
+		memories, err = store.Search(ctx, &MemorySearchRequest{Namespace: "alice", Query: "Alice tea", TopK: 1})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"Alice likes black tea"}, contents(memories))
+		assert.Equal(t, float64(1), memories[0].Score)
+		assert.False(t, memories[0].CreatedAt.After(memories[0].UpdatedAt))
+	})
+
+	t.Run("generated key skips given keys", func(t *testing.T) {
+		store, err := NewInMemoryMemoryStore(ctx, nil)
+		assert.NoError(t, err)
+
+		assert.NoError(t, store.Put(ctx, &Memory{Namespace: "n", Key: "memory-1", Content: "given"}))
+		m := &Memory{Namespace: "n", Content: "generated"}
+		assert.NoError(t, store.Put(ctx, m))
+		assert.Equal(t, "memory-2", m.Key)
+
+		memories, err := store.Search(ctx, &MemorySearchRequest{Namespace: "n", Query: "given generated"})
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []string{"given", "generated"}, contents(memories))
+	})
+
+	t.Run("embedding", func(t *testing.T) {
+		store, err := NewInMemoryMemoryStore(ctx, &InMemoryMemoryStoreConfig{Embedder: &keywordEmbedder{}})
+		assert.NoError(t, err)
+
+		assert.NoError(t, store.Put(ctx, &Memory{Namespace: "n", Content: "checks the weather every morning"}))
+		assert.NoError(t, store.Put(ctx, &Memory{Namespace: "n", Content: "prefers email over calendar invites"}))
+
+		memories, err := store.Search(ctx, &MemorySearchRequest{Namespace: "n", Query: "send an email"})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"prefers email over calendar invites"}, contents(memories))
+	})
+}
+
+func TestChatModelAgentMemory(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	cm := mockModel.NewMockToolCallingChatModel(ctrl)
+	cm.EXPECT().WithTools(gomock.Any()).Return(cm, nil).AnyTimes()
+
+	store, err := NewInMemoryMemoryStore(ctx, nil)
+	assert.NoError(t, err)
+
+	a, err := NewChatModelAgent(ctx, &ChatModelAgentConfig{
+		Name:        "assistant",
+		Description: "assistant",
+		Instruction: "you are a helpful assistant",
+		Model:       cm,
+		Memory: &MemoryConfig{
+			Store: store,
+			Namespace: func(ctx context.Context) string {
+				user, _ := GetSessionValue(ctx, "user")
+				return user.(string)
+			},
+		},
+		GenModelInput: func(ctx context.Context, instruction string, input *AgentInput) ([]Message, error) {
+			SetSessionValue(ctx, "user", "alice")
+			return []Message{schema.SystemMessage(instruction), input.Messages[len(input.Messages)-1]}, nil
+		},
+	})
+	assert.NoError(t, err)
+
+	gomock.InOrder(
+		cm.EXPECT().Generate(gomock.Any(), gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, input []Message, _ ...model.Option) (Message, error) {
+				assert.Len(t, input, 2)
+				return toolCallMessage("1", SaveMemoryToolName, `{"content":"the user likes green tea"}`), nil
+			}),
+		cm.EXPECT().Generate(gomock.Any(), gomock.Any(), gomock.Any()).
+			Return(schema.AssistantMessage("noted", nil), nil),
+		cm.EXPECT().Generate(gomock.Any(), gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, input []Message, _ ...model.Option) (Message, error) {
+				assert.Equal(t, []Message{
+					schema.SystemMessage("you are a helpful assistant"),
+					schema.SystemMessage("Relevant memories from previous conversations:\n- the user likes green tea"),
+					schema.UserMessage("which tea should I buy"),
+				}, input)
+				return schema.AssistantMessage("green tea", nil), nil
+			}),
+	)
+
+	r := NewRunner(ctx, RunnerConfig{Agent: a})
+	events := collectEvents(t, r.Query(ctx, "I like green tea"))
+	assert.Equal(t, "memory saved with key 'memory-1'", events[1].Output.MessageOutput.Message.Content)
+
+	collectEvents(t, r.Query(ctx, "which tea should I buy"))
+
+	memories, err := store.Search(ctx, &MemorySearchRequest{Namespace: "alice", Query: "tea"})
+	assert.NoError(t, err)
+	assert.Len(t, memories, 1)
+
+	node, err := DescribeAgent(ctx, a)
+	assert.NoError(t, err)
+	assert.Len(t, node.Tools, 2)
+}
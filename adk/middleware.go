@@ -0,0 +1,513 @@
+/*
+ * Copyright 2025 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package adk
+
+import (
+	"context"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/cloudwego/eino/callbacks"
+	"github.com/cloudwego/eino/components"
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/compose"
+	"github.com/cloudwego/eino/schema"
+)
+
+// AgentMiddleware hooks around the model and tool calls of ChatModelAgent.
+// Before hooks run in the order of ChatModelAgentConfig.Middlewares, and After hooks run in the reverse order.
+// Hooks run on a snapshot of the State of the agent without holding its lock, the changes made by the hooks
+// are applied to the State after the hooks return. Session values are available with GetSessionValue and SetSessionValue.
+// An error returned by any hook fails the agent.
+//
+// Tool hooks don't run for the tools added by the agent itself, i.e. the transfer tool, the exit tool
+// and the final answer tool of OutputConfig.
+//
+// When the model or the tool triggers callbacks by itself (components.Checker), the events of the agent
+// carry its original output rather than the one rewritten by AfterModel or AfterTool.
+type AgentMiddleware struct {
+	// BeforeModel can rewrite the messages sent to the model, or set Response to skip calling the model.
+	BeforeModel func(ctx context.Context, state *State, call *ModelCall) error
+	// AfterModel can rewrite the response, or set Final to end the agent with the response.
+	AfterModel func(ctx context.Context, state *State, call *ModelCall) error
+	// BeforeTool can rewrite the arguments, or set Result to skip calling the tool, e.g. to block it.
+	BeforeTool func(ctx context.Context, state *State, call *ToolInvocation) error
+	// AfterTool can rewrite the result.
+	AfterTool func(ctx context.Context, state *State, call *ToolInvocation) error
+}
+
+// ModelCall is a call to the model of ChatModelAgent.
+type ModelCall struct {
+	// Messages sent to the model, rewriting it doesn't change State.Messages.
+	Messages []Message
+	// Response of the model, nil before the model is called.
+	// In streaming mode, the response stream is concatenated if there are AfterModel hooks.
+	Response Message
+	// Final ends the agent with Response, even if it has tool calls.
+	Final bool
+}
+
+// ToolInvocation is a call to a tool of ChatModelAgent.
+type ToolInvocation struct {
+	Name       string
+	ToolCallID string
+	Arguments  string
+	// Result of the tool, nil before the tool is called.
+	// In streaming mode, the result stream is concatenated if there are AfterTool hooks.
+	Result *string
+}
+
+type middlewares []AgentMiddleware
+
+func (ms middlewares) hasModelHooks() bool {
+	for _, m := range ms {
+		if m.BeforeModel != nil || m.AfterModel != nil {
+			return true
+		}
+	}
+	return false
+}
+
+func (ms middlewares) hasToolHooks() bool {
+	for _, m := range ms {
+		if m.BeforeTool != nil || m.AfterTool != nil {
+			return true
+		}
+	}
+	return false
+}
+
+func (ms middlewares) hasAfterModel() bool {
+	for _, m := range ms {
+		if m.AfterModel != nil {
+			return true
+		}
+	}
+	return false
+}
+
+func (ms middlewares) hasAfterTool() bool {
+	for _, m := range ms {
+		if m.AfterTool != nil {
+			return true
+		}
+	}
+	return false
+}
+
+func (ms middlewares) beforeModel(ctx context.Context, call *ModelCall) error {
+	return runOnStateSnapshot(ctx, func(st *State) error {
+		for _, m := range ms {
+			if m.BeforeModel == nil {
+				continue
+			}
+			if err := m.BeforeModel(ctx, st, call); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (ms middlewares) afterModel(ctx context.Context, call *ModelCall) error {
+	return runOnStateSnapshot(ctx, func(st *State) error {
+		for i := len(ms) - 1; i >= 0; i-- {
+			if ms[i].AfterModel == nil {
+				continue
+			}
+			if err := ms[i].AfterModel(ctx, st, call); err != nil {
+				return err
+			}
+		}
+		if call.Final {
+			st.FinalResponse = true
+		}
+		return nil
+	})
+}
+
+func (ms middlewares) beforeTool(ctx context.Context, call *ToolInvocation) error {
+	return runOnStateSnapshot(ctx, func(st *State) error {
+		for _, m := range ms {
+			if m.BeforeTool == nil {
+				continue
+			}
+			if err := m.BeforeTool(ctx, st, call); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (ms middlewares) afterTool(ctx context.Context, call *ToolInvocation) error {
+	return runOnStateSnapshot(ctx, func(st *State) error {
+		for i := len(ms) - 1; i >= 0; i-- {
+			if ms[i].AfterTool == nil {
+				continue
+			}
+			if err := ms[i].AfterTool(ctx, st, call); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// runOnStateSnapshot runs the hooks on a snapshot of the state, so that the lock isn't held while user code runs,
+// e.g. the hooks may call compose.ProcessState. Only the fields changed by the hooks are applied to the state after,
+// as the other tools running concurrently may have changed the state meanwhile.
+func runOnStateSnapshot(ctx context.Context, hooks func(st *State) error) error {
+	var orig State
+	err := compose.ProcessState(ctx, func(_ context.Context, st *State) error {
+		orig = cloneState(st)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	snapshot := cloneState(&orig)
+	if err = hooks(&snapshot); err != nil {
+		return err
+	}
+
+	return compose.ProcessState(ctx, func(_ context.Context, st *State) error {
+		mergeState(st, &orig, &snapshot)
+		return nil
+	})
+}
+
+func cloneState(st *State) State {
+	c := *st
+	c.Messages = slices.Clone(st.Messages)
+	c.ToolGenActions = maps.Clone(st.ToolGenActions)
+	c.AgentToolInterruptData = maps.Clone(st.AgentToolInterruptData)
+	c.ToolCallHistory = slices.Clone(st.ToolCallHistory)
+	return c
+}
+
+// mergeState applies the fields of snapshot which are different from orig to st.
+func mergeState(st, orig, snapshot *State) {
+	if !slices.Equal(orig.Messages, snapshot.Messages) {
+		st.Messages = snapshot.Messages
+	}
+	if orig.ReturnDirectlyToolCallID != snapshot.ReturnDirectlyToolCallID {
+		st.ReturnDirectlyToolCallID = snapshot.ReturnDirectlyToolCallID
+	}
+	mergeMap(st.ToolGenActions, orig.ToolGenActions, snapshot.ToolGenActions)
+	if orig.AgentName != snapshot.AgentName {
+		st.AgentName = snapshot.AgentName
+	}
+	mergeMap(st.AgentToolInterruptData, orig.AgentToolInterruptData, snapshot.AgentToolInterruptData)
+	if orig.OutputRetries != snapshot.OutputRetries {
+		st.OutputRetries = snapshot.OutputRetries
+	}
+	if orig.OutputCorrection != snapshot.OutputCorrection {
+		st.OutputCorrection = snapshot.OutputCorrection
+	}
+	if orig.FinalResponse != snapshot.FinalResponse {
+		st.FinalResponse = snapshot.FinalResponse
+	}
+	if !slices.Equal(orig.ToolCallHistory, snapshot.ToolCallHistory) {
+		st.ToolCallHistory = snapshot.ToolCallHistory
+	}
+	if orig.ToolCallLoop != snapshot.ToolCallLoop {
+		st.ToolCallLoop = snapshot.ToolCallLoop
+	}
+	if orig.Steps != snapshot.Steps {
+		st.Steps = snapshot.Steps
+	}
+	if orig.MaxStepExhausted != snapshot.MaxStepExhausted {
+		st.MaxStepExhausted = snapshot.MaxStepExhausted
+	}
+}
+
+// mergeMap applies the entries added, changed or deleted in snapshot compared to orig to dst.
+func mergeMap[K comparable, V comparable](dst, orig, snapshot map[K]V) {
+	if dst == nil {
+		return
+	}
+	for k := range orig {
+		if _, ok := snapshot[k]; !ok {
+			delete(dst, k)
+		}
+	}
+	for k, v := range snapshot {
+		if ov, ok := orig[k]; !ok || ov != v {
+			dst[k] = v
+		}
+	}
+}
+
+// popFinalResponse reports whether AfterModel has ended the agent with the last response.
+func popFinalResponse(ctx context.Context) (bool, error) {
+	var final bool
+	err := compose.ProcessState(ctx, func(_ context.Context, st *State) error {
+		final, st.FinalResponse = st.FinalResponse, false
+		return nil
+	})
+	return final, err
+}
+
+type middlewareChatModel struct {
+	inner model.ToolCallingChatModel
+	ms    middlewares
+}
+
+func (m *middlewareChatModel) WithTools(tools []*schema.ToolInfo) (model.ToolCallingChatModel, error) {
+	inner, err := m.inner.WithTools(tools)
+	if err != nil {
+		return nil, err
+	}
+	return &middlewareChatModel{inner: inner, ms: m.ms}, nil
+}
+
+func (m *middlewareChatModel) GetType() string {
+	typ, _ := components.GetType(m.inner)
+	return typ
+}
+
+func (m *middlewareChatModel) IsCallbacksEnabled() bool {
+	return components.IsCallbacksEnabled(m.inner)
+}
+
+func (m *middlewareChatModel) Generate(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+	call := &ModelCall{Messages: input}
+	if err := m.ms.beforeModel(ctx, call); err != nil {
+		return nil, err
+	}
+
+	if call.Response != nil {
+		m.onSkipped(ctx, call)
+	} else {
+		out, err := m.inner.Generate(ctx, call.Messages, opts...)
+		if err != nil {
+			return nil, err
+		}
+		call.Response = out
+	}
+
+	if err := m.ms.afterModel(ctx, call); err != nil {
+		return nil, err
+	}
+
+	return call.Response, nil
+}
+
+func (m *middlewareChatModel) Stream(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	call := &ModelCall{Messages: input}
+	if err := m.ms.beforeModel(ctx, call); err != nil {
+		return nil, err
+	}
+
+	if call.Response != nil {
+		m.onSkipped(ctx, call)
+	} else {
+		sr, err := m.inner.Stream(ctx, call.Messages, opts...)
+		if err != nil {
+			return nil, err
+		}
+		if !m.ms.hasAfterModel() {
+			return sr, nil
+		}
+
+		call.Response, err = schema.ConcatMessageStream(sr)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := m.ms.afterModel(ctx, call); err != nil {
+		return nil, err
+	}
+
+	return schema.StreamReaderFromArray([]*schema.Message{call.Response}), nil
+}
+
+// onSkipped triggers the callbacks for the response given by BeforeModel,
+// when the model is supposed to trigger them by itself.
+func (m *middlewareChatModel) onSkipped(ctx context.Context, call *ModelCall) {
+	if !m.IsCallbacksEnabled() {
+		return
+	}
+	ctx = callbacks.EnsureRunInfo(ctx, m.GetType(), components.ComponentOfChatModel)
+	ctx = callbacks.OnStart(ctx, &model.CallbackInput{Messages: call.Messages})
+	callbacks.OnEnd(ctx, &model.CallbackOutput{Message: call.Response})
+}
+
+// wrapToolsWithMiddlewares wraps the tools except the internal ones of the agent, whose infos are in the same order as tools.
+func wrapToolsWithMiddlewares(tools []tool.BaseTool, infos []*schema.ToolInfo, internalTools map[string]bool,
+	ms middlewares) []tool.BaseTool {
+
+	ret := make([]tool.BaseTool, 0, len(tools))
+	for i, t := range tools {
+		if internalTools[infos[i].Name] {
+			ret = append(ret, t)
+			continue
+		}
+
+		mt := &middlewareTool{inner: t, ms: ms}
+		it, invokable := t.(tool.InvokableTool)
+		st, streamable := t.(tool.StreamableTool)
+		switch {
+		case invokable && streamable:
+			ret = append(ret, &middlewareFullTool{middlewareInvokableTool{mt, it}, middlewareStreamableTool{mt, st}})
+		case invokable:
+			ret = append(ret, &middlewareInvokableTool{mt, it})
+		case streamable:
+			ret = append(ret, &middlewareStreamableTool{mt, st})
+		default:
+			// left to the tools node to report
+			ret = append(ret, t)
+		}
+	}
+	return ret
+}
+
+type middlewareTool struct {
+	inner tool.BaseTool
+	ms    middlewares
+}
+
+func (t *middlewareTool) Info(ctx context.Context) (*schema.ToolInfo, error) {
+	return t.inner.Info(ctx)
+}
+
+func (t *middlewareTool) GetType() string {
+	typ, _ := components.GetType(t.inner)
+	return typ
+}
+
+func (t *middlewareTool) IsCallbacksEnabled() bool {
+	return components.IsCallbacksEnabled(t.inner)
+}
+
+func (t *middlewareTool) before(ctx context.Context, arguments string) (*ToolInvocation, error) {
+	info, err := t.inner.Info(ctx)
+	if err != nil {
+		return nil, err
+	}
+	call := &ToolInvocation{Name: info.Name, ToolCallID: compose.GetToolCallID(ctx), Arguments: arguments}
+	if err = t.ms.beforeTool(ctx, call); err != nil {
+		return nil, err
+	}
+
+	if call.Result != nil && t.IsCallbacksEnabled() {
+		// the result given by BeforeTool, trigger the callbacks as the tool is supposed to
+		ctx = callbacks.EnsureRunInfo(ctx, t.GetType(), components.ComponentOfTool)
+		ctx = callbacks.OnStart(ctx, &tool.CallbackInput{ArgumentsInJSON: call.Arguments})
+		callbacks.OnEnd(ctx, &tool.CallbackOutput{Response: *call.Result})
+	}
+
+	return call, nil
+}
+
+type middlewareInvokableTool struct {
+	*middlewareTool
+	it tool.InvokableTool
+}
+
+func (t *middlewareInvokableTool) InvokableRun(ctx context.Context, argumentsInJSON string, opts ...tool.Option) (string, error) {
+	call, err := t.before(ctx, argumentsInJSON)
+	if err != nil {
+		return "", err
+	}
+
+	if call.Result == nil {
+		result, err := t.it.InvokableRun(ctx, call.Arguments, opts...)
+		if err != nil {
+			return "", err
+		}
+		call.Result = &result
+	}
+
+	if err = t.ms.afterTool(ctx, call); err != nil {
+		return "", err
+	}
+
+	return *call.Result, nil
+}
+
+type middlewareStreamableTool struct {
+	*middlewareTool
+	st tool.StreamableTool
+}
+
+func (t *middlewareStreamableTool) StreamableRun(ctx context.Context, argumentsInJSON string, opts ...tool.Option) (*schema.StreamReader[string], error) {
+	call, err := t.before(ctx, argumentsInJSON)
+	if err != nil {
+		return nil, err
+	}
+
+	if call.Result == nil {
+		sr, err := t.st.StreamableRun(ctx, call.Arguments, opts...)
+		if err != nil {
+			return nil, err
+		}
+		if !t.ms.hasAfterTool() {
+			return sr, nil
+		}
+
+		result, err := concatStringStream(sr)
+		if err != nil {
+			return nil, err
+		}
+		call.Result = &result
+	}
+
+	if err = t.ms.afterTool(ctx, call); err != nil {
+		return nil, err
+	}
+
+	return schema.StreamReaderFromArray([]string{*call.Result}), nil
+}
+
+type middlewareFullTool struct {
+	middlewareInvokableTool
+	middlewareStreamableTool
+}
+
+func (t *middlewareFullTool) Info(ctx context.Context) (*schema.ToolInfo, error) {
+	return t.middlewareInvokableTool.Info(ctx)
+}
+
+func (t *middlewareFullTool) GetType() string {
+	return t.middlewareInvokableTool.GetType()
+}
+
+func (t *middlewareFullTool) IsCallbacksEnabled() bool {
+	return t.middlewareInvokableTool.IsCallbacksEnabled()
+}
+
+func concatStringStream(sr *schema.StreamReader[string]) (string, error) {
+	defer sr.Close()
+	var sb strings.Builder
+	for {
+		chunk, err := sr.Recv()
+		if err != nil {
+			if err == io.EOF {
+				return sb.String(), nil
+			}
+			return "", err
+		}
+		sb.WriteString(chunk)
+	}
+}
@@ -0,0 +1,251 @@
+/*
+ * Copyright 2025 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package adk
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/compose"
+	mockModel "github.com/cloudwego/eino/internal/mock/components/model"
+	"github.com/cloudwego/eino/schema"
+)
+
+func toolCallMessage(id, name, args string) Message {
+	return schema.AssistantMessage("", []schema.ToolCall{{ID: id, Function: schema.FunctionCall{Name: name, Arguments: args}}})
+}
+
+func collectEvents(t *testing.T, iter *AsyncIterator[*AgentEvent]) []*AgentEvent {
+	var events []*AgentEvent
+	for {
+		event, ok := iter.Next()
+		if !ok {
+			return events
+		}
+		assert.NoError(t, event.Err)
+		events = append(events, event)
+	}
+}
+
+func TestAgentMiddleware(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("rewrite", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		cm := mockModel.NewMockToolCallingChatModel(ctrl)
+		cm.EXPECT().WithTools(gomock.Any()).Return(cm, nil).AnyTimes()
+		gomock.InOrder(
+			cm.EXPECT().Generate(gomock.Any(), gomock.Any(), gomock.Any()).
+				DoAndReturn(func(_ context.Context, input []Message, _ ...model.Option) (Message, error) {
+					assert.Equal(t, "user is Alice", input[len(input)-1].Content)
+					return toolCallMessage("1", "test_tool", `{"name":"x"}`), nil
+				}),
+			cm.EXPECT().Generate(gomock.Any(), gomock.Any(), gomock.Any()).
+				DoAndReturn(func(_ context.Context, input []Message, _ ...model.Option) (Message, error) {
+					// the injected message is not kept in the history
+					assert.Equal(t, 4, len(input))
+					assert.Equal(t, `{"say": "hello Alice"} (checked)`, input[2].Content)
+					return schema.AssistantMessage("done", nil), nil
+				}),
+		)
+
+		var modelCalls int
+		a, err := NewChatModelAgent(ctx, &ChatModelAgentConfig{
+			Name:        "agent",
+			Description: "agent",
+			Model:       cm,
+			ToolsConfig: ToolsConfig{ToolsNodeConfig: compose.ToolsNodeConfig{Tools: []tool.BaseTool{&fakeToolForTest{tarCount: 1}}}},
+			InstructionProvider: func(ctx context.Context, _ *AgentInput) (string, error) {
+				SetSessionValue(ctx, "user", "Alice")
+				return "", nil
+			},
+			Middlewares: []AgentMiddleware{
+				{
+					BeforeModel: func(ctx context.Context, state *State, call *ModelCall) error {
+						modelCalls++
+						user, _ := GetSessionValue(ctx, "user")
+						call.Messages = append(call.Messages, schema.SystemMessage("user is "+user.(string)))
+						return nil
+					},
+					AfterTool: func(ctx context.Context, state *State, call *ToolInvocation) error {
+						result := *call.Result + " (checked)"
+						call.Result = &result
+						return nil
+					},
+				},
+				{
+					BeforeModel: func(ctx context.Context, state *State, call *ModelCall) error {
+						// runs after the first middleware
+						assert.Equal(t, "user is Alice", call.Messages[len(call.Messages)-1].Content)
+						call.Messages = call.Messages[:len(call.Messages)-1]
+						call.Messages = append(call.Messages, schema.UserMessage("user is Alice"))
+						return nil
+					},
+					AfterModel: func(ctx context.Context, state *State, call *ModelCall) error {
+						if len(call.Response.ToolCalls) == 0 {
+							call.Response = schema.AssistantMessage(call.Response.Content+"!", nil)
+						}
+						return nil
+					},
+					BeforeTool: func(ctx context.Context, state *State, call *ToolInvocation) error {
+						assert.Equal(t, "test_tool", call.Name)
+						assert.Equal(t, "1", call.ToolCallID)
+						call.Arguments = `{"name":"Alice"}`
+						return nil
+					},
+				},
+			},
+		})
+		assert.NoError(t, err)
+
+		runner := NewRunner(ctx, RunnerConfig{Agent: a})
+		iter := runner.Query(ctx, "hi")
+		events := collectEvents(t, iter)
+		assert.Equal(t, 3, len(events))
+		assert.Equal(t, `{"say": "hello Alice"} (checked)`, events[1].Output.MessageOutput.Message.Content)
+		assert.Equal(t, "done!", events[2].Output.MessageOutput.Message.Content)
+		assert.Equal(t, 2, modelCalls)
+	})
+
+	t.Run("block tool and final response", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		cm := mockModel.NewMockToolCallingChatModel(ctrl)
+		cm.EXPECT().WithTools(gomock.Any()).Return(cm, nil).AnyTimes()
+		cm.EXPECT().Generate(gomock.Any(), gomock.Any(), gomock.Any()).
+			Return(toolCallMessage("1", "test_tool", `{"name":"x"}`), nil).Times(2)
+
+		ft := &fakeToolForTest{tarCount: 1}
+		a, err := NewChatModelAgent(ctx, &ChatModelAgentConfig{
+			Name:        "agent",
+			Description: "agent",
+			Model:       cm,
+			ToolsConfig: ToolsConfig{ToolsNodeConfig: compose.ToolsNodeConfig{Tools: []tool.BaseTool{ft}}},
+			Middlewares: []AgentMiddleware{
+				{
+					BeforeTool: func(ctx context.Context, state *State, call *ToolInvocation) error {
+						blocked := "tool is blocked"
+						call.Result = &blocked
+						return nil
+					},
+					AfterModel: func(ctx context.Context, state *State, call *ModelCall) error {
+						// end the agent when the model insists on the blocked tool
+						call.Final = len(state.Messages) > 2
+						return nil
+					},
+				},
+			},
+		})
+		assert.NoError(t, err)
+
+		events := collectEvents(t, a.Run(ctx, &AgentInput{Messages: []Message{schema.UserMessage("hi")}}))
+		assert.Equal(t, 3, len(events))
+		assert.Equal(t, "tool is blocked", events[1].Output.MessageOutput.Message.Content)
+		assert.Equal(t, 1, len(events[2].Output.MessageOutput.Message.ToolCalls))
+		assert.Equal(t, 0, ft.curCount)
+	})
+
+	t.Run("stream", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		cm := mockModel.NewMockToolCallingChatModel(ctrl)
+		cm.EXPECT().WithTools(gomock.Any()).Return(cm, nil).AnyTimes()
+		gomock.InOrder(
+			cm.EXPECT().Stream(gomock.Any(), gomock.Any(), gomock.Any()).
+				Return(schema.StreamReaderFromArray([]Message{toolCallMessage("1", "test_stream_tool", `{"name":"x"}`)}), nil),
+			cm.EXPECT().Stream(gomock.Any(), gomock.Any(), gomock.Any()).
+				Return(schema.StreamReaderFromArray([]Message{
+					schema.AssistantMessage("do", nil),
+					schema.AssistantMessage("ne", nil),
+				}), nil),
+		)
+
+		a, err := NewChatModelAgent(ctx, &ChatModelAgentConfig{
+			Name:        "agent",
+			Description: "agent",
+			Model:       cm,
+			ToolsConfig: ToolsConfig{ToolsNodeConfig: compose.ToolsNodeConfig{Tools: []tool.BaseTool{&fakeStreamToolForTest{tarCount: 1}}}},
+			Middlewares: []AgentMiddleware{
+				{
+					AfterModel: func(ctx context.Context, state *State, call *ModelCall) error {
+						if call.Response.Content == "done" {
+							call.Response = schema.AssistantMessage("DONE", nil)
+						}
+						return nil
+					},
+					AfterTool: func(ctx context.Context, state *State, call *ToolInvocation) error {
+						result := "tool: " + *call.Result
+						call.Result = &result
+						return nil
+					},
+				},
+			},
+		})
+		assert.NoError(t, err)
+
+		events := collectEvents(t, a.Run(ctx, &AgentInput{Messages: []Message{schema.UserMessage("hi")}, EnableStreaming: true}))
+		assert.Equal(t, 3, len(events))
+		msg, err := events[1].Output.MessageOutput.GetMessage()
+		assert.NoError(t, err)
+		assert.Regexp(t, "^tool: ", msg.Content)
+		msg, err = events[2].Output.MessageOutput.GetMessage()
+		assert.NoError(t, err)
+		assert.Equal(t, "DONE", msg.Content)
+	})
+
+	t.Run("state snapshot and internal tools", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		cm := mockModel.NewMockToolCallingChatModel(ctrl)
+		cm.EXPECT().WithTools(gomock.Any()).Return(cm, nil).AnyTimes()
+		cm.EXPECT().Generate(gomock.Any(), gomock.Any(), gomock.Any()).
+			Return(toolCallMessage("1", ToolInfoExit.Name, `{"final_result":"bye"}`), nil)
+
+		var toolCalls []string
+		a, err := NewChatModelAgent(ctx, &ChatModelAgentConfig{
+			Name:        "agent",
+			Description: "agent",
+			Model:       cm,
+			ToolsConfig: ToolsConfig{ToolsNodeConfig: compose.ToolsNodeConfig{Tools: []tool.BaseTool{&fakeToolForTest{}}}},
+			Exit:        ExitTool{},
+			Middlewares: []AgentMiddleware{
+				{
+					BeforeModel: func(ctx context.Context, state *State, call *ModelCall) error {
+						// the state isn't locked while the hooks run
+						return compose.ProcessState(ctx, func(_ context.Context, st *State) error {
+							assert.Equal(t, len(st.Messages), len(state.Messages))
+							return nil
+						})
+					},
+					BeforeTool: func(ctx context.Context, state *State, call *ToolInvocation) error {
+						toolCalls = append(toolCalls, call.Name)
+						return nil
+					},
+				},
+			},
+		})
+		assert.NoError(t, err)
+
+		events := collectEvents(t, a.Run(ctx, &AgentInput{Messages: []Message{schema.UserMessage("hi")}}))
+		assert.Equal(t, 2, len(events))
+		assert.True(t, events[1].Action.Exit)
+		// the exit tool of the agent isn't wrapped by the tool hooks
+		assert.Empty(t, toolCalls)
+	})
+}
@@ -0,0 +1,348 @@
+/*
+ * Copyright 2025 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package adk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/cloudwego/eino/callbacks"
+	"github.com/cloudwego/eino/components"
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+)
+
+// ModelErrorClass classifies the errors returned by the chat model, to choose the ModelFallbackPolicy.
+type ModelErrorClass string
+
+const (
+	ModelErrorUnknown       ModelErrorClass = "unknown"
+	ModelErrorRateLimit     ModelErrorClass = "rate_limit"
+	ModelErrorTimeout       ModelErrorClass = "timeout"
+	ModelErrorContentFilter ModelErrorClass = "content_filter"
+)
+
+// ModelFallbackPolicy decides what to do when a model fails with a class of error.
+type ModelFallbackPolicy struct {
+	// MaxRetries is the number of retries on the same model before falling back, optional, defaults to 0.
+	MaxRetries int
+	// Backoff returns the wait before the given retry (starts from 1), optional, defaults to no wait.
+	Backoff func(ctx context.Context, retry int) time.Duration
+	// NoFallback makes the agent fail when the retries are used up, instead of trying the next model.
+	NoFallback bool
+}
+
+// FallbackModel is a model in the fallback chain.
+type FallbackModel struct {
+	// Name identifies the model in ModelAttempt, optional, defaults to the type of the model.
+	Name  string
+	Model model.ToolCallingChatModel
+}
+
+// ModelAttempt records a call to a model in the fallback chain.
+type ModelAttempt struct {
+	// Index of the model, 0 is ChatModelAgentConfig.Model, then ModelFallbackConfig.Models in order.
+	Index int
+	Name  string
+	// Retry is the number of retries on this model, 0 for the first call.
+	Retry int
+	// Err is nil if the model answered.
+	Err   error
+	Class ModelErrorClass
+}
+
+// ModelFallbackConfig makes ChatModelAgent fall back to other models when the model fails.
+// Tools are bound to every model with WithTools.
+// For streaming, only the errors before the first chunk can be recovered.
+type ModelFallbackConfig struct {
+	// Models are tried in order after ChatModelAgentConfig.Model fails.
+	Models []*FallbackModel
+
+	// Classify classifies the model error, optional, defaults to DefaultModelErrorClassifier.
+	Classify func(ctx context.Context, err error) ModelErrorClass
+
+	// Policies by error class, optional.
+	// Errors of the classes not in Policies fall back to the next model immediately.
+	Policies map[ModelErrorClass]*ModelFallbackPolicy
+
+	// OnAttempt is called after each call to a model, optional.
+	// The last attempt without Err tells which model actually answered.
+	OnAttempt func(ctx context.Context, attempt *ModelAttempt)
+}
+
+// statusCode429Regexp matches the HTTP status code 429 in error messages, e.g. "status code: 429" or "HTTP 429",
+// rather than any 429 in the message, such as in a request ID.
+var statusCode429Regexp = regexp.MustCompile(`\b(?:status(?:[ _]?code)?|code|http(?:/[\d.]+)?)["']?\s*[:=]?\s*429\b`)
+
+// DefaultModelErrorClassifier classifies the error by context and net errors, by the HTTP status code of the errors
+// with the method StatusCode() int, and by the common words and status codes in error messages.
+func DefaultModelErrorClassifier(_ context.Context, err error) ModelErrorClass {
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return ModelErrorTimeout
+	}
+
+	var statusErr interface{ StatusCode() int }
+	if errors.As(err, &statusErr) && statusErr.StatusCode() == http.StatusTooManyRequests {
+		return ModelErrorRateLimit
+	}
+
+	msg := strings.ToLower(err.Error())
+	switch {
+	case strings.Contains(msg, "rate limit"), strings.Contains(msg, "rate_limit"),
+		strings.Contains(msg, "too many requests"), statusCode429Regexp.MatchString(msg):
+		return ModelErrorRateLimit
+	case strings.Contains(msg, "content filter"), strings.Contains(msg, "content_filter"),
+		strings.Contains(msg, "content policy"), strings.Contains(msg, "content_policy"):
+		return ModelErrorContentFilter
+	case strings.Contains(msg, "timeout"), strings.Contains(msg, "timed out"):
+		return ModelErrorTimeout
+	}
+
+	return ModelErrorUnknown
+}
+
+type fallbackChatModel struct {
+	models []*FallbackModel
+	conf   *ModelFallbackConfig
+}
+
+func newFallbackChatModel(primary model.ToolCallingChatModel, conf *ModelFallbackConfig) (*fallbackChatModel, error) {
+	models := make([]*FallbackModel, 0, len(conf.Models)+1)
+	models = append(models, &FallbackModel{Model: primary})
+	for i, m := range conf.Models {
+		if m == nil || m.Model == nil {
+			return nil, fmt.Errorf("fallback model %d is nil", i)
+		}
+		models = append(models, m)
+	}
+
+	for i, m := range models {
+		if m.Name != "" {
+			continue
+		}
+		name, ok := components.GetType(m.Model)
+		if !ok {
+			name = fmt.Sprintf("model_%d", i)
+		}
+		models[i] = &FallbackModel{Name: name, Model: m.Model}
+	}
+
+	return &fallbackChatModel{models: models, conf: conf}, nil
+}
+
+func (f *fallbackChatModel) GetType() string {
+	return "Fallback"
+}
+
+// IsCallbacksEnabled is true because callbacks are triggered for each model attempt rather than the whole chain.
+func (f *fallbackChatModel) IsCallbacksEnabled() bool {
+	return true
+}
+
+func (f *fallbackChatModel) WithTools(tools []*schema.ToolInfo) (model.ToolCallingChatModel, error) {
+	models := make([]*FallbackModel, len(f.models))
+	for i, m := range f.models {
+		bound, err := m.Model.WithTools(tools)
+		if err != nil {
+			return nil, fmt.Errorf("failed to bind tools to model '%s': %w", m.Name, err)
+		}
+		models[i] = &FallbackModel{Name: m.Name, Model: bound}
+	}
+
+	return &fallbackChatModel{models: models, conf: f.conf}, nil
+}
+
+func (f *fallbackChatModel) Generate(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+	var out *schema.Message
+	err := f.try(ctx, func(m model.BaseChatModel) error {
+		var err error
+		out, err = generateWithCallbacks(ctx, m, input, opts...)
+		return err
+	})
+	return out, err
+}
+
+func (f *fallbackChatModel) Stream(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	var out *schema.StreamReader[*schema.Message]
+	err := f.try(ctx, func(m model.BaseChatModel) error {
+		var err error
+		out, err = streamWithCallbacks(ctx, m, input, opts...)
+		return err
+	})
+	return out, err
+}
+
+func (f *fallbackChatModel) try(ctx context.Context, call func(m model.BaseChatModel) error) error {
+	classify := f.conf.Classify
+	if classify == nil {
+		classify = DefaultModelErrorClassifier
+	}
+
+	var errs []error
+	for i, m := range f.models {
+		for retry := 0; ; retry++ {
+			err := call(m.Model)
+			if err == nil {
+				f.onAttempt(ctx, &ModelAttempt{Index: i, Name: m.Name, Retry: retry})
+				return nil
+			}
+
+			class := classify(ctx, err)
+			f.onAttempt(ctx, &ModelAttempt{Index: i, Name: m.Name, Retry: retry, Err: err, Class: class})
+			errs = append(errs, fmt.Errorf("model '%s': %w", m.Name, err))
+
+			if ctx.Err() != nil {
+				return errors.Join(errs...)
+			}
+
+			policy := f.conf.Policies[class]
+			if policy == nil {
+				break
+			}
+			if retry < policy.MaxRetries {
+				if policy.Backoff != nil {
+					if err = sleep(ctx, policy.Backoff(ctx, retry+1)); err != nil {
+						return errors.Join(append(errs, err)...)
+					}
+				}
+				continue
+			}
+			if policy.NoFallback {
+				return errors.Join(errs...)
+			}
+			break
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (f *fallbackChatModel) onAttempt(ctx context.Context, attempt *ModelAttempt) {
+	if f.conf.OnAttempt != nil {
+		f.conf.OnAttempt(ctx, attempt)
+	}
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+func generateWithCallbacks(ctx context.Context, m model.BaseChatModel, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+	if components.IsCallbacksEnabled(m) {
+		return m.Generate(ctx, input, opts...)
+	}
+
+	ctx = ensureChatModelRunInfo(ctx, m)
+	ctx = callbacks.OnStart(ctx, &model.CallbackInput{Messages: input})
+	out, err := m.Generate(ctx, input, opts...)
+	if err != nil {
+		callbacks.OnError(ctx, err)
+		return nil, err
+	}
+	callbacks.OnEnd(ctx, &model.CallbackOutput{Message: out})
+	return out, nil
+}
+
+// streamWithCallbacks receives the first chunk before returning, so that the errors before the first chunk can fall back.
+func streamWithCallbacks(ctx context.Context, m model.BaseChatModel, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	enabled := components.IsCallbacksEnabled(m)
+	if !enabled {
+		ctx = ensureChatModelRunInfo(ctx, m)
+		ctx = callbacks.OnStart(ctx, &model.CallbackInput{Messages: input})
+	}
+
+	sr, err := m.Stream(ctx, input, opts...)
+	if err == nil {
+		sr, err = peekStream(sr)
+	}
+	if err != nil {
+		if !enabled {
+			callbacks.OnError(ctx, err)
+		}
+		return nil, err
+	}
+
+	if enabled {
+		return sr, nil
+	}
+
+	_, cbSR := callbacks.OnEndWithStreamOutput(ctx, schema.StreamReaderWithConvert(sr, func(m *schema.Message) (*model.CallbackOutput, error) {
+		return &model.CallbackOutput{Message: m}, nil
+	}))
+	return schema.StreamReaderWithConvert(cbSR, func(o *model.CallbackOutput) (*schema.Message, error) {
+		return o.Message, nil
+	}), nil
+}
+
+func ensureChatModelRunInfo(ctx context.Context, m model.BaseChatModel) context.Context {
+	typ, _ := components.GetType(m)
+	return callbacks.EnsureRunInfo(ctx, typ, components.ComponentOfChatModel)
+}
+
+// peekStream receives the first chunk of sr, and returns a stream with the same content if there is no error.
+func peekStream(sr *schema.StreamReader[*schema.Message]) (*schema.StreamReader[*schema.Message], error) {
+	first, err := sr.Recv()
+	if err == io.EOF {
+		sr.Close()
+		return schema.StreamReaderFromArray[*schema.Message](nil), nil
+	}
+	if err != nil {
+		sr.Close()
+		return nil, err
+	}
+
+	r, w := schema.Pipe[*schema.Message](1)
+	go func() {
+		defer func() {
+			sr.Close()
+			w.Close()
+		}()
+
+		if closed := w.Send(first, nil); closed {
+			return
+		}
+		for {
+			chunk, err_ := sr.Recv()
+			if err_ == io.EOF {
+				return
+			}
+			if closed := w.Send(chunk, err_); closed || err_ != nil {
+				return
+			}
+		}
+	}()
+
+	return r, nil
+}
@@ -0,0 +1,190 @@
+/*
+ * Copyright 2025 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package adk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/compose"
+	mockModel "github.com/cloudwego/eino/internal/mock/components/model"
+	"github.com/cloudwego/eino/schema"
+)
+
+func TestModelFallback(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("generate", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		primary := mockModel.NewMockToolCallingChatModel(ctrl)
+		backup := mockModel.NewMockToolCallingChatModel(ctrl)
+		primary.EXPECT().WithTools(gomock.Any()).Return(primary, nil).Times(1)
+		backup.EXPECT().WithTools(gomock.Any()).Return(backup, nil).Times(1)
+
+		primary.EXPECT().Generate(gomock.Any(), gomock.Any(), gomock.Any()).
+			Return(nil, errors.New("429 too many requests")).Times(4)
+		gomock.InOrder(
+			backup.EXPECT().Generate(gomock.Any(), gomock.Any(), gomock.Any()).
+				Return(schema.AssistantMessage("", []schema.ToolCall{{ID: "1", Function: schema.FunctionCall{Name: "test_tool", Arguments: `{"name":"x"}`}}}), nil),
+			backup.EXPECT().Generate(gomock.Any(), gomock.Any(), gomock.Any()).
+				Return(schema.AssistantMessage("done", nil), nil),
+		)
+
+		var attempts []ModelAttempt
+		a, err := NewChatModelAgent(ctx, &ChatModelAgentConfig{
+			Name:        "agent",
+			Description: "agent",
+			Model:       primary,
+			ToolsConfig: ToolsConfig{ToolsNodeConfig: compose.ToolsNodeConfig{Tools: []tool.BaseTool{&fakeToolForTest{tarCount: 1}}}},
+			ModelFallback: &ModelFallbackConfig{
+				Models:   []*FallbackModel{{Name: "backup", Model: backup}},
+				Policies: map[ModelErrorClass]*ModelFallbackPolicy{ModelErrorRateLimit: {MaxRetries: 1}},
+				OnAttempt: func(_ context.Context, attempt *ModelAttempt) {
+					attempts = append(attempts, *attempt)
+				},
+			},
+		})
+		assert.NoError(t, err)
+
+		iter := a.Run(ctx, &AgentInput{Messages: []Message{schema.UserMessage("hi")}})
+		var events []*AgentEvent
+		for {
+			event, ok := iter.Next()
+			if !ok {
+				break
+			}
+			assert.NoError(t, event.Err)
+			events = append(events, event)
+		}
+		assert.Equal(t, 3, len(events))
+		assert.Equal(t, "done", events[2].Output.MessageOutput.Message.Content)
+
+		// every model call starts from the primary model
+		assert.Equal(t, 6, len(attempts))
+		assert.Equal(t, ModelErrorRateLimit, attempts[0].Class)
+		assert.Equal(t, 1, attempts[1].Retry)
+		assert.Equal(t, ModelAttempt{Index: 1, Name: "backup"}, attempts[2])
+		assert.Equal(t, ModelAttempt{Index: 1, Name: "backup"}, attempts[5])
+	})
+
+	t.Run("stream error before first chunk", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		primary := mockModel.NewMockToolCallingChatModel(ctrl)
+		backup := mockModel.NewMockToolCallingChatModel(ctrl)
+
+		r, w := schema.Pipe[*schema.Message](1)
+		w.Send(nil, errors.New("content_filter triggered"))
+		w.Close()
+		primary.EXPECT().Stream(gomock.Any(), gomock.Any(), gomock.Any()).Return(r, nil).Times(1)
+		backup.EXPECT().Stream(gomock.Any(), gomock.Any(), gomock.Any()).
+			Return(schema.StreamReaderFromArray([]*schema.Message{
+				schema.AssistantMessage("hello", nil),
+				schema.AssistantMessage(" world", nil),
+			}), nil).Times(1)
+
+		var answered string
+		a, err := NewChatModelAgent(ctx, &ChatModelAgentConfig{
+			Name:        "agent",
+			Description: "agent",
+			Model:       primary,
+			ModelFallback: &ModelFallbackConfig{
+				Models: []*FallbackModel{{Name: "backup", Model: backup}},
+				OnAttempt: func(_ context.Context, attempt *ModelAttempt) {
+					if attempt.Err == nil {
+						answered = attempt.Name
+					} else {
+						assert.Equal(t, ModelErrorContentFilter, attempt.Class)
+					}
+				},
+			},
+		})
+		assert.NoError(t, err)
+
+		iter := a.Run(ctx, &AgentInput{Messages: []Message{schema.UserMessage("hi")}, EnableStreaming: true})
+		event, ok := iter.Next()
+		assert.True(t, ok)
+		assert.NoError(t, event.Err)
+		msg, err := event.Output.MessageOutput.GetMessage()
+		assert.NoError(t, err)
+		assert.Equal(t, "hello world", msg.Content)
+		_, ok = iter.Next()
+		assert.False(t, ok)
+		assert.Equal(t, "backup", answered)
+	})
+
+	t.Run("no fallback", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		primary := mockModel.NewMockToolCallingChatModel(ctrl)
+		backup := mockModel.NewMockToolCallingChatModel(ctrl)
+		primary.EXPECT().Generate(gomock.Any(), gomock.Any(), gomock.Any()).
+			Return(nil, errors.New("rejected by content policy")).Times(1)
+
+		a, err := NewChatModelAgent(ctx, &ChatModelAgentConfig{
+			Name:        "agent",
+			Description: "agent",
+			Model:       primary,
+			ModelFallback: &ModelFallbackConfig{
+				Models:   []*FallbackModel{{Model: backup}},
+				Policies: map[ModelErrorClass]*ModelFallbackPolicy{ModelErrorContentFilter: {NoFallback: true}},
+			},
+		})
+		assert.NoError(t, err)
+
+		iter := a.Run(ctx, &AgentInput{Messages: []Message{schema.UserMessage("hi")}})
+		event, ok := iter.Next()
+		assert.True(t, ok)
+		assert.ErrorContains(t, event.Err, "content policy")
+	})
+}
+
+type statusCodeError struct {
+	code int
+}
+
+func (e *statusCodeError) Error() string {
+	return "request failed"
+}
+
+func (e *statusCodeError) StatusCode() int {
+	return e.code
+}
+
+func TestDefaultModelErrorClassifier(t *testing.T) {
+	ctx := context.Background()
+	for msg, class := range map[string]ModelErrorClass{
+		"error, status code: 429, message: slow down": ModelErrorRateLimit,
+		"HTTP 429":                         ModelErrorRateLimit,
+		`{"code":429}`:                     ModelErrorRateLimit,
+		"Rate limit reached":               ModelErrorRateLimit,
+		"bad request, request id: 4291-af": ModelErrorUnknown,
+		"max tokens 8429 exceeded":         ModelErrorUnknown,
+		"content_filter triggered":         ModelErrorContentFilter,
+		"read timed out":                   ModelErrorTimeout,
+	} {
+		assert.Equal(t, class, DefaultModelErrorClassifier(ctx, errors.New(msg)), msg)
+	}
+
+	err := fmt.Errorf("wrapped: %w", &statusCodeError{code: 429})
+	assert.Equal(t, ModelErrorRateLimit, DefaultModelErrorClassifier(ctx, err))
+	assert.Equal(t, ModelErrorUnknown, DefaultModelErrorClassifier(ctx, &statusCodeError{code: 500}))
+}
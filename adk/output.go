@@ -0,0 +1,326 @@
+/*
+ * Copyright 2025 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package adk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/bytedance/sonic"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/components/tool/utils"
+	"github.com/cloudwego/eino/compose"
+	"github.com/cloudwego/eino/internal"
+	"github.com/cloudwego/eino/schema"
+)
+
+type OutputMode string
+
+const (
+	// OutputModeTool binds a synthetic tool whose parameters are the output schema, the model gives the final answer by calling it.
+	OutputModeTool OutputMode = "tool"
+	// OutputModeJSON asks the model to give the final answer as a JSON object in the message content.
+	OutputModeJSON OutputMode = "json"
+)
+
+const (
+	FinalAnswerToolName = "final_answer"
+	FinalAnswerToolDesc = "Give the final answer. Call this tool once you have the final answer."
+
+	finalAnswerToolInstruction = `When you have the final answer, you MUST call the '%s' tool with the final answer as arguments, instead of answering directly.`
+	jsonOutputInstruction      = `When you have the final answer, respond with a JSON object ONLY, which conforms to the following JSON schema:
+%s`
+	finalAnswerCorrection = `The final answer is invalid: %s
+Please correct it and give the final answer again.`
+	finalAnswerToolMissing = `You didn't call the '%s' tool.`
+)
+
+// ErrFinalAnswerMissing is the error of ChatModelAgent with OutputConfig, when the agent ends without the final answer,
+// e.g. a tool that returns directly ends the agent, or AfterModel of a middleware ends the agent with the response.
+// It isn't reported if the agent exits or transfers to another agent.
+var ErrFinalAnswerMissing = errors.New("agent ended without the final answer")
+
+// OutputConfig describes the final answer of ChatModelAgent.
+// Use NewOutputConfig to generate the config from a go struct.
+type OutputConfig struct {
+	// Schema describes the final answer, required.
+	Schema *schema.ParamsOneOf
+
+	// Mode to make the model give the final answer, optional, defaults to OutputModeTool.
+	Mode OutputMode
+
+	// Parse converts the final answer JSON to the typed value, optional.
+	// Defaults to unmarshal to map[string]any.
+	Parse func(ctx context.Context, data string) (any, error)
+
+	// Validate validates the typed value returned by Parse, optional.
+	// The final answer is always validated against Schema before Parse.
+	Validate func(ctx context.Context, value any) error
+
+	// MaxRetries is the max number of corrective re-prompts when the final answer is invalid, optional.
+	// The agent fails if the final answer is still invalid after that.
+	// Defaults to 0, which means no retries, the agent fails on the first invalid final answer.
+	MaxRetries int
+
+	// ToolName of the synthetic tool in OutputModeTool, optional, defaults to FinalAnswerToolName.
+	ToolName string
+}
+
+// NewOutputConfig generates an OutputConfig from T, Schema is inferred from T using utils.GoStruct2ParamsOneOf,
+// and the final answer is parsed to T with schema.MessageJSONParser.
+// Other fields are copied from base, which is optional.
+func NewOutputConfig[T any](base *OutputConfig) (*OutputConfig, error) {
+	conf := &OutputConfig{}
+	if base != nil {
+		*conf = *base
+	}
+
+	if conf.Schema == nil {
+		sc, err := utils.GoStruct2ParamsOneOf[T]()
+		if err != nil {
+			return nil, err
+		}
+		conf.Schema = sc
+	}
+
+	if conf.Parse == nil {
+		parser := schema.NewMessageJSONParser[T](&schema.MessageJSONParseConfig{ParseFrom: schema.MessageParseFromContent})
+		conf.Parse = func(ctx context.Context, data string) (any, error) {
+			return parser.Parse(ctx, schema.AssistantMessage(data, nil))
+		}
+	}
+
+	return conf, nil
+}
+
+type outputHandler struct {
+	conf     *OutputConfig
+	toolName string
+}
+
+func newOutputHandler(conf *OutputConfig) (*outputHandler, error) {
+	if conf.Schema == nil {
+		return nil, errors.New("output 'Schema' is required")
+	}
+
+	h := &outputHandler{conf: conf, toolName: conf.ToolName}
+	if h.toolName == "" {
+		h.toolName = FinalAnswerToolName
+	}
+
+	switch conf.Mode {
+	case "":
+		h.conf.Mode = OutputModeTool
+	case OutputModeTool, OutputModeJSON:
+	default:
+		return nil, fmt.Errorf("unknown output mode: %s", conf.Mode)
+	}
+
+	return h, nil
+}
+
+func (h *outputHandler) instruction() (string, error) {
+	if h.conf.Mode == OutputModeTool {
+		return fmt.Sprintf(finalAnswerToolInstruction, h.toolName), nil
+	}
+
+	sc, err := h.conf.Schema.ToOpenAPIV3()
+	if err != nil {
+		return "", err
+	}
+	b, err := sonic.MarshalString(sc)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf(jsonOutputInstruction, b), nil
+}
+
+func (h *outputHandler) tool() tool.BaseTool {
+	if h.conf.Mode != OutputModeTool {
+		return nil
+	}
+
+	return &finalAnswerTool{h: h}
+}
+
+// validate validates the final answer against the schema, then parses and validates the typed value.
+func (h *outputHandler) validate(ctx context.Context, data string) (any, error) {
+	data = internal.TrimJSONCodeBlock(data)
+
+	var generic any
+	if err := sonic.UnmarshalString(data, &generic); err != nil {
+		return nil, fmt.Errorf("not a valid JSON: %w", err)
+	}
+
+	sc, err := h.conf.Schema.ToOpenAPIV3()
+	if err != nil {
+		return nil, err
+	}
+	if sc != nil {
+		if err = sc.VisitJSON(generic); err != nil {
+			return nil, err
+		}
+	}
+
+	value := generic
+	if h.conf.Parse != nil {
+		value, err = h.conf.Parse(ctx, data)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if h.conf.Validate != nil {
+		if err = h.conf.Validate(ctx, value); err != nil {
+			return nil, err
+		}
+	}
+
+	return value, nil
+}
+
+// retry records an invalid final answer in state, and returns the corrective message for the model.
+func (h *outputHandler) retry(ctx context.Context, cause error) (string, error) {
+	var exceeded bool
+	err := compose.ProcessState(ctx, func(_ context.Context, st *State) error {
+		st.OutputRetries++
+		exceeded = st.OutputRetries > h.conf.MaxRetries
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if exceeded {
+		return "", fmt.Errorf("final answer is still invalid after %d retries: %w", h.conf.MaxRetries, cause)
+	}
+
+	return fmt.Sprintf(finalAnswerCorrection, cause.Error()), nil
+}
+
+// checkFinalMessage is called when the model answers without tool calls,
+// it returns the corrective message if the answer is invalid.
+func (h *outputHandler) checkFinalMessage(ctx context.Context, msg Message) (string, error) {
+	var cause error
+	if h.conf.Mode == OutputModeTool {
+		cause = fmt.Errorf(finalAnswerToolMissing, h.toolName)
+	} else {
+		value, err := h.validate(ctx, msg.Content)
+		if err == nil {
+			setFinalAnswer(ctx, value)
+			return "", nil
+		}
+		cause = err
+	}
+
+	return h.retry(ctx, cause)
+}
+
+type finalAnswerTool struct {
+	h *outputHandler
+}
+
+func (t *finalAnswerTool) Info(_ context.Context) (*schema.ToolInfo, error) {
+	return &schema.ToolInfo{
+		Name:        t.h.toolName,
+		Desc:        FinalAnswerToolDesc,
+		ParamsOneOf: t.h.conf.Schema,
+	}, nil
+}
+
+func (t *finalAnswerTool) InvokableRun(ctx context.Context, argumentsInJSON string, _ ...tool.Option) (string, error) {
+	value, err := t.h.validate(ctx, argumentsInJSON)
+	if err == nil {
+		setFinalAnswer(ctx, value)
+		return argumentsInJSON, nil
+	}
+
+	correction, err := t.h.retry(ctx, err)
+	if err != nil {
+		return "", err
+	}
+
+	// go back to the model instead of returning directly
+	toolCallID := compose.GetToolCallID(ctx)
+	err = compose.ProcessState(ctx, func(_ context.Context, st *State) error {
+		if st.ReturnDirectlyToolCallID == toolCallID {
+			st.ReturnDirectlyToolCallID = ""
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return correction, nil
+}
+
+// finalAnswer holds the valid final answer of a run.
+type finalAnswer struct {
+	mu    sync.Mutex
+	value any
+	set   bool
+	// skipped is set when the run ends by an exit or transfer action, which needs no final answer.
+	skipped bool
+}
+
+type finalAnswerKey struct{}
+
+func withFinalAnswer(ctx context.Context) (context.Context, *finalAnswer) {
+	fa := &finalAnswer{}
+	return context.WithValue(ctx, finalAnswerKey{}, fa), fa
+}
+
+func setFinalAnswer(ctx context.Context, value any) {
+	fa, ok := ctx.Value(finalAnswerKey{}).(*finalAnswer)
+	if !ok {
+		return
+	}
+	fa.mu.Lock()
+	fa.value, fa.set = value, true
+	fa.mu.Unlock()
+}
+
+// skipFinalAnswer marks the final answer not required if the action ends the run.
+func skipFinalAnswer(ctx context.Context, action *AgentAction) {
+	if action == nil || !action.Exit && action.TransferToAgent == nil {
+		return
+	}
+	fa, ok := ctx.Value(finalAnswerKey{}).(*finalAnswer)
+	if !ok {
+		return
+	}
+	fa.mu.Lock()
+	fa.skipped = true
+	fa.mu.Unlock()
+}
+
+func (fa *finalAnswer) isSkipped() bool {
+	fa.mu.Lock()
+	defer fa.mu.Unlock()
+	return fa.skipped
+}
+
+func (fa *finalAnswer) get() (any, bool) {
+	fa.mu.Lock()
+	defer fa.mu.Unlock()
+	return fa.value, fa.set
+}
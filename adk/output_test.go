@@ -0,0 +1,275 @@
+/*
+ * Copyright 2025 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package adk
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/compose"
+	mockModel "github.com/cloudwego/eino/internal/mock/components/model"
+	"github.com/cloudwego/eino/schema"
+)
+
+type weatherAnswer struct {
+	City        string `json:"city" jsonschema:"required"`
+	Temperature int    `json:"temperature" jsonschema:"required"`
+}
+
+func finalAnswerCall(id, args string) Message {
+	return schema.AssistantMessage("", []schema.ToolCall{{ID: id, Function: schema.FunctionCall{Name: FinalAnswerToolName, Arguments: args}}})
+}
+
+func TestStructuredOutput(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("tool mode with retry", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		cm := mockModel.NewMockToolCallingChatModel(ctrl)
+		cm.EXPECT().WithTools(gomock.Any()).DoAndReturn(func(tools []*schema.ToolInfo) (*mockModel.MockToolCallingChatModel, error) {
+			assert.Equal(t, 1, len(tools))
+			assert.Equal(t, FinalAnswerToolName, tools[0].Name)
+			return cm, nil
+		}).AnyTimes()
+		gomock.InOrder(
+			cm.EXPECT().Generate(gomock.Any(), gomock.Any(), gomock.Any()).
+				Return(schema.AssistantMessage("it's sunny", nil), nil),
+			cm.EXPECT().Generate(gomock.Any(), gomock.Any(), gomock.Any()).
+				Return(finalAnswerCall("1", `{"city":"Beijing","temperature":-300}`), nil),
+			cm.EXPECT().Generate(gomock.Any(), gomock.Any(), gomock.Any()).
+				DoAndReturn(func(_ context.Context, input []Message, _ ...any) (Message, error) {
+					last := input[len(input)-1]
+					assert.Equal(t, schema.Tool, last.Role)
+					assert.Contains(t, last.Content, "too cold")
+					return finalAnswerCall("2", `{"city":"Beijing","temperature":25}`), nil
+				}),
+		)
+
+		conf, err := NewOutputConfig[weatherAnswer](&OutputConfig{
+			MaxRetries: 2,
+			Validate: func(ctx context.Context, value any) error {
+				if value.(weatherAnswer).Temperature < -100 {
+					return errors.New("too cold")
+				}
+				return nil
+			},
+		})
+		assert.NoError(t, err)
+
+		a, err := NewChatModelAgent(ctx, &ChatModelAgentConfig{
+			Name:        "weather",
+			Description: "weather agent",
+			Model:       cm,
+			OutputKey:   "weather",
+			Output:      conf,
+		})
+		assert.NoError(t, err)
+
+		runner := NewRunner(ctx, RunnerConfig{Agent: a})
+		iter := runner.Query(ctx, "weather of Beijing")
+		var events []*AgentEvent
+		for {
+			event, ok := iter.Next()
+			if !ok {
+				break
+			}
+			assert.NoError(t, event.Err)
+			events = append(events, event)
+		}
+		last := events[len(events)-1]
+		assert.Equal(t, weatherAnswer{City: "Beijing", Temperature: 25}, last.Output.CustomizedOutput)
+	})
+
+	t.Run("json mode", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		cm := mockModel.NewMockToolCallingChatModel(ctrl)
+		cm.EXPECT().WithTools(gomock.Any()).Return(cm, nil).AnyTimes()
+		gomock.InOrder(
+			cm.EXPECT().Generate(gomock.Any(), gomock.Any(), gomock.Any()).
+				DoAndReturn(func(_ context.Context, input []Message, _ ...any) (Message, error) {
+					assert.Equal(t, schema.System, input[0].Role)
+					assert.Contains(t, input[0].Content, "JSON schema")
+					return schema.AssistantMessage(`{"city":"Beijing"}`, nil), nil
+				}),
+			cm.EXPECT().Generate(gomock.Any(), gomock.Any(), gomock.Any()).
+				Return(schema.AssistantMessage("```json\n{\"city\":\"Beijing\",\"temperature\":25}\n```", nil), nil),
+		)
+
+		conf, err := NewOutputConfig[weatherAnswer](&OutputConfig{Mode: OutputModeJSON, MaxRetries: 1})
+		assert.NoError(t, err)
+		a, err := NewChatModelAgent(ctx, &ChatModelAgentConfig{
+			Name:        "weather",
+			Description: "weather agent",
+			Model:       cm,
+			OutputKey:   "weather",
+			Output:      conf,
+		})
+		assert.NoError(t, err)
+
+		iter := a.Run(ctx, &AgentInput{Messages: []Message{schema.UserMessage("weather of Beijing")}})
+		var events []*AgentEvent
+		for {
+			event, ok := iter.Next()
+			if !ok {
+				break
+			}
+			assert.NoError(t, event.Err)
+			events = append(events, event)
+		}
+		assert.Equal(t, 3, len(events))
+		assert.Equal(t, weatherAnswer{City: "Beijing", Temperature: 25}, events[2].Output.CustomizedOutput)
+	})
+
+	t.Run("json mode with session values", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		cm := mockModel.NewMockToolCallingChatModel(ctrl)
+		cm.EXPECT().WithTools(gomock.Any()).Return(cm, nil).AnyTimes()
+		cm.EXPECT().Generate(gomock.Any(), gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, input []Message, _ ...any) (Message, error) {
+				// the user instruction is rendered, and the JSON schema is kept as is
+				assert.True(t, strings.HasPrefix(input[0].Content, "answer Alice"))
+				assert.Contains(t, input[0].Content, `"properties"`)
+				return schema.AssistantMessage(`{"city":"Beijing","temperature":25}`, nil), nil
+			})
+
+		conf, err := NewOutputConfig[weatherAnswer](&OutputConfig{Mode: OutputModeJSON})
+		assert.NoError(t, err)
+		a, err := NewChatModelAgent(ctx, &ChatModelAgentConfig{
+			Name:        "weather",
+			Description: "weather agent",
+			InstructionProvider: func(ctx context.Context, _ *AgentInput) (string, error) {
+				SetSessionValue(ctx, "user", "Alice")
+				return "answer {user}", nil
+			},
+			Model:  cm,
+			Output: conf,
+		})
+		assert.NoError(t, err)
+
+		iter := NewRunner(ctx, RunnerConfig{Agent: a}).Query(ctx, "weather of Beijing")
+		var last *AgentEvent
+		for {
+			event, ok := iter.Next()
+			if !ok {
+				break
+			}
+			assert.NoError(t, event.Err)
+			last = event
+		}
+		assert.Equal(t, weatherAnswer{City: "Beijing", Temperature: 25}, last.Output.CustomizedOutput)
+	})
+
+	t.Run("retries exhausted", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		cm := mockModel.NewMockToolCallingChatModel(ctrl)
+		cm.EXPECT().WithTools(gomock.Any()).Return(cm, nil).AnyTimes()
+		cm.EXPECT().Generate(gomock.Any(), gomock.Any(), gomock.Any()).
+			Return(schema.AssistantMessage("not json", nil), nil).Times(2)
+
+		a, err := NewChatModelAgent(ctx, &ChatModelAgentConfig{
+			Name:        "weather",
+			Description: "weather agent",
+			Model:       cm,
+			Output: &OutputConfig{
+				Schema: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+					"city": {Type: schema.String, Required: true},
+				}),
+				Mode:       OutputModeJSON,
+				MaxRetries: 1,
+			},
+		})
+		assert.NoError(t, err)
+
+		iter := a.Run(ctx, &AgentInput{Messages: []Message{schema.UserMessage("weather of Beijing")}})
+		var lastErr error
+		for {
+			event, ok := iter.Next()
+			if !ok {
+				break
+			}
+			if event.Err != nil {
+				lastErr = event.Err
+			}
+		}
+		assert.ErrorContains(t, lastErr, "still invalid after 1 retries")
+	})
+
+	t.Run("ended without final answer", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		cm := mockModel.NewMockToolCallingChatModel(ctrl)
+		cm.EXPECT().WithTools(gomock.Any()).Return(cm, nil).AnyTimes()
+		cm.EXPECT().Generate(gomock.Any(), gomock.Any(), gomock.Any()).
+			Return(toolCallMessage("1", "test_tool", `{"name":"Alice"}`), nil)
+
+		conf, err := NewOutputConfig[weatherAnswer](nil)
+		assert.NoError(t, err)
+		a, err := NewChatModelAgent(ctx, &ChatModelAgentConfig{
+			Name:        "weather",
+			Description: "weather agent",
+			Model:       cm,
+			ToolsConfig: ToolsConfig{
+				ToolsNodeConfig: compose.ToolsNodeConfig{Tools: []tool.BaseTool{&fakeToolForTest{tarCount: 1}}},
+				ReturnDirectly:  map[string]bool{"test_tool": true},
+			},
+			Output: conf,
+		})
+		assert.NoError(t, err)
+
+		iter := a.Run(ctx, &AgentInput{Messages: []Message{schema.UserMessage("weather of Beijing")}})
+		var last *AgentEvent
+		for {
+			event, ok := iter.Next()
+			if !ok {
+				break
+			}
+			last = event
+		}
+		assert.ErrorIs(t, last.Err, ErrFinalAnswerMissing)
+		assert.Equal(t, "weather", last.AgentName)
+	})
+
+	t.Run("exit without final answer", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		cm := mockModel.NewMockToolCallingChatModel(ctrl)
+		cm.EXPECT().WithTools(gomock.Any()).Return(cm, nil).AnyTimes()
+		cm.EXPECT().Generate(gomock.Any(), gomock.Any(), gomock.Any()).
+			Return(toolCallMessage("1", ToolInfoExit.Name, `{"final_result":"unknown"}`), nil)
+
+		conf, err := NewOutputConfig[weatherAnswer](nil)
+		assert.NoError(t, err)
+		a, err := NewChatModelAgent(ctx, &ChatModelAgentConfig{
+			Name:        "weather",
+			Description: "weather agent",
+			Model:       cm,
+			Exit:        ExitTool{},
+			Output:      conf,
+		})
+		assert.NoError(t, err)
+
+		events := collectEvents(t, a.Run(ctx, &AgentInput{Messages: []Message{schema.UserMessage("weather of Beijing")}}))
+		for _, event := range events {
+			assert.NoError(t, event.Err)
+		}
+		assert.True(t, events[len(events)-1].Action.Exit)
+	})
+}
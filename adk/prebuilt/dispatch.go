@@ -0,0 +1,316 @@
+/*
+ * Copyright 2025 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package prebuilt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"strings"
+	"sync"
+
+	"github.com/bytedance/sonic"
+
+	"github.com/cloudwego/eino/adk"
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/internal/safe"
+	"github.com/cloudwego/eino/schema"
+)
+
+const (
+	DispatchToolName = "dispatch_to_agents"
+	dispatchToolDesc = `Dispatch tasks to several agents, which run the tasks concurrently, and return the results of all the tasks.
+Available agents:
+%s`
+)
+
+// DispatchFailurePolicy decides what to do when some of the dispatched tasks fail.
+type DispatchFailurePolicy string
+
+const (
+	// DispatchReportFailures reports the errors of the failed tasks in the tool result, along with the results of the other tasks.
+	DispatchReportFailures DispatchFailurePolicy = "report"
+	// DispatchAbortOnFailure cancels the other tasks and fails the tool on the first failed task.
+	DispatchAbortOnFailure DispatchFailurePolicy = "abort"
+)
+
+type DispatchToolConfig struct {
+	// SubAgents the tasks can be dispatched to, required.
+	SubAgents []adk.Agent
+
+	// MaxConcurrency is the max number of tasks running at the same time in one dispatch, optional, defaults to no limit.
+	MaxConcurrency int
+
+	// FailurePolicy optional, defaults to DispatchReportFailures.
+	FailurePolicy DispatchFailurePolicy
+
+	// ToolName optional, defaults to DispatchToolName.
+	ToolName string
+}
+
+// DispatchTask is a task in the arguments of the dispatch tool.
+type DispatchTask struct {
+	Agent string `json:"agent"`
+	Task  string `json:"task"`
+}
+
+// DispatchResult is the result of a task, the tool result is the JSON array of the results in the order of the tasks.
+type DispatchResult struct {
+	Agent  string `json:"agent"`
+	Task   string `json:"task"`
+	Result string `json:"result,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// NewDispatchTool creates a tool for the supervisor to dispatch tasks to several sub-agents concurrently.
+// Add it to the tools of the supervisor agent, so that the supervisor can either transfer to one sub-agent,
+// or dispatch tasks to several of them and gather the results as the tool result.
+// Each task runs the sub-agent in a new session with the task as the only input, and the last message of the
+// sub-agent is the result. Interrupts of the sub-agents are not supported, and fail the task.
+// A dispatched sub-agent can't transfer, e.g. back to the supervisor it is set to, the transfer ends the task instead.
+func NewDispatchTool(ctx context.Context, conf *DispatchToolConfig) (tool.InvokableTool, error) {
+	if len(conf.SubAgents) == 0 {
+		return nil, errors.New("'SubAgents' is required")
+	}
+
+	policy := conf.FailurePolicy
+	switch policy {
+	case "":
+		policy = DispatchReportFailures
+	case DispatchReportFailures, DispatchAbortOnFailure:
+	default:
+		return nil, fmt.Errorf("unknown dispatch failure policy: %s", policy)
+	}
+
+	dt := &dispatchTool{
+		name:           conf.ToolName,
+		agents:         make(map[string]adk.Agent, len(conf.SubAgents)),
+		maxConcurrency: conf.MaxConcurrency,
+		policy:         policy,
+	}
+	if dt.name == "" {
+		dt.name = DispatchToolName
+	}
+
+	var sb strings.Builder
+	for _, a := range conf.SubAgents {
+		name := a.Name(ctx)
+		if _, ok := dt.agents[name]; ok {
+			return nil, fmt.Errorf("duplicate sub-agent name: %s", name)
+		}
+		dt.agents[name] = a
+		dt.names = append(dt.names, name)
+		sb.WriteString(fmt.Sprintf("- %s: %s\n", name, a.Description(ctx)))
+	}
+	dt.desc = fmt.Sprintf(dispatchToolDesc, sb.String())
+
+	return dt, nil
+}
+
+type dispatchTool struct {
+	name   string
+	desc   string
+	names  []string
+	agents map[string]adk.Agent
+
+	maxConcurrency int
+	policy         DispatchFailurePolicy
+}
+
+func (d *dispatchTool) Info(_ context.Context) (*schema.ToolInfo, error) {
+	return &schema.ToolInfo{
+		Name: d.name,
+		Desc: d.desc,
+		ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+			"tasks": {
+				Type:     schema.Array,
+				Desc:     "the tasks to dispatch",
+				Required: true,
+				ElemInfo: &schema.ParameterInfo{
+					Type: schema.Object,
+					SubParams: map[string]*schema.ParameterInfo{
+						"agent": {
+							Type:     schema.String,
+							Desc:     "name of the agent to run the task",
+							Enum:     d.names,
+							Required: true,
+						},
+						"task": {
+							Type:     schema.String,
+							Desc:     "the task for the agent, with all the necessary context",
+							Required: true,
+						},
+					},
+				},
+			},
+		}),
+	}, nil
+}
+
+func (d *dispatchTool) InvokableRun(ctx context.Context, argumentsInJSON string, _ ...tool.Option) (string, error) {
+	var args struct {
+		Tasks []*DispatchTask `json:"tasks"`
+	}
+	if err := sonic.UnmarshalString(argumentsInJSON, &args); err != nil {
+		return "", err
+	}
+
+	agents := make([]adk.Agent, len(args.Tasks))
+	for i, t := range args.Tasks {
+		a, ok := d.agents[t.Agent]
+		if !ok {
+			return fmt.Sprintf("unknown agent '%s', available agents: %s", t.Agent, strings.Join(d.names, ", ")), nil
+		}
+		agents[i] = a
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	limit := d.maxConcurrency
+	if limit <= 0 || limit > len(args.Tasks) {
+		limit = len(args.Tasks)
+	}
+	sem := make(chan struct{}, limit)
+
+	results := make([]*DispatchResult, len(args.Tasks))
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		abortErr error
+	)
+	for i, t := range args.Tasks {
+		wg.Add(1)
+		go func(i int, t *DispatchTask) {
+			defer wg.Done()
+
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				results[i] = &DispatchResult{Agent: t.Agent, Task: t.Task, Error: ctx.Err().Error()}
+				return
+			}
+
+			result, err := runDispatchTask(ctx, agents[i], t.Task)
+			results[i] = &DispatchResult{Agent: t.Agent, Task: t.Task, Result: result}
+			if err != nil {
+				results[i].Error = err.Error()
+				if d.policy == DispatchAbortOnFailure {
+					once.Do(func() {
+						abortErr = fmt.Errorf("task of agent '%s' failed: %w", t.Agent, err)
+						cancel()
+					})
+				}
+			}
+		}(i, t)
+	}
+	wg.Wait()
+
+	if abortErr != nil {
+		return "", abortErr
+	}
+
+	return sonic.MarshalString(results)
+}
+
+func runDispatchTask(ctx context.Context, agent adk.Agent, task string) (result string, err error) {
+	defer func() {
+		if panicErr := recover(); panicErr != nil {
+			err = safe.NewPanicErr(panicErr, debug.Stack())
+		}
+	}()
+
+	iter := adk.NewRunner(ctx, adk.RunnerConfig{Agent: &dispatchedAgent{Agent: agent}}).Query(ctx, task)
+
+	var lastMsg adk.Message
+	for {
+		event, ok := iter.Next()
+		if !ok {
+			break
+		}
+		if event.Err != nil {
+			err = event.Err
+			continue
+		}
+		if event.Action != nil && event.Action.Interrupted != nil {
+			err = errors.New("sub-agent is interrupted, which is not supported in dispatch")
+			continue
+		}
+		if event.Output != nil && event.Output.MessageOutput != nil {
+			msg, err_ := event.Output.MessageOutput.GetMessage()
+			if err_ != nil {
+				err = err_
+				continue
+			}
+			// skip the message of the sub-agent calling the transfer tool without content
+			if msg.Role == schema.Assistant && (msg.Content != "" || len(msg.ToolCalls) == 0) {
+				lastMsg = msg
+			}
+		}
+	}
+	if err != nil {
+		return "", err
+	}
+	if lastMsg == nil {
+		return "", errors.New("sub-agent gives no answer")
+	}
+
+	return lastMsg.Content, nil
+}
+
+// dispatchedAgent runs the sub-agent of a dispatched task. The sub-agent may be set to the supervisor, and transfer
+// to it or other agents, which can't be resolved in the task, so the transfer actions are dropped to end the task.
+type dispatchedAgent struct {
+	adk.Agent
+}
+
+func (a *dispatchedAgent) Run(ctx context.Context, input *adk.AgentInput,
+	opts ...adk.AgentRunOption) *adk.AsyncIterator[*adk.AgentEvent] {
+
+	aIter := a.Agent.Run(ctx, input, opts...)
+
+	iterator, generator := adk.NewAsyncIteratorPair[*adk.AgentEvent]()
+	go func() {
+		defer func() {
+			panicErr := recover()
+			if panicErr != nil {
+				e := safe.NewPanicErr(panicErr, debug.Stack())
+				generator.Send(&adk.AgentEvent{Err: e})
+			}
+
+			generator.Close()
+		}()
+
+		for {
+			event, ok := aIter.Next()
+			if !ok {
+				break
+			}
+
+			if event.Action != nil && event.Action.TransferToAgent != nil {
+				action := *event.Action
+				action.TransferToAgent = nil
+				event.Action = &action
+			}
+			generator.Send(event)
+		}
+	}()
+
+	return iterator
+}
@@ -0,0 +1,168 @@
+/*
+ * Copyright 2025 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package prebuilt
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bytedance/sonic"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"github.com/cloudwego/eino/adk"
+	mockModel "github.com/cloudwego/eino/internal/mock/components/model"
+	"github.com/cloudwego/eino/schema"
+)
+
+type dispatchTestAgent struct {
+	name string
+	run  func(ctx context.Context, task string) (string, error)
+}
+
+func (a *dispatchTestAgent) Name(_ context.Context) string {
+	return a.name
+}
+
+func (a *dispatchTestAgent) Description(_ context.Context) string {
+	return a.name + " agent"
+}
+
+func (a *dispatchTestAgent) Run(ctx context.Context, input *adk.AgentInput, _ ...adk.AgentRunOption) *adk.AsyncIterator[*adk.AgentEvent] {
+	iter, gen := adk.NewAsyncIteratorPair[*adk.AgentEvent]()
+	go func() {
+		defer gen.Close()
+		result, err := a.run(ctx, input.Messages[len(input.Messages)-1].Content)
+		if err != nil {
+			gen.Send(&adk.AgentEvent{Err: err})
+			return
+		}
+		gen.Send(adk.EventFromMessage(schema.AssistantMessage(result, nil), nil, schema.Assistant, ""))
+	}()
+	return iter
+}
+
+func TestDispatchTool(t *testing.T) {
+	ctx := context.Background()
+
+	var running, maxRunning int32
+	echo := func(ctx context.Context, task string) (string, error) {
+		n := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+		for {
+			m := atomic.LoadInt32(&maxRunning)
+			if n <= m || atomic.CompareAndSwapInt32(&maxRunning, m, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		return "done: " + task, nil
+	}
+	failing := func(ctx context.Context, task string) (string, error) {
+		return "", errors.New("search engine down")
+	}
+
+	agents := []adk.Agent{
+		&dispatchTestAgent{name: "researcher", run: echo},
+		&dispatchTestAgent{name: "searcher", run: failing},
+	}
+
+	t.Run("report failures", func(t *testing.T) {
+		dt, err := NewDispatchTool(ctx, &DispatchToolConfig{SubAgents: agents, MaxConcurrency: 2})
+		assert.NoError(t, err)
+
+		info, err := dt.Info(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, DispatchToolName, info.Name)
+		assert.Contains(t, info.Desc, "- searcher: searcher agent")
+
+		out, err := dt.InvokableRun(ctx, `{"tasks":[
+			{"agent":"researcher","task":"a"},
+			{"agent":"researcher","task":"b"},
+			{"agent":"searcher","task":"c"},
+			{"agent":"researcher","task":"d"}]}`)
+		assert.NoError(t, err)
+
+		var results []*DispatchResult
+		assert.NoError(t, sonic.UnmarshalString(out, &results))
+		assert.Equal(t, []*DispatchResult{
+			{Agent: "researcher", Task: "a", Result: "done: a"},
+			{Agent: "researcher", Task: "b", Result: "done: b"},
+			{Agent: "searcher", Task: "c", Error: "search engine down"},
+			{Agent: "researcher", Task: "d", Result: "done: d"},
+		}, results)
+		assert.LessOrEqual(t, atomic.LoadInt32(&maxRunning), int32(2))
+	})
+
+	t.Run("abort on failure", func(t *testing.T) {
+		dt, err := NewDispatchTool(ctx, &DispatchToolConfig{SubAgents: agents, FailurePolicy: DispatchAbortOnFailure})
+		assert.NoError(t, err)
+
+		_, err = dt.InvokableRun(ctx, `{"tasks":[{"agent":"researcher","task":"a"},{"agent":"searcher","task":"b"}]}`)
+		assert.ErrorContains(t, err, "task of agent 'searcher' failed: search engine down")
+	})
+
+	t.Run("unknown agent", func(t *testing.T) {
+		dt, err := NewDispatchTool(ctx, &DispatchToolConfig{SubAgents: agents})
+		assert.NoError(t, err)
+
+		out, err := dt.InvokableRun(ctx, `{"tasks":[{"agent":"writer","task":"a"}]}`)
+		assert.NoError(t, err)
+		assert.Equal(t, "unknown agent 'writer', available agents: researcher, searcher", out)
+	})
+}
+
+func TestDispatchTransferBack(t *testing.T) {
+	ctx := context.Background()
+
+	// the researcher is set as the sub-agent of the supervisor, and transfers back to it in the dispatched task
+	rm := mockModel.NewMockToolCallingChatModel(gomock.NewController(t))
+	rm.EXPECT().WithTools(gomock.Any()).Return(rm, nil).AnyTimes()
+	rm.EXPECT().Generate(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(schema.AssistantMessage("found it", []schema.ToolCall{{ID: "1", Function: schema.FunctionCall{
+			Name:      adk.TransferToAgentToolName,
+			Arguments: `{"agent_name": "supervisor"}`,
+		}}}), nil)
+	researcher, err := adk.NewChatModelAgent(ctx, &adk.ChatModelAgentConfig{
+		Name:        "researcher",
+		Description: "researcher",
+		Model:       rm,
+	})
+	assert.NoError(t, err)
+
+	supervisor, err := adk.NewChatModelAgent(ctx, &adk.ChatModelAgentConfig{
+		Name:        "supervisor",
+		Description: "supervisor",
+		Model:       mockModel.NewMockToolCallingChatModel(gomock.NewController(t)),
+	})
+	assert.NoError(t, err)
+	_, err = adk.SetSubAgents(ctx, supervisor, []adk.Agent{researcher})
+	assert.NoError(t, err)
+
+	dt, err := NewDispatchTool(ctx, &DispatchToolConfig{SubAgents: []adk.Agent{researcher}})
+	assert.NoError(t, err)
+
+	out, err := dt.InvokableRun(ctx, `{"tasks":[{"agent":"researcher","task":"find"}]}`)
+	assert.NoError(t, err)
+
+	var results []*DispatchResult
+	assert.NoError(t, sonic.UnmarshalString(out, &results))
+	assert.Equal(t, []*DispatchResult{{Agent: "researcher", Task: "find", Result: "found it"}}, results)
+}
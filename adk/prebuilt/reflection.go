@@ -0,0 +1,309 @@
+/*
+ * Copyright 2025 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package prebuilt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+
+	"github.com/bytedance/sonic"
+
+	"github.com/cloudwego/eino/adk"
+	"github.com/cloudwego/eino/internal"
+	"github.com/cloudwego/eino/internal/safe"
+	"github.com/cloudwego/eino/schema"
+)
+
+const (
+	defaultReflectionMaxRounds = 3
+
+	reflectionCritiquePrompt = `Review the following answer to the conversation above.
+Respond with a JSON object ONLY: {"verdict": "accept" or "revise", "feedback": "what to improve, empty if accepted"}
+
+Answer:
+%s`
+	reflectionRevisePrompt = `Revise your answer according to the following feedback, and give the complete revised answer:
+%s`
+)
+
+type CriticVerdictType string
+
+const (
+	CriticAccept CriticVerdictType = "accept"
+	CriticRevise CriticVerdictType = "revise"
+)
+
+// CriticVerdict is the structured verdict of the critic on a draft.
+// The critic can give it as the CustomizedOutput of its last event, e.g. a ChatModelAgent with
+// adk.NewOutputConfig[CriticVerdict], or as a JSON object in the content of its last message.
+type CriticVerdict struct {
+	Verdict  CriticVerdictType `json:"verdict" jsonschema:"enum=accept,enum=revise"`
+	Feedback string            `json:"feedback"`
+}
+
+type ReflectionOutputMode string
+
+const (
+	// ReflectionOutputFinal emits the final draft only, as one event.
+	ReflectionOutputFinal ReflectionOutputMode = "final"
+	// ReflectionOutputHistory emits the events of the generator and the critic of all the rounds as they run,
+	// followed by the event of the final draft.
+	ReflectionOutputHistory ReflectionOutputMode = "history"
+)
+
+type ReflectionConfig struct {
+	Name        string
+	Description string
+
+	// Generator drafts the answer, and revises it according to the feedback of the critic, required.
+	Generator adk.Agent
+	// Critic reviews the drafts and gives CriticVerdict, required.
+	Critic adk.Agent
+
+	// MaxRounds is the max number of drafts, optional, defaults to 3.
+	// The last draft is the final one if none is accepted.
+	MaxRounds int
+
+	// OutputMode optional, defaults to ReflectionOutputFinal.
+	OutputMode ReflectionOutputMode
+
+	// ParseVerdict parses the verdict from the last message of the critic, optional,
+	// defaults to parsing CriticVerdict JSON from the content.
+	ParseVerdict func(ctx context.Context, msg adk.Message) (*CriticVerdict, error)
+}
+
+// ReflectionRound is a draft and the verdict of the critic on it.
+type ReflectionRound struct {
+	Draft   string
+	Verdict *CriticVerdict
+}
+
+// ReflectionResult is the CustomizedOutput of the event of the final draft.
+type ReflectionResult struct {
+	Draft    string
+	Accepted bool
+	Rounds   []*ReflectionRound
+}
+
+// NewReflectionAgent creates an agent running the generator-critic loop: the generator drafts an answer to the input,
+// the critic reviews it, and the generator revises it according to the feedback, until the critic accepts
+// the draft or MaxRounds is reached.
+// The generator sees the input, its last draft and the feedback, and the critic sees the input and the draft.
+// Each run of the generator or the critic is a new session, interrupts of them are not supported.
+func NewReflectionAgent(_ context.Context, conf *ReflectionConfig) (adk.Agent, error) {
+	if conf.Name == "" {
+		return nil, errors.New("agent 'Name' is required")
+	}
+	if conf.Description == "" {
+		return nil, errors.New("agent 'Description' is required")
+	}
+	if conf.Generator == nil {
+		return nil, errors.New("'Generator' is required")
+	}
+	if conf.Critic == nil {
+		return nil, errors.New("'Critic' is required")
+	}
+
+	a := &reflectionAgent{conf: *conf}
+	if a.conf.MaxRounds <= 0 {
+		a.conf.MaxRounds = defaultReflectionMaxRounds
+	}
+	switch a.conf.OutputMode {
+	case "":
+		a.conf.OutputMode = ReflectionOutputFinal
+	case ReflectionOutputFinal, ReflectionOutputHistory:
+	default:
+		return nil, fmt.Errorf("unknown reflection output mode: %s", a.conf.OutputMode)
+	}
+	if a.conf.ParseVerdict == nil {
+		a.conf.ParseVerdict = parseCriticVerdict
+	}
+
+	return a, nil
+}
+
+type reflectionAgent struct {
+	conf ReflectionConfig
+}
+
+func (a *reflectionAgent) Name(_ context.Context) string {
+	return a.conf.Name
+}
+
+func (a *reflectionAgent) Description(_ context.Context) string {
+	return a.conf.Description
+}
+
+func (a *reflectionAgent) Run(ctx context.Context, input *adk.AgentInput, _ ...adk.AgentRunOption) *adk.AsyncIterator[*adk.AgentEvent] {
+	iterator, generator := adk.NewAsyncIteratorPair[*adk.AgentEvent]()
+
+	go func() {
+		defer func() {
+			panicErr := recover()
+			if panicErr != nil {
+				e := safe.NewPanicErr(panicErr, debug.Stack())
+				generator.Send(&adk.AgentEvent{Err: e})
+			}
+
+			generator.Close()
+		}()
+
+		result, err := a.reflect(ctx, input, generator)
+		if err != nil {
+			generator.Send(&adk.AgentEvent{Err: err})
+			return
+		}
+
+		event := adk.EventFromMessage(schema.AssistantMessage(result.Draft, nil), nil, schema.Assistant, "")
+		event.Output.CustomizedOutput = result
+		generator.Send(event)
+	}()
+
+	return iterator
+}
+
+func (a *reflectionAgent) reflect(ctx context.Context, input *adk.AgentInput, generator *adk.AsyncGenerator[*adk.AgentEvent]) (*ReflectionResult, error) {
+	var forward func(event *adk.AgentEvent)
+	if a.conf.OutputMode == ReflectionOutputHistory {
+		forward = func(event *adk.AgentEvent) { generator.Send(event) }
+	}
+
+	result := &ReflectionResult{}
+	genInput := input.Messages
+	for round := 1; round <= a.conf.MaxRounds; round++ {
+		if generator.Canceled() {
+			return nil, context.Canceled
+		}
+
+		draft, _, err := runReflectionAgent(ctx, a.conf.Generator, genInput, input.EnableStreaming, forward)
+		if err != nil {
+			return nil, fmt.Errorf("generator failed in round %d: %w", round, err)
+		}
+
+		criticInput := append(input.Messages[:len(input.Messages):len(input.Messages)],
+			schema.UserMessage(fmt.Sprintf(reflectionCritiquePrompt, draft.Content)))
+		critique, output, err := runReflectionAgent(ctx, a.conf.Critic, criticInput, input.EnableStreaming, forward)
+		if err != nil {
+			return nil, fmt.Errorf("critic failed in round %d: %w", round, err)
+		}
+
+		verdict, ok := toCriticVerdict(output)
+		if !ok {
+			verdict, err = a.conf.ParseVerdict(ctx, critique)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse verdict of critic in round %d: %w", round, err)
+			}
+		}
+
+		result.Draft = draft.Content
+		result.Rounds = append(result.Rounds, &ReflectionRound{Draft: draft.Content, Verdict: verdict})
+		if verdict.Verdict == CriticAccept {
+			result.Accepted = true
+			break
+		}
+
+		genInput = append(input.Messages[:len(input.Messages):len(input.Messages)],
+			schema.AssistantMessage(draft.Content, nil),
+			schema.UserMessage(fmt.Sprintf(reflectionRevisePrompt, verdict.Feedback)))
+	}
+
+	return result, nil
+}
+
+// runReflectionAgent runs the agent in a new session, and returns its last assistant message
+// and the CustomizedOutput of its last event. Events are passed to forward if it's not nil.
+func runReflectionAgent(ctx context.Context, agent adk.Agent, messages []adk.Message, streaming bool,
+	forward func(event *adk.AgentEvent)) (adk.Message, any, error) {
+
+	iter := adk.NewRunner(ctx, adk.RunnerConfig{Agent: agent, EnableStreaming: streaming}).Run(ctx, messages)
+	defer iter.Close()
+
+	var (
+		lastMsg adk.Message
+		output  any
+	)
+	for {
+		event, ok := iter.Next()
+		if !ok {
+			break
+		}
+		if event.Err != nil {
+			return nil, nil, event.Err
+		}
+		if event.Action != nil && event.Action.Interrupted != nil {
+			return nil, nil, errors.New("interrupt is not supported in reflection")
+		}
+		if event.Output == nil {
+			continue
+		}
+		output = event.Output.CustomizedOutput
+
+		mv := event.Output.MessageOutput
+		if mv == nil {
+			if forward != nil {
+				forward(event)
+			}
+			continue
+		}
+		if mv.IsStreaming && forward != nil {
+			ss := mv.MessageStream.Copy(2)
+			mv.MessageStream = ss[0]
+			forward(event)
+			mv = &adk.MessageVariant{IsStreaming: true, MessageStream: ss[1], Role: mv.Role}
+		} else if forward != nil {
+			forward(event)
+		}
+
+		msg, err := mv.GetMessage()
+		if err != nil {
+			return nil, nil, err
+		}
+		if msg.Role == schema.Assistant {
+			lastMsg = msg
+		}
+	}
+
+	if lastMsg == nil {
+		return nil, nil, errors.New("agent gives no answer")
+	}
+	return lastMsg, output, nil
+}
+
+func toCriticVerdict(output any) (*CriticVerdict, bool) {
+	switch v := output.(type) {
+	case *CriticVerdict:
+		return v, v != nil
+	case CriticVerdict:
+		return &v, true
+	}
+	return nil, false
+}
+
+func parseCriticVerdict(_ context.Context, msg adk.Message) (*CriticVerdict, error) {
+	s := internal.TrimJSONCodeBlock(msg.Content)
+
+	v := &CriticVerdict{}
+	if err := sonic.UnmarshalString(s, v); err != nil {
+		return nil, err
+	}
+	if v.Verdict != CriticAccept && v.Verdict != CriticRevise {
+		return nil, fmt.Errorf("unknown verdict: %s", v.Verdict)
+	}
+	return v, nil
+}
@@ -0,0 +1,114 @@
+/*
+ * Copyright 2025 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package prebuilt
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cloudwego/eino/adk"
+)
+
+func TestReflectionAgent(t *testing.T) {
+	ctx := context.Background()
+
+	newAgents := func(acceptAt int) (adk.Agent, adk.Agent, *[]string) {
+		var tasks []string
+		drafts := 0
+		gen := &dispatchTestAgent{name: "writer", run: func(ctx context.Context, task string) (string, error) {
+			tasks = append(tasks, task)
+			drafts++
+			return fmt.Sprintf("draft %d", drafts), nil
+		}}
+		critic := &dispatchTestAgent{name: "critic", run: func(ctx context.Context, task string) (string, error) {
+			if strings.HasSuffix(task, fmt.Sprintf("draft %d", acceptAt)) {
+				return "```json\n{\"verdict\": \"accept\"}\n```", nil
+			}
+			return `{"verdict": "revise", "feedback": "be shorter"}`, nil
+		}}
+		return gen, critic, &tasks
+	}
+
+	t.Run("final", func(t *testing.T) {
+		gen, critic, tasks := newAgents(2)
+		a, err := NewReflectionAgent(ctx, &ReflectionConfig{Name: "reflect", Description: "reflect", Generator: gen, Critic: critic})
+		assert.NoError(t, err)
+
+		iter := adk.NewRunner(ctx, adk.RunnerConfig{Agent: a}).Query(ctx, "write a poem")
+		var events []*adk.AgentEvent
+		for {
+			event, ok := iter.Next()
+			if !ok {
+				break
+			}
+			assert.NoError(t, event.Err)
+			events = append(events, event)
+		}
+
+		assert.Len(t, events, 1)
+		assert.Equal(t, "draft 2", events[0].Output.MessageOutput.Message.Content)
+		assert.Equal(t, &ReflectionResult{
+			Draft:    "draft 2",
+			Accepted: true,
+			Rounds: []*ReflectionRound{
+				{Draft: "draft 1", Verdict: &CriticVerdict{Verdict: CriticRevise, Feedback: "be shorter"}},
+				{Draft: "draft 2", Verdict: &CriticVerdict{Verdict: CriticAccept}},
+			},
+		}, events[0].Output.CustomizedOutput)
+		assert.Equal(t, []string{"write a poem", fmt.Sprintf(reflectionRevisePrompt, "be shorter")}, *tasks)
+	})
+
+	t.Run("history with max rounds", func(t *testing.T) {
+		gen, critic, _ := newAgents(0)
+		a, err := NewReflectionAgent(ctx, &ReflectionConfig{
+			Name:        "reflect",
+			Description: "reflect",
+			Generator:   gen,
+			Critic:      critic,
+			MaxRounds:   2,
+			OutputMode:  ReflectionOutputHistory,
+		})
+		assert.NoError(t, err)
+
+		iter := adk.NewRunner(ctx, adk.RunnerConfig{Agent: a}).Query(ctx, "write a poem")
+		var contents []string
+		var result *ReflectionResult
+		for {
+			event, ok := iter.Next()
+			if !ok {
+				break
+			}
+			assert.NoError(t, event.Err)
+			contents = append(contents, event.Output.MessageOutput.Message.Content)
+			if r, ok := event.Output.CustomizedOutput.(*ReflectionResult); ok {
+				result = r
+			}
+		}
+
+		assert.Equal(t, []string{
+			"draft 1", `{"verdict": "revise", "feedback": "be shorter"}`,
+			"draft 2", `{"verdict": "revise", "feedback": "be shorter"}`,
+			"draft 2",
+		}, contents)
+		assert.False(t, result.Accepted)
+		assert.Len(t, result.Rounds, 2)
+	})
+}
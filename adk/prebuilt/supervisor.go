@@ -18,6 +18,8 @@ package prebuilt
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"runtime/debug"
 
 	"github.com/cloudwego/eino/adk"
@@ -28,6 +30,10 @@ import (
 type SupervisorConfig struct {
 	Supervisor adk.Agent
 	SubAgents  []adk.Agent
+
+	// Dispatch adds the tool created by NewDispatchTool to the supervisor, optional.
+	// SubAgents of the config defaults to SubAgents, and the supervisor must be an *adk.ChatModelAgent.
+	Dispatch *DispatchToolConfig
 }
 
 type BackToParentWrapper struct {
@@ -36,6 +42,10 @@ type BackToParentWrapper struct {
 	parentAgentName string
 }
 
+func (a *BackToParentWrapper) Unwrap() adk.Agent {
+	return a.Agent
+}
+
 func (a *BackToParentWrapper) Run(ctx context.Context, input *adk.AgentInput,
 	opts ...adk.AgentRunOption) *adk.AsyncIterator[*adk.AgentEvent] {
 
@@ -82,7 +92,27 @@ func (a *BackToParentWrapper) Run(ctx context.Context, input *adk.AgentInput,
 	return iterator
 }
 
+// NewSupervisor sets the sub-agents to the supervisor, and makes them transfer back to the supervisor when they finish.
+// To let the supervisor dispatch tasks to several sub-agents concurrently, set SupervisorConfig.Dispatch.
 func NewSupervisor(ctx context.Context, conf *SupervisorConfig) (adk.Agent, error) {
+	if conf.Dispatch != nil {
+		cma, ok := conf.Supervisor.(*adk.ChatModelAgent)
+		if !ok {
+			return nil, errors.New("'Dispatch' requires the supervisor to be a ChatModelAgent")
+		}
+		dc := *conf.Dispatch
+		if len(dc.SubAgents) == 0 {
+			dc.SubAgents = conf.SubAgents
+		}
+		dt, err := NewDispatchTool(ctx, &dc)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create dispatch tool: %w", err)
+		}
+		if err = cma.AddTools(ctx, dt); err != nil {
+			return nil, err
+		}
+	}
+
 	subAgents := make([]adk.Agent, 0, len(conf.SubAgents))
 	supervisorName := conf.Supervisor.Name(ctx)
 	for _, subAgent := range conf.SubAgents {
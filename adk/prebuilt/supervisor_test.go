@@ -18,6 +18,7 @@ package prebuilt
 
 import (
 	"context"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -25,6 +26,7 @@ import (
 
 	"github.com/cloudwego/eino/adk"
 	mockAdk "github.com/cloudwego/eino/internal/mock/adk"
+	mockModel "github.com/cloudwego/eino/internal/mock/components/model"
 	"github.com/cloudwego/eino/schema"
 )
 
@@ -168,3 +170,64 @@ func TestNewSupervisor(t *testing.T) {
 	assert.Equal(t, schema.Assistant, event.Output.MessageOutput.Role)
 	assert.Equal(t, finishMsg.Content, event.Output.MessageOutput.Message.Content)
 }
+
+func TestSupervisorDispatch(t *testing.T) {
+	ctx := context.Background()
+	cm := mockModel.NewMockToolCallingChatModel(gomock.NewController(t))
+	cm.EXPECT().WithTools(gomock.Any()).DoAndReturn(func(tools []*schema.ToolInfo) (*mockModel.MockToolCallingChatModel, error) {
+		var names []string
+		for _, ti := range tools {
+			names = append(names, ti.Name)
+		}
+		assert.Contains(t, names, DispatchToolName)
+		return cm, nil
+	}).AnyTimes()
+	gomock.InOrder(
+		cm.EXPECT().Generate(gomock.Any(), gomock.Any(), gomock.Any()).
+			Return(schema.AssistantMessage("", []schema.ToolCall{{ID: "1", Function: schema.FunctionCall{
+				Name:      DispatchToolName,
+				Arguments: `{"tasks": [{"agent": "researcher", "task": "find"}, {"agent": "writer", "task": "write"}]}`,
+			}}}), nil),
+		cm.EXPECT().Generate(gomock.Any(), gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, input []*schema.Message, _ ...any) (*schema.Message, error) {
+				result := input[len(input)-1].Content
+				assert.True(t, strings.Contains(result, "done: find") && strings.Contains(result, "done: write"))
+				return schema.AssistantMessage("all done", nil), nil
+			}),
+	)
+
+	supervisor, err := adk.NewChatModelAgent(ctx, &adk.ChatModelAgentConfig{
+		Name:        "supervisor",
+		Description: "supervisor",
+		Model:       cm,
+	})
+	assert.NoError(t, err)
+	done := func(ctx context.Context, task string) (string, error) {
+		return "done: " + task, nil
+	}
+	a, err := NewSupervisor(ctx, &SupervisorConfig{
+		Supervisor: supervisor,
+		SubAgents:  []adk.Agent{&dispatchTestAgent{name: "researcher", run: done}, &dispatchTestAgent{name: "writer", run: done}},
+		Dispatch:   &DispatchToolConfig{MaxConcurrency: 1},
+	})
+	assert.NoError(t, err)
+
+	iter := adk.NewRunner(ctx, adk.RunnerConfig{Agent: a}).Query(ctx, "write a report")
+	var last *adk.AgentEvent
+	for {
+		event, ok := iter.Next()
+		if !ok {
+			break
+		}
+		assert.NoError(t, event.Err)
+		last = event
+	}
+	assert.Equal(t, "all done", last.Output.MessageOutput.Message.Content)
+
+	_, err = NewSupervisor(ctx, &SupervisorConfig{
+		Supervisor: &dispatchTestAgent{name: "custom"},
+		SubAgents:  []adk.Agent{&dispatchTestAgent{name: "writer", run: done}},
+		Dispatch:   &DispatchToolConfig{},
+	})
+	assert.ErrorContains(t, err, "requires the supervisor to be a ChatModelAgent")
+}
@@ -18,10 +18,12 @@ package adk
 
 import (
 	"context"
+	"fmt"
 	"io"
 
 	"github.com/cloudwego/eino/components/model"
 	"github.com/cloudwego/eino/compose"
+	"github.com/cloudwego/eino/internal/toolloop"
 	"github.com/cloudwego/eino/schema"
 )
 
@@ -35,6 +37,24 @@ type State struct {
 	AgentName string
 
 	AgentToolInterruptData map[string] /*tool call id*/ *agentToolInterruptInfo
+
+	// OutputRetries is the number of invalid final answers, only used when OutputConfig is set.
+	OutputRetries int
+	// OutputCorrection is the corrective message for the last invalid final answer.
+	OutputCorrection string
+
+	// FinalResponse is set when AfterModel ends the agent with the last response.
+	FinalResponse bool
+
+	// ToolCallHistory is the tool calls of the steps since the last loop, only used when ToolCallLoop is configured.
+	ToolCallHistory []string
+	// ToolCallLoop is the loop detected whose reaction is pending.
+	ToolCallLoop *ToolCallLoop
+
+	// Steps is the number of nodes run, only counted when MaxStepAnswer is configured.
+	Steps int
+	// MaxStepExhausted is set when the model is asked for the final answer after MaxStep is exhausted.
+	MaxStepExhausted bool
 }
 
 type agentToolInterruptInfo struct {
@@ -76,6 +96,20 @@ type reactConfig struct {
 	toolsReturnDirectly map[string]bool
 
 	agentName string
+
+	output *outputHandler
+
+	middlewares middlewares
+
+	toolSelector ToolSelector
+	// pinnedTools are the internal tools of the agent, bound to the model regardless of toolSelector,
+	// and not wrapped by the tool hooks of middlewares
+	pinnedTools map[string]bool
+
+	toolCallLoop *ToolCallLoopConfig
+
+	maxStep       int
+	maxStepAnswer *MaxStepAnswerConfig
 }
 
 func genToolInfos(ctx context.Context, config *compose.ToolsNodeConfig) ([]*schema.ToolInfo, error) {
@@ -114,10 +148,15 @@ func newReact(ctx context.Context, config *reactConfig) (reactGraph, error) {
 	}
 
 	const (
-		chatModel_ = "ChatModel"
-		toolNode_  = "ToolNode"
+		chatModel_        = "ChatModel"
+		toolNode_         = "ToolNode"
+		outputCorrection_ = "OutputCorrection"
+		maxStepAnswer_    = "MaxStepAnswer"
 	)
 
+	// the steps are counted by the agent to give the final answer before the graph fails for exceeding them
+	countSteps := config.maxStepAnswer != nil && config.maxStep > 0
+
 	g := compose.NewGraph[[]Message, Message](compose.WithGenLocalState(genState))
 
 	toolsInfo, err := genToolInfos(ctx, config.toolsConfig)
@@ -125,18 +164,49 @@ func newReact(ctx context.Context, config *reactConfig) (reactGraph, error) {
 		return nil, err
 	}
 
-	chatModel, err := config.model.WithTools(toolsInfo)
-	if err != nil {
-		return nil, err
+	cm := config.model
+	if config.middlewares.hasModelHooks() {
+		cm = &middlewareChatModel{inner: cm, ms: config.middlewares}
+	}
+	var chatModel model.BaseChatModel
+	if config.toolSelector != nil {
+		chatModel = newToolSelectingChatModel(cm, config.toolSelector, toolsInfo, config.pinnedTools)
+	} else {
+		chatModel, err = cm.WithTools(toolsInfo)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if config.toolCallLoop != nil || countSteps {
+		chatModel = &toolloop.ToolsForbiddenChatModel{Inner: chatModel, Forbidden: isToolCallingForbidden}
 	}
 
-	toolsNode, err := compose.NewToolNode(ctx, config.toolsConfig)
+	toolsConfig := config.toolsConfig
+	if config.middlewares.hasToolHooks() {
+		tc := *toolsConfig
+		tc.Tools = wrapToolsWithMiddlewares(tc.Tools, toolsInfo, config.pinnedTools, config.middlewares)
+		toolsConfig = &tc
+	}
+	toolsNode, err := compose.NewToolNode(ctx, toolsConfig)
 	if err != nil {
 		return nil, err
 	}
 
 	modelPreHandle := func(ctx context.Context, input []Message, st *State) ([]Message, error) {
 		st.Messages = append(st.Messages, input...)
+		if st.ToolCallLoop != nil {
+			st.Messages = append(st.Messages, schema.UserMessage(toolloop.GetMessage(config.toolCallLoop)))
+			if st.ToolCallLoop.Reaction == ToolCallLoopCorrect {
+				st.ToolCallLoop = nil
+			}
+		}
+		if countSteps {
+			st.Steps++
+			if st.Steps > config.maxStep {
+				st.MaxStepExhausted = true
+				st.Messages = append(st.Messages, schema.UserMessage(config.maxStepAnswer.prompt()))
+			}
+		}
 		return st.Messages, nil
 	}
 	_ = g.AddChatModelNode(chatModel_, chatModel,
@@ -146,6 +216,18 @@ func newReact(ctx context.Context, config *reactConfig) (reactGraph, error) {
 		if input != nil {
 			// isn't resume
 			st.Messages = append(st.Messages, input)
+			if countSteps {
+				st.Steps++
+			}
+
+			if config.toolCallLoop != nil {
+				if loop := toolloop.DetectLoop(ctx, config.toolCallLoop, st.AgentName, &st.ToolCallHistory, input.ToolCalls); loop != nil {
+					if loop.Reaction == ToolCallLoopFail {
+						return nil, &ToolCallLoopError{Loop: loop}
+					}
+					st.ToolCallLoop = loop
+				}
+			}
 		}
 
 		if len(config.toolsReturnDirectly) > 0 {
@@ -167,22 +249,132 @@ func newReact(ctx context.Context, config *reactConfig) (reactGraph, error) {
 
 	toolCallCheck := func(ctx context.Context, sMsg MessageStream) (string, error) {
 		defer sMsg.Close()
+
+		if config.middlewares.hasAfterModel() {
+			final, err_ := popFinalResponse(ctx)
+			if err_ != nil {
+				return "", err_
+			}
+			if final {
+				return compose.END, nil
+			}
+		}
+
+		var loop *ToolCallLoop
+		if config.toolCallLoop != nil {
+			err_ := compose.ProcessState(ctx, func(_ context.Context, st *State) error {
+				loop, st.ToolCallLoop = st.ToolCallLoop, nil
+				return nil
+			})
+			if err_ != nil {
+				return "", err_
+			}
+		}
+
+		var exhausted, outOfSteps bool
+		if countSteps {
+			err_ := compose.ProcessState(ctx, func(_ context.Context, st *State) error {
+				exhausted = st.MaxStepExhausted
+				// the tool node would be the last step, leaving no step for the model to answer with the tool results
+				outOfSteps = st.Steps+1 > config.maxStep
+				return nil
+			})
+			if err_ != nil {
+				return "", err_
+			}
+		}
+
+		var chunks []Message
 		for {
 			chunk, err_ := sMsg.Recv()
 			if err_ != nil {
 				if err_ == io.EOF {
-					return compose.END, nil
+					break
 				}
 
 				return "", err_
 			}
 
 			if len(chunk.ToolCalls) > 0 {
+				if loop != nil {
+					// the model is still calling tools when asked for the final answer
+					return "", &ToolCallLoopError{Loop: loop}
+				}
+				if exhausted {
+					return "", fmt.Errorf("model is still calling tools after max step is exhausted: %w", compose.ErrExceedMaxSteps)
+				}
+				if outOfSteps {
+					return maxStepAnswer_, nil
+				}
 				return toolNode_, nil
 			}
+
+			chunks = append(chunks, chunk)
+		}
+
+		// the answer after max step is exhausted can't be corrected any more
+		if config.output == nil || exhausted {
+			return compose.END, nil
+		}
+
+		msg, err_ := schema.ConcatMessages(chunks)
+		if err_ != nil {
+			return "", err_
+		}
+		correction, err_ := config.output.checkFinalMessage(ctx, msg)
+		if err_ != nil {
+			return "", err_
+		}
+		if correction == "" {
+			return compose.END, nil
+		}
+
+		err_ = compose.ProcessState(ctx, func(_ context.Context, st *State) error {
+			st.OutputCorrection = correction
+			return nil
+		})
+		if err_ != nil {
+			return "", err_
+		}
+
+		return outputCorrection_, nil
+	}
+	branchEnds := map[string]bool{compose.END: true, toolNode_: true}
+	if config.output != nil {
+		// re-prompt the model with the invalid answer and the corrective message
+		correct := func(ctx context.Context, input Message) ([]Message, error) {
+			var correction string
+			err_ := compose.ProcessState(ctx, func(_ context.Context, st *State) error {
+				correction = st.OutputCorrection
+				if countSteps {
+					st.Steps++
+				}
+				return nil
+			})
+			if err_ != nil {
+				return nil, err_
+			}
+
+			return []Message{input, schema.UserMessage(correction)}, nil
+		}
+		_ = g.AddLambdaNode(outputCorrection_, compose.InvokableLambda(correct), compose.WithNodeName(outputCorrection_))
+		_ = g.AddEdge(outputCorrection_, chatModel_)
+		branchEnds[outputCorrection_] = true
+	}
+	if countSteps {
+		// drop the tool calls which can't run, and ask the model for the final answer
+		answer := func(ctx context.Context, _ Message) ([]Message, error) {
+			err_ := compose.ProcessState(ctx, func(_ context.Context, st *State) error {
+				st.Steps++
+				return nil
+			})
+			return nil, err_
 		}
+		_ = g.AddLambdaNode(maxStepAnswer_, compose.InvokableLambda(answer), compose.WithNodeName(maxStepAnswer_))
+		_ = g.AddEdge(maxStepAnswer_, chatModel_)
+		branchEnds[maxStepAnswer_] = true
 	}
-	branch := compose.NewStreamGraphBranch(toolCallCheck, map[string]bool{compose.END: true, toolNode_: true})
+	branch := compose.NewStreamGraphBranch(toolCallCheck, branchEnds)
 	_ = g.AddBranch(chatModel_, branch)
 
 	if len(config.toolsReturnDirectly) == 0 {
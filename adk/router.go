@@ -0,0 +1,182 @@
+/*
+ * Copyright 2025 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package adk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/bytedance/sonic"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/internal"
+	"github.com/cloudwego/eino/schema"
+)
+
+const routerClassifierPrompt = `You are a router. Pick the agents to handle the conversation according to their descriptions.
+
+Available agents:%s
+
+Respond with a JSON object ONLY: {"agents": ["<agent name>", ...], "reason": "<short explanation>"}
+Pick as few agents as possible, and an empty list if none of them fits.`
+
+// RouteFunc picks the sub-agents to run by their names, from the input and the session values by GetSessionValues.
+type RouteFunc func(ctx context.Context, input *AgentInput) ([]string, error)
+
+// RouteAction reports the sub-agents picked by a router agent, in AgentAction.Route.
+type RouteAction struct {
+	Agents []string `json:"agents"`
+	Reason string   `json:"reason,omitempty"`
+}
+
+type RouterAgentConfig struct {
+	Name        string
+	Description string
+	SubAgents   []Agent
+
+	// Route picks the sub-agents deterministically, without model calls.
+	Route RouteFunc
+	// Classifier picks the sub-agents by their descriptions, used if Route is nil.
+	// One of Route and Classifier is required.
+	Classifier model.BaseChatModel
+
+	// ParallelRoutes runs the picked sub-agents in parallel, otherwise they run one by one in the order picked.
+	ParallelRoutes bool
+}
+
+// NewRouterAgent creates a workflow agent running the sub-agents picked for the input, instead of all of them.
+// The decision is emitted as an event with AgentAction.Route before running the picked sub-agents,
+// and the router ends with no more events if none is picked.
+// On resume, the sub-agents picked before the interrupt are resumed without routing again.
+func NewRouterAgent(ctx context.Context, config *RouterAgentConfig) (Agent, error) {
+	if config.Route == nil && config.Classifier == nil {
+		return nil, errors.New("one of 'Route' and 'Classifier' is required")
+	}
+
+	fa, err := newWorkflowAgent(ctx, config.Name, config.Description, config.SubAgents, workflowAgentModeRouter, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	wa := fa.Agent.(*workflowAgent)
+	wa.parallelRoutes = config.ParallelRoutes
+	if config.Route != nil {
+		route := config.Route
+		wa.route = func(ctx context.Context, input *AgentInput) (*RouteAction, error) {
+			agents, err := route(ctx, input)
+			if err != nil {
+				return nil, err
+			}
+			return &RouteAction{Agents: agents}, nil
+		}
+	} else {
+		classifier := config.Classifier
+		wa.route = func(ctx context.Context, input *AgentInput) (*RouteAction, error) {
+			return classifyRoute(ctx, classifier, wa.subAgents, input)
+		}
+	}
+
+	return fa, nil
+}
+
+func (a *workflowAgent) runRouter(ctx context.Context, input *AgentInput,
+	generator *AsyncGenerator[*AgentEvent], intInfo *workflowInterruptInfo, opts ...AgentRunOption) error {
+
+	var names []string
+	if intInfo != nil {
+		names = intInfo.RoutedAgents
+	} else {
+		decision, err := a.route(ctx, input)
+		if err != nil {
+			return fmt.Errorf("failed to route: %w", err)
+		}
+
+		generator.Send(&AgentEvent{
+			AgentName: a.Name(ctx),
+			RunPath:   getRunCtx(ctx).RunPath,
+			Action:    &AgentAction{Route: decision},
+		})
+		names = decision.Agents
+	}
+
+	picked := make([]*flowAgent, 0, len(names))
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		sa := a.getSubAgent(ctx, name)
+		if sa == nil {
+			return fmt.Errorf("routed agent '%s' isn't a sub-agent of '%s'", name, a.name)
+		}
+		picked = append(picked, sa)
+	}
+	if len(picked) == 0 {
+		return nil
+	}
+
+	// the picked sub-agents run as a workflow of their own, which records them in its interrupt info for resuming
+	routed := &workflowAgent{
+		name:         a.name,
+		description:  a.description,
+		subAgents:    picked,
+		routedAgents: names,
+	}
+	if a.parallelRoutes {
+		routed.runParallel(ctx, input, generator, intInfo, opts...)
+	} else {
+		routed.runSequential(ctx, input, generator, intInfo, 0, opts...)
+	}
+	return nil
+}
+
+func (a *workflowAgent) getSubAgent(ctx context.Context, name string) *flowAgent {
+	for _, sa := range a.subAgents {
+		if sa.Name(ctx) == name {
+			return sa
+		}
+	}
+	return nil
+}
+
+func classifyRoute(ctx context.Context, classifier model.BaseChatModel, subAgents []*flowAgent, input *AgentInput) (*RouteAction, error) {
+	var sb strings.Builder
+	for _, sa := range subAgents {
+		sb.WriteString(fmt.Sprintf("\n- Agent name: %s\n  Agent description: %s", sa.Name(ctx), sa.Description(ctx)))
+	}
+
+	msgs := make([]Message, 0, len(input.Messages)+1)
+	msgs = append(msgs, schema.SystemMessage(fmt.Sprintf(routerClassifierPrompt, sb.String())))
+	msgs = append(msgs, input.Messages...)
+
+	out, err := classifier.Generate(ctx, msgs)
+	if err != nil {
+		return nil, err
+	}
+
+	content := internal.TrimJSONCodeBlock(out.Content)
+
+	decision := &RouteAction{}
+	if err = sonic.UnmarshalString(content, decision); err != nil {
+		return nil, fmt.Errorf("failed to parse decision of classifier: %w", err)
+	}
+	return decision, nil
+}
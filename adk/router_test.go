@@ -0,0 +1,206 @@
+/*
+ * Copyright 2025 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package adk
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+func TestRouterAgent(t *testing.T) {
+	ctx := context.Background()
+
+	newAgents := func() []Agent {
+		var agents []Agent
+		for _, name := range []string{"billing", "tech", "sales"} {
+			agents = append(agents, newMockAgent(name, name+" questions", []*AgentEvent{
+				{Output: &AgentOutput{MessageOutput: &MessageVariant{Message: schema.AssistantMessage(name+" answer", nil)}}},
+			}))
+		}
+		return agents
+	}
+
+	run := func(a Agent, query string) ([]string, []*RouteAction, error) {
+		iter := NewRunner(ctx, RunnerConfig{Agent: a}).Query(ctx, query)
+		var (
+			contents  []string
+			decisions []*RouteAction
+		)
+		for {
+			event, ok := iter.Next()
+			if !ok {
+				break
+			}
+			if event.Err != nil {
+				return nil, nil, event.Err
+			}
+			if event.Action != nil && event.Action.Route != nil {
+				assert.Equal(t, "router", event.AgentName)
+				decisions = append(decisions, event.Action.Route)
+				continue
+			}
+			contents = append(contents, event.AgentName+": "+event.Output.MessageOutput.Message.Content)
+		}
+		return contents, decisions, nil
+	}
+
+	t.Run("route func", func(t *testing.T) {
+		a, err := NewRouterAgent(ctx, &RouterAgentConfig{
+			Name:        "router",
+			Description: "router",
+			SubAgents:   newAgents(),
+			Route: func(ctx context.Context, input *AgentInput) ([]string, error) {
+				query := input.Messages[0].Content
+				switch {
+				case strings.Contains(query, "invoice"):
+					return []string{"billing"}, nil
+				case strings.Contains(query, "unknown"):
+					return []string{"legal"}, nil
+				}
+				return nil, nil
+			},
+		})
+		assert.NoError(t, err)
+
+		contents, decisions, err := run(a, "where is my invoice")
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"billing: billing answer"}, contents)
+		assert.Equal(t, []*RouteAction{{Agents: []string{"billing"}}}, decisions)
+
+		contents, decisions, err = run(a, "hi")
+		assert.NoError(t, err)
+		assert.Empty(t, contents)
+		assert.Equal(t, []*RouteAction{{}}, decisions)
+
+		_, _, err = run(a, "unknown")
+		assert.ErrorContains(t, err, "routed agent 'legal' isn't a sub-agent of 'router'")
+	})
+
+	t.Run("classifier", func(t *testing.T) {
+		classifier := &myModel{
+			messages: []*schema.Message{
+				schema.AssistantMessage("```json\n{\"agents\": [\"tech\", \"billing\"], \"reason\": \"both\"}\n```", nil),
+			},
+			validator: func(_ int, input []*schema.Message) bool {
+				return strings.Contains(input[0].Content, "- Agent name: sales\n  Agent description: sales questions") &&
+					input[1].Content == "my router is broken and I was charged twice"
+			},
+		}
+		a, err := NewRouterAgent(ctx, &RouterAgentConfig{
+			Name:        "router",
+			Description: "router",
+			SubAgents:   newAgents(),
+			Classifier:  classifier,
+		})
+		assert.NoError(t, err)
+
+		contents, decisions, err := run(a, "my router is broken and I was charged twice")
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"tech: tech answer", "billing: billing answer"}, contents)
+		assert.Equal(t, []*RouteAction{{Agents: []string{"tech", "billing"}, Reason: "both"}}, decisions)
+	})
+
+	t.Run("config", func(t *testing.T) {
+		_, err := NewRouterAgent(ctx, &RouterAgentConfig{Name: "router", Description: "router", SubAgents: newAgents()})
+		assert.Error(t, err)
+	})
+}
+
+func TestRouterAgentInterrupt(t *testing.T) {
+	ctx := context.Background()
+
+	for _, parallel := range []bool{false, true} {
+		resumed := 0
+		interrupting := &myAgent{
+			name: "interrupting",
+			runner: func(ctx context.Context, input *AgentInput, options ...AgentRunOption) *AsyncIterator[*AgentEvent] {
+				iter, generator := NewAsyncIteratorPair[*AgentEvent]()
+				generator.Send(&AgentEvent{Action: &AgentAction{Interrupted: &InterruptInfo{Data: "interrupt data"}}})
+				generator.Close()
+				return iter
+			},
+			resumer: func(ctx context.Context, info *ResumeInfo, opts ...AgentRunOption) *AsyncIterator[*AgentEvent] {
+				assert.Equal(t, "interrupt data", info.Data)
+				resumed++
+				iter, generator := NewAsyncIteratorPair[*AgentEvent]()
+				generator.Send(&AgentEvent{Output: &AgentOutput{MessageOutput: &MessageVariant{Message: schema.AssistantMessage("resumed", nil)}}})
+				generator.Close()
+				return iter
+			},
+		}
+		plain := newMockAgent("plain", "plain", []*AgentEvent{
+			{Output: &AgentOutput{MessageOutput: &MessageVariant{Message: schema.AssistantMessage("done", nil)}}},
+		})
+		unused := newMockAgent("unused", "unused", nil)
+
+		routes := 0
+		a, err := NewRouterAgent(ctx, &RouterAgentConfig{
+			Name:        "router",
+			Description: "router",
+			SubAgents:   []Agent{interrupting, plain, unused},
+			Route: func(ctx context.Context, input *AgentInput) ([]string, error) {
+				routes++
+				return []string{"interrupting", "plain"}, nil
+			},
+			ParallelRoutes: parallel,
+		})
+		assert.NoError(t, err)
+
+		runner := NewRunner(ctx, RunnerConfig{Agent: a, CheckPointStore: newMyStore()})
+		collect := func(iter *AsyncIterator[*AgentEvent]) (contents []string, interrupted bool) {
+			for {
+				event, ok := iter.Next()
+				if !ok {
+					return contents, interrupted
+				}
+				assert.NoError(t, event.Err)
+				if event.Action != nil && event.Action.Interrupted != nil {
+					interrupted = true
+				}
+				if event.Output != nil {
+					contents = append(contents, event.Output.MessageOutput.Message.Content)
+				}
+			}
+		}
+
+		contents, interrupted := collect(runner.Query(ctx, "hello", WithCheckPointID("1")))
+		assert.True(t, interrupted)
+		if parallel {
+			assert.Equal(t, []string{"done"}, contents)
+		} else {
+			assert.Empty(t, contents)
+		}
+
+		iter, err := runner.Resume(ctx, "1")
+		assert.NoError(t, err)
+		contents, interrupted = collect(iter)
+		assert.False(t, interrupted)
+		if parallel {
+			assert.Equal(t, []string{"resumed"}, contents)
+		} else {
+			assert.Equal(t, []string{"resumed", "done"}, contents)
+		}
+
+		assert.Equal(t, 1, routes)
+		assert.Equal(t, 1, resumed)
+	}
+}
@@ -18,6 +18,7 @@ package adk
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"runtime/debug"
 
@@ -26,10 +27,18 @@ import (
 	"github.com/cloudwego/eino/schema"
 )
 
+// ErrCheckPointNotFound is returned by Runner.Resume and Runner.ResumeWithData when the checkpoint doesn't exist.
+var ErrCheckPointNotFound = errors.New("checkpoint not found")
+
 type Runner struct {
 	a               Agent
 	enableStreaming bool
 	store           compose.CheckPointStore
+
+	eventBufferSize int
+
+	threadStore         ThreadStore
+	threadHistoryFilter ThreadHistoryFilter
 }
 
 type RunnerConfig struct {
@@ -37,6 +46,15 @@ type RunnerConfig struct {
 	EnableStreaming bool
 
 	CheckPointStore compose.CheckPointStore
+
+	// EventBufferSize bounds the number of events buffered by each agent, optional, defaults to unbounded.
+	// Agents block when the buffer is full, until the consumer catches up.
+	EventBufferSize int
+
+	// ThreadStore persists the conversations run with WithThreadID, optional.
+	ThreadStore ThreadStore
+	// ThreadHistoryFilter decides which events become the history of the thread, optional, defaults to ThreadHistoryReplies.
+	ThreadHistoryFilter ThreadHistoryFilter
 }
 
 func NewRunner(_ context.Context, conf RunnerConfig) *Runner {
@@ -44,6 +62,10 @@ func NewRunner(_ context.Context, conf RunnerConfig) *Runner {
 		enableStreaming: conf.EnableStreaming,
 		a:               conf.Agent,
 		store:           conf.CheckPointStore,
+		eventBufferSize: conf.EventBufferSize,
+
+		threadStore:         conf.ThreadStore,
+		threadHistoryFilter: conf.ThreadHistoryFilter,
 	}
 }
 
@@ -53,21 +75,64 @@ func (r *Runner) Run(ctx context.Context, messages []Message,
 
 	fa := toFlowAgent(ctx, r.a)
 
+	ctx = ctxWithNewRunCtx(ctx)
+
+	var th *threadRun
+	if o.threadID != nil {
+		var err error
+		th, err = r.newThreadRun(ctx, *o.threadID, messages)
+		if err == nil {
+			messages, err = th.load(ctx)
+		}
+		if err != nil {
+			return errorIter(err)
+		}
+	}
+
 	input := &AgentInput{
 		Messages:        messages,
 		EnableStreaming: r.enableStreaming,
 	}
 
-	ctx = ctxWithNewRunCtx(ctx)
+	ctx, cancel := r.initRunCtx(ctx)
+
+	iter := r.wrapIter(ctx, fa.Run(ctx, input, opts...), cancel, o.checkPointID)
+	if th != nil {
+		iter = th.record(ctx, iter)
+	}
+	return iter
+}
+
+func errorIter(err error) *AsyncIterator[*AgentEvent] {
+	iter, gen := NewAsyncIteratorPair[*AgentEvent]()
+	gen.Send(&AgentEvent{Err: err})
+	gen.Close()
+	return iter
+}
+
+// initRunCtx applies the options of the runner to ctx, and makes ctx cancelable by closing the iterator of the run.
+// The returned cancel is called when the run ends, either closed by the consumer or all its events are received.
+func (r *Runner) initRunCtx(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx = withEventBufferSize(ctx, r.eventBufferSize)
+	return context.WithCancel(ctx)
+}
+
+func (r *Runner) wrapIter(ctx context.Context, iter *AsyncIterator[*AgentEvent], cancel context.CancelFunc,
+	checkPointID *string) *AsyncIterator[*AgentEvent] {
 
-	iter := fa.Run(ctx, input, opts...)
 	if r.store == nil {
+		iter.ch.addOnCancel(cancel)
+		iter.ch.addOnDrain(cancel)
 		return iter
 	}
 
-	niter, gen := NewAsyncIteratorPair[*AgentEvent]()
+	niter, gen := newEventIteratorPair(ctx)
+	niter.ch.addOnCancel(func() {
+		cancel()
+		iter.Close()
+	})
 
-	go r.handleIter(ctx, iter, gen, o.checkPointID)
+	go r.handleIter(ctx, iter, gen, cancel, checkPointID)
 	return niter
 }
 
@@ -86,6 +151,20 @@ func (r *Runner) Query(ctx context.Context,
 }
 
 func (r *Runner) Resume(ctx context.Context, checkPointID string, opts ...AgentRunOption) (*AsyncIterator[*AgentEvent], error) {
+	return r.resume(ctx, checkPointID, nil, opts...)
+}
+
+// ResumeWithData resumes the interrupted run, and passes resume data to the interrupt points.
+// The key of data is InterruptPoint.ID, which can be got from InterruptInfo.Points() of the interrupt event,
+// the interrupted agents or tools read the data meant for them by GetResumeData.
+func (r *Runner) ResumeWithData(ctx context.Context, checkPointID string, data map[string]any,
+	opts ...AgentRunOption) (*AsyncIterator[*AgentEvent], error) {
+
+	return r.resume(ctx, checkPointID, data, opts...)
+}
+
+func (r *Runner) resume(ctx context.Context, checkPointID string, data map[string]any,
+	opts ...AgentRunOption) (*AsyncIterator[*AgentEvent], error) {
 	if r.store == nil {
 		return nil, fmt.Errorf("failed to resume: store is nil")
 	}
@@ -95,22 +174,32 @@ func (r *Runner) Resume(ctx context.Context, checkPointID string, opts ...AgentR
 		return nil, fmt.Errorf("failed to get checkpoint: %w", err)
 	}
 	if !existed {
-		return nil, fmt.Errorf("checkpoint[%s] is not existed", checkPointID)
+		return nil, fmt.Errorf("%w: %s", ErrCheckPointNotFound, checkPointID)
 	}
 
-	ctx = setRunCtx(ctx, runCtx)
-	aIter := toFlowAgent(ctx, r.a).Resume(ctx, info, opts...)
-	if r.store == nil {
-		return aIter, nil
+	// the history has been loaded by the interrupted run, the resumed run is only appended to the thread
+	var th *threadRun
+	if o := getCommonOptions(nil, opts...); o.threadID != nil {
+		th, err = r.newThreadRun(ctx, *o.threadID, nil)
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	niter, gen := NewAsyncIteratorPair[*AgentEvent]()
+	ctx = setRunCtx(ctx, runCtx)
+	ctx = setResumeData(ctx, data)
+	ctx, cancel := r.initRunCtx(ctx)
 
-	go r.handleIter(ctx, aIter, gen, &checkPointID)
-	return niter, nil
+	aIter := toFlowAgent(ctx, r.a).Resume(ctx, info, opts...)
+	iter := r.wrapIter(ctx, aIter, cancel, &checkPointID)
+	if th != nil {
+		iter = th.record(ctx, iter)
+	}
+	return iter, nil
 }
 
-func (r *Runner) handleIter(ctx context.Context, aIter *AsyncIterator[*AgentEvent], gen *AsyncGenerator[*AgentEvent], checkPointID *string) {
+func (r *Runner) handleIter(ctx context.Context, aIter *AsyncIterator[*AgentEvent], gen *AsyncGenerator[*AgentEvent],
+	cancel context.CancelFunc, checkPointID *string) {
 	defer func() {
 		panicErr := recover()
 		if panicErr != nil {
@@ -119,6 +208,7 @@ func (r *Runner) handleIter(ctx context.Context, aIter *AsyncIterator[*AgentEven
 		}
 
 		gen.Close()
+		cancel()
 	}()
 	for {
 		event, ok := aIter.Next()
@@ -131,10 +221,12 @@ func (r *Runner) handleIter(ctx context.Context, aIter *AsyncIterator[*AgentEven
 			if ti, ok := info.Data.(*tempInterruptInfo); ok {
 				// from ChatModelAgent, tempInfo.data for saving and tempInfo.info for user
 				event.Action.Interrupted = &InterruptInfo{
-					Data: ti.info,
+					Data:   ti.info,
+					points: info.points,
 				}
 				info.Data = ti.data
 			}
+			resolveWorkflowTempInterruptInfo(info)
 			if checkPointID != nil {
 				err := saveCheckPoint(ctx, r.store, *checkPointID, getInterruptRunCtx(ctx), info)
 				if err != nil {
@@ -146,3 +238,28 @@ func (r *Runner) handleIter(ctx context.Context, aIter *AsyncIterator[*AgentEven
 		gen.Send(event)
 	}
 }
+
+// resolveWorkflowTempInterruptInfo replaces the temp infos of the sub-agents interrupted in the workflows
+// by the data to save, as the runner does to the temp info of the interrupted root agent.
+func resolveWorkflowTempInterruptInfo(info *InterruptInfo) {
+	wi, ok := info.Data.(*workflowInterruptInfo)
+	if !ok {
+		return
+	}
+
+	resolve := func(sub *InterruptInfo) {
+		if sub == nil {
+			return
+		}
+		if ti, ok := sub.Data.(*tempInterruptInfo); ok {
+			sub.Data = ti.data
+			return
+		}
+		resolveWorkflowTempInterruptInfo(sub)
+	}
+
+	resolve(wi.SequentialInterruptInfo)
+	for _, sub := range wi.ParallelInterruptInfo {
+		resolve(sub)
+	}
+}
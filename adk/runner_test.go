@@ -19,9 +19,11 @@ package adk
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
+	"github.com/cloudwego/eino/compose"
 	"github.com/cloudwego/eino/schema"
 )
 
@@ -261,3 +263,86 @@ func TestRunner_Query_WithStreaming(t *testing.T) {
 	_, ok = iterator.Next()
 	assert.False(t, ok)
 }
+
+type blockingAgent struct {
+	canceled chan struct{}
+}
+
+func (a *blockingAgent) Name(_ context.Context) string {
+	return "blocking"
+}
+
+func (a *blockingAgent) Description(_ context.Context) string {
+	return "blocking agent"
+}
+
+func (a *blockingAgent) Run(ctx context.Context, _ *AgentInput, _ ...AgentRunOption) *AsyncIterator[*AgentEvent] {
+	iterator, generator := NewAsyncIteratorPair[*AgentEvent]()
+	go func() {
+		defer generator.Close()
+		generator.Send(EventFromMessage(schema.AssistantMessage("first", nil), nil, schema.Assistant, ""))
+		generator.Send(EventFromMessage(schema.AssistantMessage("second", nil), nil, schema.Assistant, ""))
+		<-ctx.Done()
+		close(a.canceled)
+	}()
+	return iterator
+}
+
+func TestRunner_Close(t *testing.T) {
+	ctx := context.Background()
+
+	for _, store := range []compose.CheckPointStore{nil, newMyStore()} {
+		a := &blockingAgent{canceled: make(chan struct{})}
+		runner := NewRunner(ctx, RunnerConfig{Agent: a, CheckPointStore: store, EventBufferSize: 1})
+		iter := runner.Query(ctx, "hi")
+
+		event, ok := iter.Next()
+		assert.True(t, ok)
+		assert.Equal(t, "first", event.Output.MessageOutput.Message.Content)
+
+		iter.Close()
+		<-a.canceled
+
+		_, ok = iter.Next()
+		assert.False(t, ok)
+	}
+}
+
+type ctxAgent struct {
+	ctx context.Context
+}
+
+func (a *ctxAgent) Name(_ context.Context) string {
+	return "ctx"
+}
+
+func (a *ctxAgent) Description(_ context.Context) string {
+	return "agent keeping the context of its run"
+}
+
+func (a *ctxAgent) Run(ctx context.Context, _ *AgentInput, _ ...AgentRunOption) *AsyncIterator[*AgentEvent] {
+	a.ctx = ctx
+	iterator, generator := NewAsyncIteratorPair[*AgentEvent]()
+	generator.Send(EventFromMessage(schema.AssistantMessage("done", nil), nil, schema.Assistant, ""))
+	generator.Close()
+	return iterator
+}
+
+func TestRunner_CancelOnEnd(t *testing.T) {
+	ctx := context.Background()
+
+	for _, store := range []compose.CheckPointStore{nil, newMyStore()} {
+		a := &ctxAgent{}
+		runner := NewRunner(ctx, RunnerConfig{Agent: a, CheckPointStore: store})
+		iter := runner.Query(ctx, "hi")
+
+		events := collectEvents(t, iter)
+		assert.Len(t, events, 1)
+
+		select {
+		case <-a.ctx.Done():
+		case <-time.After(time.Second):
+			t.Fatal("context of the run should be canceled when the run ends")
+		}
+	}
+}
@@ -0,0 +1,239 @@
+/*
+ * Copyright 2025 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package server provides a net/http handler which exposes an adk.Runner over HTTP,
+// AgentEvents are streamed to clients as Server-Sent Events in the JSON wire format of adk.EventEncoder.
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/bytedance/sonic"
+
+	"github.com/cloudwego/eino/adk"
+	"github.com/cloudwego/eino/schema"
+)
+
+const (
+	RunPath    = "/run"
+	ResumePath = "/resume"
+
+	// SSEEventDone is the name of the last Server-Sent Event of a run, sent after all AgentEvents have been sent.
+	SSEEventDone = "done"
+)
+
+// RunRequest is the JSON body of run requests.
+// If Messages is empty, Query is used as the user message.
+type RunRequest struct {
+	Messages     []*schema.Message `json:"messages,omitempty"`
+	Query        string            `json:"query,omitempty"`
+	CheckPointID string            `json:"checkpoint_id,omitempty"`
+}
+
+// ResumeRequest is the JSON body of resume requests.
+// ResumeData is keyed by adk.InterruptPoint.ID, see adk.Runner.ResumeWithData.
+type ResumeRequest struct {
+	CheckPointID string         `json:"checkpoint_id"`
+	ResumeData   map[string]any `json:"resume_data,omitempty"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+type HandlerConfig struct {
+	Runner *adk.Runner
+
+	// GenRunOptions generates AgentRunOptions from the HTTP request for both run and resume, optional.
+	GenRunOptions func(ctx context.Context, r *http.Request) ([]adk.AgentRunOption, error)
+}
+
+// Handler serves POST requests of RunPath and ResumePath, any path prefix is allowed.
+// Events are written as Server-Sent Events, whose event name is the frame type and data is the frame,
+// and finally a SSEEventDone event is written.
+// The run is canceled once the client disconnects.
+type Handler struct {
+	runner        *adk.Runner
+	genRunOptions func(ctx context.Context, r *http.Request) ([]adk.AgentRunOption, error)
+}
+
+func NewHandler(_ context.Context, conf *HandlerConfig) (*Handler, error) {
+	if conf == nil || conf.Runner == nil {
+		return nil, errors.New("runner is required")
+	}
+
+	return &Handler{
+		runner:        conf.Runner,
+		genRunOptions: conf.GenRunOptions,
+	}, nil
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case strings.HasSuffix(r.URL.Path, RunPath):
+		h.HandleRun(w, r)
+	case strings.HasSuffix(r.URL.Path, ResumePath):
+		h.HandleResume(w, r)
+	default:
+		writeError(w, http.StatusNotFound, fmt.Errorf("unknown path: %s", r.URL.Path))
+	}
+}
+
+func (h *Handler) HandleRun(w http.ResponseWriter, r *http.Request) {
+	req := &RunRequest{}
+	if !h.decodeRequest(w, r, req) {
+		return
+	}
+
+	messages := req.Messages
+	if len(messages) == 0 {
+		if req.Query == "" {
+			writeError(w, http.StatusBadRequest, errors.New("messages or query is required"))
+			return
+		}
+		messages = []*schema.Message{schema.UserMessage(req.Query)}
+	}
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	opts, ok := h.runOptions(ctx, w, r)
+	if !ok {
+		return
+	}
+	if req.CheckPointID != "" {
+		opts = append(opts, adk.WithCheckPointID(req.CheckPointID))
+	}
+
+	h.stream(w, h.runner.Run(ctx, messages, opts...))
+}
+
+func (h *Handler) HandleResume(w http.ResponseWriter, r *http.Request) {
+	req := &ResumeRequest{}
+	if !h.decodeRequest(w, r, req) {
+		return
+	}
+	if req.CheckPointID == "" {
+		writeError(w, http.StatusBadRequest, errors.New("checkpoint_id is required"))
+		return
+	}
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	opts, ok := h.runOptions(ctx, w, r)
+	if !ok {
+		return
+	}
+
+	iter, err := h.runner.ResumeWithData(ctx, req.CheckPointID, req.ResumeData, opts...)
+	if err != nil {
+		// the request has been validated, the other errors are of the store or the agent
+		code := http.StatusInternalServerError
+		if errors.Is(err, adk.ErrCheckPointNotFound) {
+			code = http.StatusNotFound
+		}
+		writeError(w, code, err)
+		return
+	}
+
+	h.stream(w, iter)
+}
+
+func (h *Handler) decodeRequest(w http.ResponseWriter, r *http.Request, req any) bool {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s is not allowed", r.Method))
+		return false
+	}
+
+	if err := sonic.ConfigDefault.NewDecoder(r.Body).Decode(req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("failed to decode request: %w", err))
+		return false
+	}
+
+	return true
+}
+
+func (h *Handler) runOptions(ctx context.Context, w http.ResponseWriter, r *http.Request) ([]adk.AgentRunOption, bool) {
+	if h.genRunOptions == nil {
+		return nil, true
+	}
+
+	opts, err := h.genRunOptions(ctx, r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return nil, false
+	}
+
+	return opts, true
+}
+
+func (h *Handler) stream(w http.ResponseWriter, iter *adk.AsyncIterator[*adk.AgentEvent]) {
+	flusher, _ := w.(http.Flusher)
+
+	header := w.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	if flusher != nil {
+		flusher.Flush()
+	}
+
+	sw := &sseWriter{w: w, flusher: flusher}
+	enc := adk.NewEventEncoder(sw.write)
+
+	for {
+		event, ok := iter.Next()
+		if !ok {
+			break
+		}
+
+		if err := enc.Encode(event); err != nil {
+			// the client has gone, stop the run and release the pending events
+			iter.Close()
+			return
+		}
+	}
+
+	_ = sw.write(SSEEventDone, []byte("{}"))
+}
+
+type sseWriter struct {
+	w       http.ResponseWriter
+	flusher http.Flusher
+}
+
+func (s *sseWriter) write(event string, data []byte) error {
+	if _, err := fmt.Fprintf(s.w, "event: %s\ndata: %s\n\n", event, data); err != nil {
+		return err
+	}
+	if s.flusher != nil {
+		s.flusher.Flush()
+	}
+	return nil
+}
+
+func writeError(w http.ResponseWriter, code int, err error) {
+	b, _ := sonic.Marshal(&errorResponse{Error: err.Error()})
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_, _ = w.Write(b)
+}
@@ -0,0 +1,232 @@
+/*
+ * Copyright 2025 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package server
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cloudwego/eino/adk"
+	"github.com/cloudwego/eino/schema"
+)
+
+type testAgent struct {
+	run    func(ctx context.Context, input *adk.AgentInput) *adk.AsyncIterator[*adk.AgentEvent]
+	resume func(ctx context.Context, info *adk.ResumeInfo) *adk.AsyncIterator[*adk.AgentEvent]
+}
+
+func (a *testAgent) Name(_ context.Context) string        { return "test" }
+func (a *testAgent) Description(_ context.Context) string { return "test agent" }
+
+func (a *testAgent) Run(ctx context.Context, input *adk.AgentInput, _ ...adk.AgentRunOption) *adk.AsyncIterator[*adk.AgentEvent] {
+	return a.run(ctx, input)
+}
+
+func (a *testAgent) Resume(ctx context.Context, info *adk.ResumeInfo, _ ...adk.AgentRunOption) *adk.AsyncIterator[*adk.AgentEvent] {
+	return a.resume(ctx, info)
+}
+
+type testStore struct {
+	m map[string][]byte
+}
+
+func (s *testStore) Get(_ context.Context, id string) ([]byte, bool, error) {
+	v, ok := s.m[id]
+	return v, ok, nil
+}
+
+func (s *testStore) Set(_ context.Context, id string, v []byte) error {
+	s.m[id] = v
+	return nil
+}
+
+type sseEvent struct {
+	name string
+	data string
+}
+
+func readSSE(t *testing.T, r io.Reader) []sseEvent {
+	var events []sseEvent
+	var cur sseEvent
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "event: "):
+			cur.name = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			cur.data = strings.TrimPrefix(line, "data: ")
+		case line == "":
+			events = append(events, cur)
+			cur = sseEvent{}
+		}
+	}
+	assert.NoError(t, scanner.Err())
+	return events
+}
+
+func decodeSSE(t *testing.T, events []sseEvent) []*adk.AgentEvent {
+	d := adk.NewEventDecoder()
+	var ret []*adk.AgentEvent
+	for _, e := range events {
+		if e.name == SSEEventDone {
+			continue
+		}
+		event, err := d.Decode([]byte(e.data))
+		assert.NoError(t, err)
+		if event != nil {
+			ret = append(ret, event)
+		}
+	}
+	d.Close()
+	return ret
+}
+
+func TestHandlerRunAndResume(t *testing.T) {
+	ctx := context.Background()
+	agent := &testAgent{
+		run: func(ctx context.Context, input *adk.AgentInput) *adk.AsyncIterator[*adk.AgentEvent] {
+			iter, gen := adk.NewAsyncIteratorPair[*adk.AgentEvent]()
+			gen.Send(adk.EventFromMessage(nil, schema.StreamReaderFromArray([]*schema.Message{
+				schema.AssistantMessage("echo: ", nil),
+				schema.AssistantMessage(input.Messages[0].Content, nil),
+			}), schema.Assistant, ""))
+			gen.Send(&adk.AgentEvent{Action: &adk.AgentAction{Interrupted: &adk.InterruptInfo{Data: "approve?"}}})
+			gen.Close()
+			return iter
+		},
+		resume: func(ctx context.Context, info *adk.ResumeInfo) *adk.AsyncIterator[*adk.AgentEvent] {
+			data, _ := adk.GetResumeData(ctx)
+			iter, gen := adk.NewAsyncIteratorPair[*adk.AgentEvent]()
+			gen.Send(adk.EventFromMessage(schema.AssistantMessage(data.(string), nil), nil, schema.Assistant, ""))
+			gen.Close()
+			return iter
+		},
+	}
+	store := &testStore{m: map[string][]byte{"broken": []byte("broken")}}
+	runner := adk.NewRunner(ctx, adk.RunnerConfig{
+		Agent:           agent,
+		EnableStreaming: true,
+		CheckPointStore: store,
+	})
+	h, err := NewHandler(ctx, &HandlerConfig{Runner: runner})
+	assert.NoError(t, err)
+
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+
+	resp, err := http.Post(srv.URL+"/agent"+RunPath, "application/json",
+		strings.NewReader(`{"query":"hello","checkpoint_id":"c1"}`))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+	sse := readSSE(t, resp.Body)
+	_ = resp.Body.Close()
+
+	assert.Equal(t, SSEEventDone, sse[len(sse)-1].name)
+	assert.Equal(t, adk.EventFrameTypeEvent, sse[0].name)
+	assert.Equal(t, adk.EventFrameTypeChunk, sse[1].name)
+	events := decodeSSE(t, sse)
+	assert.Equal(t, 2, len(events))
+	msg, err := events[0].Output.MessageOutput.GetMessage()
+	assert.NoError(t, err)
+	assert.Equal(t, "echo: hello", msg.Content)
+	points := events[1].Action.Interrupted.Points()
+	assert.Equal(t, 1, len(points))
+	assert.Equal(t, "approve?", points[0].Info)
+
+	resp, err = http.Post(srv.URL+ResumePath, "application/json",
+		strings.NewReader(`{"checkpoint_id":"c1","resume_data":{"`+points[0].ID+`":"approved"}}`))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	events = decodeSSE(t, readSSE(t, resp.Body))
+	_ = resp.Body.Close()
+	assert.Equal(t, 1, len(events))
+	assert.Equal(t, "approved", events[0].Output.MessageOutput.Message.Content)
+
+	resp, err = http.Post(srv.URL+ResumePath, "application/json", strings.NewReader(`{"checkpoint_id":"c2"}`))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	_ = resp.Body.Close()
+
+	resp, err = http.Post(srv.URL+ResumePath, "application/json", strings.NewReader(`{"checkpoint_id":"broken"}`))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+	_ = resp.Body.Close()
+
+	resp, err = http.Post(srv.URL+ResumePath, "application/json", strings.NewReader(`{}`))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	_ = resp.Body.Close()
+
+	resp, err = http.Post(srv.URL+RunPath, "application/json", strings.NewReader(`{}`))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	_ = resp.Body.Close()
+
+	resp, err = http.Get(srv.URL + RunPath)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+	_ = resp.Body.Close()
+}
+
+func TestHandlerClientDisconnect(t *testing.T) {
+	ctx := context.Background()
+	canceled := make(chan struct{})
+	agent := &testAgent{
+		run: func(ctx context.Context, input *adk.AgentInput) *adk.AsyncIterator[*adk.AgentEvent] {
+			iter, gen := adk.NewAsyncIteratorPair[*adk.AgentEvent]()
+			go func() {
+				defer gen.Close()
+				gen.Send(adk.EventFromMessage(schema.AssistantMessage("started", nil), nil, schema.Assistant, ""))
+				<-ctx.Done()
+				close(canceled)
+			}()
+			return iter
+		},
+	}
+	runner := adk.NewRunner(ctx, adk.RunnerConfig{Agent: agent})
+	h, err := NewHandler(ctx, &HandlerConfig{Runner: runner})
+	assert.NoError(t, err)
+
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+
+	reqCtx, cancel := context.WithCancel(ctx)
+	req, err := http.NewRequestWithContext(reqCtx, http.MethodPost, srv.URL+RunPath, strings.NewReader(`{"query":"hello"}`))
+	assert.NoError(t, err)
+	resp, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	cancel()
+	_ = resp.Body.Close()
+
+	select {
+	case <-canceled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("run is not canceled after client disconnected")
+	}
+}
@@ -0,0 +1,239 @@
+/*
+ * Copyright 2025 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package adk
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"sync"
+
+	"github.com/cloudwego/eino/internal/safe"
+	"github.com/cloudwego/eino/schema"
+)
+
+// Thread is a persistent conversation of Runner, see WithThreadID.
+type Thread struct {
+	// Messages are the conversation history, which are prepended to the input messages of the next run.
+	Messages []Message
+	// Events are the events of the previous runs, with the message streams concatenated.
+	Events []*AgentEvent
+	// Values are the session values, which are restored before the next run.
+	Values map[string]any
+}
+
+// ThreadStore persists the conversation threads.
+type ThreadStore interface {
+	// Get returns the thread, false if the thread doesn't exist.
+	Get(ctx context.Context, threadID string) (*Thread, bool, error)
+	// Append appends the messages and the events of a run to the thread, creating the thread if it doesn't exist.
+	// The values of run are all the session values after the run, and replace the values of the thread.
+	Append(ctx context.Context, threadID string, run *Thread) error
+}
+
+// ThreadHistoryFilter decides whether the message of the event becomes the history of the thread.
+// The event has a non-streaming MessageOutput.
+type ThreadHistoryFilter func(event *AgentEvent) bool
+
+// ThreadHistoryReplies keeps the assistant messages without tool calls, i.e. the replies of the agents.
+func ThreadHistoryReplies(event *AgentEvent) bool {
+	msg := event.Output.MessageOutput.Message
+	return msg.Role == schema.Assistant && len(msg.ToolCalls) == 0
+}
+
+// ThreadHistoryAll keeps all the messages, including the tool calls and the tool results.
+func ThreadHistoryAll(_ *AgentEvent) bool {
+	return true
+}
+
+// WithThreadID makes Runner load the thread before the run and append the run to it afterward.
+// Requires RunnerConfig.ThreadStore.
+func WithThreadID(id string) AgentRunOption {
+	return WrapImplSpecificOptFn(func(t *options) {
+		t.threadID = &id
+	})
+}
+
+type threadRun struct {
+	id       string
+	store    ThreadStore
+	filter   ThreadHistoryFilter
+	rootName string
+
+	// input messages of the run, excluding the history
+	input []Message
+}
+
+func (r *Runner) newThreadRun(ctx context.Context, threadID string, input []Message) (*threadRun, error) {
+	if r.threadStore == nil {
+		return nil, fmt.Errorf("thread store is nil, cannot run thread %s", threadID)
+	}
+
+	th := &threadRun{
+		id:       threadID,
+		store:    r.threadStore,
+		filter:   r.threadHistoryFilter,
+		rootName: r.a.Name(ctx),
+		input:    input,
+	}
+	if th.filter == nil {
+		th.filter = ThreadHistoryReplies
+	}
+	return th, nil
+}
+
+// load prepends the history of the thread to the input messages, and restores the session values into ctx.
+func (th *threadRun) load(ctx context.Context) ([]Message, error) {
+	thread, existed, err := th.store.Get(ctx, th.id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get thread %s: %w", th.id, err)
+	}
+	if !existed {
+		return th.input, nil
+	}
+
+	for k, v := range thread.Values {
+		SetSessionValue(ctx, k, v)
+	}
+
+	messages := make([]Message, 0, len(thread.Messages)+len(th.input))
+	messages = append(messages, thread.Messages...)
+	messages = append(messages, th.input...)
+	return messages, nil
+}
+
+// record forwards the events, and appends the run to the thread when the run ends without error.
+func (th *threadRun) record(ctx context.Context, iter *AsyncIterator[*AgentEvent]) *AsyncIterator[*AgentEvent] {
+	niter, gen := newEventIteratorPair(ctx)
+	niter.ch.addOnCancel(iter.Close)
+
+	go func() {
+		defer func() {
+			panicErr := recover()
+			if panicErr != nil {
+				e := safe.NewPanicErr(panicErr, debug.Stack())
+				gen.Send(&AgentEvent{Err: e})
+			}
+
+			gen.Close()
+		}()
+
+		run := &Thread{Messages: append([]Message{}, th.input...)}
+		var failed bool
+		for {
+			event, ok := iter.Next()
+			if !ok {
+				break
+			}
+
+			if event.Err != nil {
+				failed = true
+				gen.Send(event)
+				continue
+			}
+
+			recorded := copyAgentEvent(event)
+			gen.Send(event)
+
+			if err := th.materialize(recorded); err != nil {
+				failed = true
+				gen.Send(&AgentEvent{Err: err})
+				continue
+			}
+			run.Events = append(run.Events, recorded)
+
+			if recorded.Output == nil || recorded.Output.MessageOutput == nil || !th.filter(recorded) {
+				continue
+			}
+			msg, err := genMsg(&HistoryEntry{AgentName: recorded.AgentName, Message: recorded.Output.MessageOutput.Message}, th.rootName)
+			if err != nil {
+				failed = true
+				gen.Send(&AgentEvent{Err: err})
+				continue
+			}
+			run.Messages = append(run.Messages, msg)
+		}
+
+		if failed || gen.Canceled() {
+			return
+		}
+
+		run.Values = GetSessionValues(ctx)
+		if err := th.store.Append(ctx, th.id, run); err != nil {
+			gen.Send(&AgentEvent{Err: fmt.Errorf("failed to append to thread %s: %w", th.id, err)})
+		}
+	}()
+
+	return niter
+}
+
+// materialize concatenates the message stream of the event.
+func (th *threadRun) materialize(event *AgentEvent) error {
+	if event.Output == nil || event.Output.MessageOutput == nil || !event.Output.MessageOutput.IsStreaming {
+		return nil
+	}
+
+	mo := event.Output.MessageOutput
+	msg, err := schema.ConcatMessageStream(mo.MessageStream)
+	if err != nil {
+		return err
+	}
+	mo.IsStreaming, mo.Message, mo.MessageStream = false, msg, nil
+	return nil
+}
+
+// NewInMemoryThreadStore returns a ThreadStore keeping the threads in memory.
+func NewInMemoryThreadStore() ThreadStore {
+	return &inMemoryThreadStore{threads: make(map[string]*Thread)}
+}
+
+type inMemoryThreadStore struct {
+	mu      sync.Mutex
+	threads map[string]*Thread
+}
+
+func (s *inMemoryThreadStore) Get(_ context.Context, threadID string) (*Thread, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.threads[threadID]
+	if !ok {
+		return nil, false, nil
+	}
+
+	return &Thread{
+		Messages: append([]Message{}, t.Messages...),
+		Events:   append([]*AgentEvent{}, t.Events...),
+		Values:   copyMap(t.Values),
+	}, true, nil
+}
+
+func (s *inMemoryThreadStore) Append(_ context.Context, threadID string, run *Thread) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.threads[threadID]
+	if !ok {
+		t = &Thread{}
+		s.threads[threadID] = t
+	}
+
+	t.Messages = append(t.Messages, run.Messages...)
+	t.Events = append(t.Events, run.Events...)
+	t.Values = copyMap(run.Values)
+	return nil
+}
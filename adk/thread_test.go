@@ -0,0 +1,121 @@
+/*
+ * Copyright 2025 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package adk
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+// threadTestAgent calls a tool, then replies with the number of the input messages in a stream,
+// and counts its runs in the session values.
+type threadTestAgent struct {
+	inputs [][]Message
+}
+
+func (a *threadTestAgent) Name(_ context.Context) string {
+	return "chat"
+}
+
+func (a *threadTestAgent) Description(_ context.Context) string {
+	return "chat agent"
+}
+
+func (a *threadTestAgent) Run(ctx context.Context, input *AgentInput, _ ...AgentRunOption) *AsyncIterator[*AgentEvent] {
+	a.inputs = append(a.inputs, input.Messages)
+
+	turns, _ := GetSessionValue(ctx, "turns")
+	n, _ := turns.(int)
+	SetSessionValue(ctx, "turns", n+1)
+
+	iter, gen := NewAsyncIteratorPair[*AgentEvent]()
+	go func() {
+		defer gen.Close()
+		gen.Send(EventFromMessage(toolCallMessage("1", "clock", "{}"), nil, schema.Assistant, ""))
+		gen.Send(EventFromMessage(schema.ToolMessage("12:00", "1"), nil, schema.Tool, "clock"))
+
+		sr, sw := schema.Pipe[Message](2)
+		go func() {
+			defer sw.Close()
+			sw.Send(schema.AssistantMessage("seen ", nil), nil)
+			sw.Send(schema.AssistantMessage(fmt.Sprintf("%d", len(input.Messages)), nil), nil)
+		}()
+		gen.Send(EventFromMessage(nil, sr, schema.Assistant, ""))
+	}()
+	return iter
+}
+
+func TestRunnerThread(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("replies as history", func(t *testing.T) {
+		store := NewInMemoryThreadStore()
+		a := &threadTestAgent{}
+		r := NewRunner(ctx, RunnerConfig{Agent: a, EnableStreaming: true, ThreadStore: store})
+
+		events := collectEvents(t, r.Query(ctx, "hi", WithThreadID("t1")))
+		assert.Len(t, events, 3)
+		msg, err := events[2].Output.MessageOutput.GetMessage()
+		assert.NoError(t, err)
+		assert.Equal(t, "seen 1", msg.Content)
+
+		collectEvents(t, r.Query(ctx, "again", WithThreadID("t1")))
+		assert.Equal(t, []Message{
+			schema.UserMessage("hi"),
+			schema.AssistantMessage("seen 1", nil),
+			schema.UserMessage("again"),
+		}, a.inputs[1])
+
+		thread, ok, err := store.Get(ctx, "t1")
+		assert.NoError(t, err)
+		assert.True(t, ok)
+		assert.Len(t, thread.Messages, 4)
+		assert.Len(t, thread.Events, 6)
+		assert.False(t, thread.Events[2].Output.MessageOutput.IsStreaming)
+		assert.Equal(t, "seen 3", thread.Events[5].Output.MessageOutput.Message.Content)
+		assert.Equal(t, map[string]any{"turns": 2}, thread.Values)
+
+		// other threads are independent
+		collectEvents(t, r.Query(ctx, "hello", WithThreadID("t2")))
+		assert.Equal(t, []Message{schema.UserMessage("hello")}, a.inputs[2])
+	})
+
+	t.Run("all messages as history", func(t *testing.T) {
+		a := &threadTestAgent{}
+		r := NewRunner(ctx, RunnerConfig{Agent: a, ThreadStore: NewInMemoryThreadStore(), ThreadHistoryFilter: ThreadHistoryAll})
+
+		collectEvents(t, r.Query(ctx, "hi", WithThreadID("t1")))
+		collectEvents(t, r.Query(ctx, "again", WithThreadID("t1")))
+		assert.Len(t, a.inputs[1], 5)
+		assert.Len(t, a.inputs[1][1].ToolCalls, 1)
+		assert.Equal(t, schema.Tool, a.inputs[1][2].Role)
+	})
+
+	t.Run("no thread store", func(t *testing.T) {
+		r := NewRunner(ctx, RunnerConfig{Agent: &threadTestAgent{}})
+
+		iter := r.Query(ctx, "hi", WithThreadID("t1"))
+		event, ok := iter.Next()
+		assert.True(t, ok)
+		assert.ErrorContains(t, event.Err, "thread store is nil")
+	})
+}
@@ -0,0 +1,158 @@
+/*
+ * Copyright 2025 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package adk
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/compose"
+	mockModel "github.com/cloudwego/eino/internal/mock/components/model"
+	"github.com/cloudwego/eino/schema"
+)
+
+func TestToolCallLoop(t *testing.T) {
+	ctx := context.Background()
+
+	// run gives the responses by respond until it returns nil, then the final answer
+	run := func(t *testing.T, conf *ToolCallLoopConfig,
+		respond func(i int, input []Message, opts *model.Options) Message) (events []*AgentEvent, loops []*ToolCallLoop, ft *fakeToolForTest) {
+
+		cm := mockModel.NewMockToolCallingChatModel(gomock.NewController(t))
+		cm.EXPECT().WithTools(gomock.Any()).Return(cm, nil).AnyTimes()
+		i := 0
+		cm.EXPECT().Generate(gomock.Any(), gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+				i++
+				if msg := respond(i, input, model.GetCommonOptions(nil, opts...)); msg != nil {
+					return msg, nil
+				}
+				return schema.AssistantMessage("final", nil), nil
+			}).AnyTimes()
+
+		conf.OnDetected = func(ctx context.Context, loop *ToolCallLoop) {
+			loops = append(loops, loop)
+		}
+		ft = &fakeToolForTest{tarCount: 100}
+		a, err := NewChatModelAgent(ctx, &ChatModelAgentConfig{
+			Name:         "looper",
+			Description:  "looper",
+			Model:        cm,
+			ToolsConfig:  ToolsConfig{ToolsNodeConfig: compose.ToolsNodeConfig{Tools: []tool.BaseTool{ft}}},
+			ToolCallLoop: conf,
+		})
+		assert.NoError(t, err)
+
+		iter := NewRunner(ctx, RunnerConfig{Agent: a}).Query(ctx, "hi")
+		for {
+			event, ok := iter.Next()
+			if !ok {
+				return events, loops, ft
+			}
+			events = append(events, event)
+		}
+	}
+
+	sameCall := toolCallMessage("1", "test_tool", `{"name": "tom"}`)
+
+	t.Run("correct", func(t *testing.T) {
+		events, loops, ft := run(t, &ToolCallLoopConfig{}, func(i int, input []Message, opts *model.Options) Message {
+			if i <= 3 {
+				return sameCall
+			}
+			assert.Equal(t, schema.User, input[len(input)-1].Role)
+			assert.Contains(t, input[len(input)-1].Content, "repeating the same tool calls")
+			assert.Nil(t, opts.ToolChoice)
+			return nil
+		})
+		assert.Equal(t, 3, ft.curCount)
+		assert.Equal(t, []*ToolCallLoop{{
+			AgentName: "looper",
+			Pattern:   []string{`test_tool({"name":"tom"})`},
+			Repeats:   3,
+			Reaction:  ToolCallLoopCorrect,
+		}}, loops)
+		assert.NoError(t, events[len(events)-1].Err)
+		assert.Equal(t, "final", events[len(events)-1].Output.MessageOutput.Message.Content)
+	})
+
+	t.Run("final answer", func(t *testing.T) {
+		events, loops, _ := run(t, &ToolCallLoopConfig{Reaction: ToolCallLoopFinalAnswer, Message: "answer now"},
+			func(i int, input []Message, opts *model.Options) Message {
+				if i <= 3 {
+					assert.Nil(t, opts.ToolChoice)
+					return sameCall
+				}
+				assert.Equal(t, "answer now", input[len(input)-1].Content)
+				assert.Equal(t, schema.ToolChoiceForbidden, *opts.ToolChoice)
+				return nil
+			})
+		assert.Len(t, loops, 1)
+		assert.Equal(t, "final", events[len(events)-1].Output.MessageOutput.Message.Content)
+	})
+
+	t.Run("final answer ignored", func(t *testing.T) {
+		events, _, _ := run(t, &ToolCallLoopConfig{Reaction: ToolCallLoopFinalAnswer}, func(i int, input []Message, opts *model.Options) Message {
+			return sameCall
+		})
+		var loopErr *ToolCallLoopError
+		assert.True(t, errors.As(events[len(events)-1].Err, &loopErr))
+		assert.Equal(t, ToolCallLoopFinalAnswer, loopErr.Loop.Reaction)
+	})
+
+	t.Run("fail on oscillation", func(t *testing.T) {
+		events, loops, ft := run(t, &ToolCallLoopConfig{Reaction: ToolCallLoopFail}, func(i int, input []Message, opts *model.Options) Message {
+			if i%2 == 1 {
+				return toolCallMessage("1", "test_tool", `{"name": "a"}`)
+			}
+			return toolCallMessage("1", "test_tool", `{"name":"b"}`)
+		})
+		var loopErr *ToolCallLoopError
+		assert.True(t, errors.As(events[len(events)-1].Err, &loopErr))
+		assert.Equal(t, []string{`test_tool({"name":"a"})`, `test_tool({"name":"b"})`}, loopErr.Loop.Pattern)
+		assert.Equal(t, loops[0], loopErr.Loop)
+		// the tools of the last step aren't run
+		assert.Equal(t, 5, ft.curCount)
+	})
+
+	t.Run("no loop", func(t *testing.T) {
+		events, loops, _ := run(t, &ToolCallLoopConfig{}, func(i int, input []Message, opts *model.Options) Message {
+			if i <= 2 {
+				return sameCall
+			}
+			return nil
+		})
+		assert.Empty(t, loops)
+		assert.Equal(t, "final", events[len(events)-1].Output.MessageOutput.Message.Content)
+	})
+
+	t.Run("config", func(t *testing.T) {
+		_, err := NewChatModelAgent(ctx, &ChatModelAgentConfig{
+			Name:         "looper",
+			Description:  "looper",
+			Model:        mockModel.NewMockToolCallingChatModel(gomock.NewController(t)),
+			ToolCallLoop: &ToolCallLoopConfig{Reaction: "retry"},
+		})
+		assert.Error(t, err)
+	})
+}
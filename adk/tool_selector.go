@@ -0,0 +1,299 @@
+/*
+ * Copyright 2025 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package adk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+
+	"github.com/cloudwego/eino/callbacks"
+	"github.com/cloudwego/eino/components"
+	"github.com/cloudwego/eino/components/embedding"
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+)
+
+// ComponentOfToolSelector is the component in the RunInfo of the callbacks reporting the tool selections.
+// The callback input is *ToolSelectionCallbackInput, and the callback output is *ToolSelectionCallbackOutput.
+const ComponentOfToolSelector components.Component = "ToolSelector"
+
+// ToolSelector picks the tools bound to the model for each model step of ChatModelAgent, see ToolsConfig.ToolSelector.
+type ToolSelector interface {
+	// Select returns the tools to bind from the candidates, according to the messages about to be sent to the model.
+	Select(ctx context.Context, candidates []*schema.ToolInfo, messages []Message) ([]*schema.ToolInfo, error)
+}
+
+type ToolSelectionCallbackInput struct {
+	Candidates []*schema.ToolInfo
+	Messages   []Message
+}
+
+type ToolSelectionCallbackOutput struct {
+	Selected []*schema.ToolInfo
+}
+
+// toolSelectingChatModel binds the tools chosen by the selector to the model before each call.
+// The pinned tools, i.e. the tools added by the agent itself such as transfer_to_agent, are always bound.
+type toolSelectingChatModel struct {
+	inner      model.ToolCallingChatModel
+	selector   ToolSelector
+	candidates []*schema.ToolInfo
+	pinned     []*schema.ToolInfo
+}
+
+func newToolSelectingChatModel(cm model.ToolCallingChatModel, selector ToolSelector,
+	tools []*schema.ToolInfo, pinned map[string]bool) *toolSelectingChatModel {
+
+	m := &toolSelectingChatModel{inner: cm, selector: selector}
+	for _, t := range tools {
+		if pinned[t.Name] {
+			m.pinned = append(m.pinned, t)
+		} else {
+			m.candidates = append(m.candidates, t)
+		}
+	}
+	return m
+}
+
+func (m *toolSelectingChatModel) GetType() string {
+	typ, _ := components.GetType(m.inner)
+	return typ
+}
+
+func (m *toolSelectingChatModel) IsCallbacksEnabled() bool {
+	return components.IsCallbacksEnabled(m.inner)
+}
+
+func (m *toolSelectingChatModel) Generate(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+	cm, err := m.bind(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	return cm.Generate(ctx, input, opts...)
+}
+
+func (m *toolSelectingChatModel) Stream(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	cm, err := m.bind(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	return cm.Stream(ctx, input, opts...)
+}
+
+func (m *toolSelectingChatModel) bind(ctx context.Context, input []Message) (model.BaseChatModel, error) {
+	selected, err := m.selectTools(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	tools := make([]*schema.ToolInfo, 0, len(selected)+len(m.pinned))
+	tools = append(tools, selected...)
+	tools = append(tools, m.pinned...)
+	if len(tools) == 0 {
+		return m.inner, nil
+	}
+
+	return m.inner.WithTools(tools)
+}
+
+func (m *toolSelectingChatModel) selectTools(ctx context.Context, input []Message) (selected []*schema.ToolInfo, err error) {
+	if len(m.candidates) == 0 {
+		return nil, nil
+	}
+
+	typ, ok := components.GetType(m.selector)
+	if !ok {
+		typ = string(ComponentOfToolSelector)
+	}
+	ctx = callbacks.ReuseHandlers(ctx, &callbacks.RunInfo{Name: typ, Type: typ, Component: ComponentOfToolSelector})
+	ctx = callbacks.OnStart(ctx, &ToolSelectionCallbackInput{Candidates: m.candidates, Messages: input})
+	defer func() {
+		if err != nil {
+			callbacks.OnError(ctx, err)
+			return
+		}
+		callbacks.OnEnd(ctx, &ToolSelectionCallbackOutput{Selected: selected})
+	}()
+
+	selected, err = m.selector.Select(ctx, m.candidates, input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to select tools: %w", err)
+	}
+	return selected, nil
+}
+
+const defaultToolSelectorTopK = 5
+
+type EmbeddingToolSelectorConfig struct {
+	// Embedder embeds the tools and the messages, required.
+	Embedder embedding.Embedder
+
+	// TopK is the max number of the selected tools, optional, defaults to 5.
+	TopK int
+
+	// ToolText is the text of the tool to embed, optional, defaults to "<name>: <description>".
+	ToolText func(info *schema.ToolInfo) string
+}
+
+// NewEmbeddingToolSelector creates a ToolSelector which selects the top-k tools most similar to the latest
+// user or assistant message with content, by the cosine similarity of their embeddings.
+// The embeddings of the tools are cached by their text, so each tool is embedded only once.
+func NewEmbeddingToolSelector(_ context.Context, conf *EmbeddingToolSelectorConfig) (ToolSelector, error) {
+	if conf.Embedder == nil {
+		return nil, errors.New("'Embedder' is required")
+	}
+
+	s := &embeddingToolSelector{
+		embedder: conf.Embedder,
+		topK:     conf.TopK,
+		toolText: conf.ToolText,
+		cache:    make(map[string][]float64),
+	}
+	if s.topK <= 0 {
+		s.topK = defaultToolSelectorTopK
+	}
+	if s.toolText == nil {
+		s.toolText = func(info *schema.ToolInfo) string {
+			return info.Name + ": " + info.Desc
+		}
+	}
+	return s, nil
+}
+
+type embeddingToolSelector struct {
+	embedder embedding.Embedder
+	topK     int
+	toolText func(info *schema.ToolInfo) string
+
+	mu    sync.Mutex
+	cache map[string][]float64
+}
+
+func (s *embeddingToolSelector) GetType() string {
+	return "Embedding"
+}
+
+func (s *embeddingToolSelector) Select(ctx context.Context, candidates []*schema.ToolInfo, messages []Message) ([]*schema.ToolInfo, error) {
+	if len(candidates) <= s.topK {
+		return candidates, nil
+	}
+
+	query := latestQuery(messages)
+	if query == "" {
+		return candidates[:s.topK], nil
+	}
+
+	toolVectors, err := s.embedTools(ctx, candidates)
+	if err != nil {
+		return nil, err
+	}
+	vectors, err := s.embedder.EmbedStrings(ctx, []string{query})
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed query: %w", err)
+	}
+	if len(vectors) != 1 {
+		return nil, fmt.Errorf("embedder returns %d vectors for 1 query", len(vectors))
+	}
+
+	scores := make([]float64, len(candidates))
+	indexes := make([]int, len(candidates))
+	for i := range candidates {
+		scores[i] = cosineSimilarity(vectors[0], toolVectors[i])
+		indexes[i] = i
+	}
+	sort.SliceStable(indexes, func(i, j int) bool {
+		return scores[indexes[i]] > scores[indexes[j]]
+	})
+
+	selected := make([]*schema.ToolInfo, s.topK)
+	for i := range selected {
+		selected[i] = candidates[indexes[i]]
+	}
+	return selected, nil
+}
+
+// embedTools returns the embeddings of the tools, embedding the ones missing from the cache in one batch.
+func (s *embeddingToolSelector) embedTools(ctx context.Context, tools []*schema.ToolInfo) ([][]float64, error) {
+	texts := make([]string, len(tools))
+	for i, t := range tools {
+		texts[i] = s.toolText(t)
+	}
+
+	s.mu.Lock()
+	var missing []string
+	for _, text := range texts {
+		if _, ok := s.cache[text]; !ok {
+			missing = append(missing, text)
+		}
+	}
+	s.mu.Unlock()
+
+	if len(missing) > 0 {
+		vectors, err := s.embedder.EmbedStrings(ctx, missing)
+		if err != nil {
+			return nil, fmt.Errorf("failed to embed tools: %w", err)
+		}
+		if len(vectors) != len(missing) {
+			return nil, fmt.Errorf("embedder returns %d vectors for %d tools", len(vectors), len(missing))
+		}
+
+		s.mu.Lock()
+		for i, text := range missing {
+			s.cache[text] = vectors[i]
+		}
+		s.mu.Unlock()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	vectors := make([][]float64, len(texts))
+	for i, text := range texts {
+		vectors[i] = s.cache[text]
+	}
+	return vectors, nil
+}
+
+func latestQuery(messages []Message) string {
+	for i := len(messages) - 1; i >= 0; i-- {
+		msg := messages[i]
+		if (msg.Role == schema.User || msg.Role == schema.Assistant) && msg.Content != "" {
+			return msg.Content
+		}
+	}
+	return ""
+}
+
+func cosineSimilarity(a, b []float64) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+
+	var dot, na, nb float64
+	for i := range a {
+		dot += a[i] * b[i]
+		na += a[i] * a[i]
+		nb += b[i] * b[i]
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
@@ -0,0 +1,183 @@
+/*
+ * Copyright 2025 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package adk
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"github.com/cloudwego/eino/callbacks"
+	"github.com/cloudwego/eino/components/embedding"
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/compose"
+	mockModel "github.com/cloudwego/eino/internal/mock/components/model"
+	"github.com/cloudwego/eino/schema"
+)
+
+// keywordEmbedder embeds the texts by counting the keywords.
+type keywordEmbedder struct {
+	embedded []string
+}
+
+var embedKeywords = []string{"weather", "stock", "email", "calendar"}
+
+func (e *keywordEmbedder) EmbedStrings(_ context.Context, texts []string, _ ...embedding.Option) ([][]float64, error) {
+	vectors := make([][]float64, len(texts))
+	for i, text := range texts {
+		e.embedded = append(e.embedded, text)
+		vectors[i] = make([]float64, len(embedKeywords))
+		for j, kw := range embedKeywords {
+			vectors[i][j] = float64(strings.Count(text, kw))
+		}
+	}
+	return vectors, nil
+}
+
+type namedToolForTest struct {
+	name, desc string
+}
+
+func (t *namedToolForTest) Info(_ context.Context) (*schema.ToolInfo, error) {
+	return &schema.ToolInfo{Name: t.name, Desc: t.desc}, nil
+}
+
+func (t *namedToolForTest) InvokableRun(_ context.Context, _ string, _ ...tool.Option) (string, error) {
+	return t.name + " done", nil
+}
+
+func TestEmbeddingToolSelector(t *testing.T) {
+	ctx := context.Background()
+
+	emb := &keywordEmbedder{}
+	selector, err := NewEmbeddingToolSelector(ctx, &EmbeddingToolSelectorConfig{Embedder: emb, TopK: 2})
+	assert.NoError(t, err)
+
+	candidates := []*schema.ToolInfo{
+		{Name: "get_weather", Desc: "weather forecast"},
+		{Name: "get_stock", Desc: "stock price"},
+		{Name: "send_email", Desc: "send email"},
+		{Name: "add_event", Desc: "add calendar event"},
+	}
+	names := func(tools []*schema.ToolInfo) []string {
+		var ns []string
+		for _, t := range tools {
+			ns = append(ns, t.Name)
+		}
+		return ns
+	}
+
+	selected, err := selector.Select(ctx, candidates, []Message{
+		schema.UserMessage("email me the stock price"),
+		toolCallMessage("1", "get_stock", "{}"),
+		schema.ToolMessage("100", "1"),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"get_stock", "send_email"}, names(selected))
+
+	selected, err = selector.Select(ctx, candidates, []Message{
+		schema.UserMessage("email me the stock price"),
+		schema.AssistantMessage("sent, anything about the weather or the calendar weather?", nil),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"get_weather", "add_event"}, names(selected))
+
+	// the tools are embedded once, and then only the queries
+	assert.Equal(t, 4+2, len(emb.embedded))
+
+	// no query
+	selected, err = selector.Select(ctx, candidates, []Message{schema.SystemMessage("you are a helpful assistant")})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"get_weather", "get_stock"}, names(selected))
+}
+
+func TestChatModelAgentToolSelector(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	cm := mockModel.NewMockToolCallingChatModel(ctrl)
+
+	var bound [][]string
+	cm.EXPECT().WithTools(gomock.Any()).DoAndReturn(func(tools []*schema.ToolInfo) (model.ToolCallingChatModel, error) {
+		var ns []string
+		for _, t := range tools {
+			ns = append(ns, t.Name)
+		}
+		bound = append(bound, ns)
+		return cm, nil
+	}).AnyTimes()
+	gomock.InOrder(
+		cm.EXPECT().Generate(gomock.Any(), gomock.Any(), gomock.Any()).
+			Return(toolCallMessage("1", "get_weather", "{}"), nil),
+		cm.EXPECT().Generate(gomock.Any(), gomock.Any(), gomock.Any()).
+			Return(schema.AssistantMessage("sunny", nil), nil),
+	)
+
+	selector, err := NewEmbeddingToolSelector(ctx, &EmbeddingToolSelectorConfig{Embedder: &keywordEmbedder{}, TopK: 1})
+	assert.NoError(t, err)
+
+	a, err := NewChatModelAgent(ctx, &ChatModelAgentConfig{
+		Name:        "agent",
+		Description: "agent",
+		Model:       cm,
+		ToolsConfig: ToolsConfig{
+			ToolsNodeConfig: compose.ToolsNodeConfig{Tools: []tool.BaseTool{
+				&namedToolForTest{name: "get_stock", desc: "stock price"},
+				&namedToolForTest{name: "get_weather", desc: "weather forecast"},
+			}},
+			ToolSelector: selector,
+		},
+		Exit: &ExitTool{},
+	})
+	assert.NoError(t, err)
+
+	events := collectEvents(t, NewRunner(ctx, RunnerConfig{Agent: a}).Query(ctx, "how is the weather"))
+	assert.Equal(t, "get_weather done", events[1].Output.MessageOutput.Message.Content)
+	assert.Equal(t, "sunny", events[2].Output.MessageOutput.Message.Content)
+	assert.Equal(t, [][]string{{"get_weather", "exit"}, {"get_weather", "exit"}}, bound)
+}
+
+func TestToolSelectionCallbacks(t *testing.T) {
+	var reported *ToolSelectionCallbackOutput
+	handler := callbacks.NewHandlerBuilder().OnEndFn(func(ctx context.Context, info *callbacks.RunInfo, output callbacks.CallbackOutput) context.Context {
+		if info.Component == ComponentOfToolSelector {
+			assert.Equal(t, "Embedding", info.Type)
+			reported = output.(*ToolSelectionCallbackOutput)
+		}
+		return ctx
+	}).Build()
+	ctx := callbacks.InitCallbacks(context.Background(), &callbacks.RunInfo{}, handler)
+
+	ctrl := gomock.NewController(t)
+	cm := mockModel.NewMockToolCallingChatModel(ctrl)
+	cm.EXPECT().WithTools(gomock.Any()).Return(cm, nil)
+	cm.EXPECT().Generate(gomock.Any(), gomock.Any(), gomock.Any()).Return(schema.AssistantMessage("ok", nil), nil)
+
+	selector, err := NewEmbeddingToolSelector(ctx, &EmbeddingToolSelectorConfig{Embedder: &keywordEmbedder{}, TopK: 1})
+	assert.NoError(t, err)
+	m := newToolSelectingChatModel(cm, selector, []*schema.ToolInfo{
+		{Name: "send_email", Desc: "send email"},
+		{Name: "get_stock", Desc: "stock price"},
+	}, nil)
+
+	_, err = m.Generate(ctx, []Message{schema.UserMessage("stock")})
+	assert.NoError(t, err)
+	assert.Equal(t, &ToolSelectionCallbackOutput{Selected: []*schema.ToolInfo{{Name: "get_stock", Desc: "stock price"}}}, reported)
+}
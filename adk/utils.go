@@ -21,36 +21,224 @@ import (
 	"errors"
 	"io"
 	"strings"
+	"sync"
 
-	"github.com/cloudwego/eino/internal"
 	"github.com/cloudwego/eino/schema"
 )
 
 type AsyncIterator[T any] struct {
-	ch *internal.UnboundedChan[T]
+	ch *asyncChan[T]
 }
 
+// Next returns the next value, false if the generator is closed and all values are received, or the iterator is closed.
 func (ai *AsyncIterator[T]) Next() (T, bool) {
-	return ai.ch.Receive()
+	return ai.ch.receive()
+}
+
+// Close stops receiving values from the iterator. The pending and later sent values are discarded,
+// and the message streams in the discarded AgentEvents are closed.
+// For the iterators returned by Runner, Close also cancels the context of the run, so that the agents stop producing.
+func (ai *AsyncIterator[T]) Close() {
+	ai.ch.cancel()
 }
 
 type AsyncGenerator[T any] struct {
-	ch *internal.UnboundedChan[T]
+	ch *asyncChan[T]
 }
 
+// Send sends the value to the iterator. It blocks if the iterator is bounded and its buffer is full.
+// The value is discarded if the iterator has been closed.
 func (ag *AsyncGenerator[T]) Send(v T) {
-	ag.ch.Send(v)
+	ag.ch.send(v)
 }
 
+// Close marks the end of values.
 func (ag *AsyncGenerator[T]) Close() {
-	ag.ch.Close()
+	ag.ch.close()
+}
+
+// Canceled reports whether the iterator has been closed by the consumer, so the producer can stop early.
+func (ag *AsyncGenerator[T]) Canceled() bool {
+	return ag.ch.isCanceled()
 }
 
+// NewAsyncIteratorPair returns an iterator with unbounded buffer, and the generator to send values to it.
 func NewAsyncIteratorPair[T any]() (*AsyncIterator[T], *AsyncGenerator[T]) {
-	ch := internal.NewUnboundedChan[T]()
+	return NewBoundedAsyncIteratorPair[T](0)
+}
+
+// NewBoundedAsyncIteratorPair returns an iterator that buffers at most capacity values, and the generator to send values to it.
+// Send of the generator blocks while the buffer is full, which applies backpressure to the producer.
+// Capacity <= 0 means unbounded.
+func NewBoundedAsyncIteratorPair[T any](capacity int) (*AsyncIterator[T], *AsyncGenerator[T]) {
+	ch := newAsyncChan[T](capacity)
 	return &AsyncIterator[T]{ch}, &AsyncGenerator[T]{ch}
 }
 
+type eventBufferSizeKey struct{}
+
+func withEventBufferSize(ctx context.Context, size int) context.Context {
+	if size <= 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, eventBufferSizeKey{}, size)
+}
+
+// newEventIteratorPair returns the iterator pair for the events of the agents, bounded by RunnerConfig.EventBufferSize.
+func newEventIteratorPair(ctx context.Context) (*AsyncIterator[*AgentEvent], *AsyncGenerator[*AgentEvent]) {
+	size, _ := ctx.Value(eventBufferSizeKey{}).(int)
+	return NewBoundedAsyncIteratorPair[*AgentEvent](size)
+}
+
+type asyncChan[T any] struct {
+	buffer   []T
+	capacity int
+
+	mu       sync.Mutex
+	notEmpty *sync.Cond
+	notFull  *sync.Cond
+	closed   bool
+	canceled bool
+
+	onCancel []func()
+	onDrain  []func()
+}
+
+func newAsyncChan[T any](capacity int) *asyncChan[T] {
+	ch := &asyncChan[T]{capacity: capacity}
+	ch.notEmpty = sync.NewCond(&ch.mu)
+	ch.notFull = sync.NewCond(&ch.mu)
+	return ch
+}
+
+func (ch *asyncChan[T]) send(v T) {
+	ch.mu.Lock()
+
+	if ch.closed {
+		ch.mu.Unlock()
+		panic("send on closed channel")
+	}
+
+	for ch.capacity > 0 && len(ch.buffer) >= ch.capacity && !ch.canceled {
+		ch.notFull.Wait()
+	}
+
+	if ch.canceled {
+		ch.mu.Unlock()
+		discard(v)
+		return
+	}
+
+	ch.buffer = append(ch.buffer, v)
+	ch.notEmpty.Signal()
+	ch.mu.Unlock()
+}
+
+func (ch *asyncChan[T]) receive() (T, bool) {
+	ch.mu.Lock()
+
+	for len(ch.buffer) == 0 && !ch.closed && !ch.canceled {
+		ch.notEmpty.Wait()
+	}
+
+	var zero T
+	if len(ch.buffer) == 0 || ch.canceled {
+		var onDrain []func()
+		if !ch.canceled {
+			onDrain = ch.onDrain
+			ch.onDrain = nil
+		}
+		ch.mu.Unlock()
+
+		for _, f := range onDrain {
+			f()
+		}
+		return zero, false
+	}
+
+	v := ch.buffer[0]
+	ch.buffer[0] = zero
+	ch.buffer = ch.buffer[1:]
+	ch.notFull.Signal()
+	ch.mu.Unlock()
+	return v, true
+}
+
+func (ch *asyncChan[T]) close() {
+	ch.mu.Lock()
+	defer ch.mu.Unlock()
+
+	if !ch.closed {
+		ch.closed = true
+		ch.notEmpty.Broadcast()
+	}
+}
+
+func (ch *asyncChan[T]) cancel() {
+	ch.mu.Lock()
+	if ch.canceled {
+		ch.mu.Unlock()
+		return
+	}
+	ch.canceled = true
+	pending := ch.buffer
+	ch.buffer = nil
+	onCancel := ch.onCancel
+	ch.onCancel = nil
+	ch.notEmpty.Broadcast()
+	ch.notFull.Broadcast()
+	ch.mu.Unlock()
+
+	for _, v := range pending {
+		discard(v)
+	}
+	for _, f := range onCancel {
+		f()
+	}
+}
+
+func (ch *asyncChan[T]) isCanceled() bool {
+	ch.mu.Lock()
+	defer ch.mu.Unlock()
+	return ch.canceled
+}
+
+// addOnCancel registers f to be called when the iterator is closed by the consumer, f is called at once if it has been closed.
+func (ch *asyncChan[T]) addOnCancel(f func()) {
+	ch.mu.Lock()
+	if !ch.canceled {
+		ch.onCancel = append(ch.onCancel, f)
+		ch.mu.Unlock()
+		return
+	}
+	ch.mu.Unlock()
+	f()
+}
+
+// addOnDrain registers f to be called when all values are received after the generator is closed,
+// f is called at once if they have been received.
+func (ch *asyncChan[T]) addOnDrain(f func()) {
+	ch.mu.Lock()
+	if !ch.closed || len(ch.buffer) > 0 {
+		ch.onDrain = append(ch.onDrain, f)
+		ch.mu.Unlock()
+		return
+	}
+	ch.mu.Unlock()
+	f()
+}
+
+// discard releases the resources held by the value which will never be received.
+func discard(v any) {
+	e, ok := v.(*AgentEvent)
+	if !ok || e == nil || e.Output == nil || e.Output.MessageOutput == nil {
+		return
+	}
+	if mo := e.Output.MessageOutput; mo.IsStreaming && mo.MessageStream != nil {
+		mo.MessageStream.Close()
+	}
+}
+
 func copyMap[K comparable, V any](m map[K]V) map[K]V {
 	res := make(map[K]V, len(m))
 	for k, v := range m {
@@ -20,6 +20,8 @@ import (
 	"sync"
 	"testing"
 	"time"
+
+	"github.com/cloudwego/eino/schema"
 )
 
 func TestAsyncIteratorPair_Basic(t *testing.T) {
@@ -153,3 +155,70 @@ func TestAsyncIteratorPair_Concurrency(t *testing.T) {
 		t.Error("duplicate or missing messages detected")
 	}
 }
+
+func TestAsyncIteratorPair_Bounded(t *testing.T) {
+	iterator, generator := NewBoundedAsyncIteratorPair[int](1)
+
+	sent := make(chan int, 3)
+	go func() {
+		for i := 0; i < 3; i++ {
+			generator.Send(i)
+			sent <- i
+		}
+		generator.Close()
+	}()
+
+	<-sent
+	select {
+	case <-sent:
+		t.Fatal("send should block while the buffer is full")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	for i := 0; i < 3; i++ {
+		val, ok := iterator.Next()
+		if !ok || val != i {
+			t.Errorf("expected %d, got %d, %v", i, val, ok)
+		}
+	}
+	_, ok := iterator.Next()
+	if ok {
+		t.Error("receive from closed, empty channel should return ok=false")
+	}
+}
+
+func TestAsyncIteratorPair_ConsumerClose(t *testing.T) {
+	iterator, generator := NewBoundedAsyncIteratorPair[*AgentEvent](1)
+
+	sr, sw := schema.Pipe[Message](1)
+	generator.Send(EventFromMessage(nil, sr, schema.Assistant, ""))
+
+	blocked := make(chan struct{})
+	go func() {
+		// blocks on the full buffer until the iterator is closed
+		generator.Send(&AgentEvent{})
+		close(blocked)
+	}()
+
+	canceled := make(chan struct{})
+	iterator.ch.addOnCancel(func() { close(canceled) })
+	iterator.Close()
+	iterator.Close()
+
+	<-blocked
+	<-canceled
+	if !generator.Canceled() {
+		t.Error("generator should be canceled")
+	}
+	if closed := sw.Send(schema.AssistantMessage("a", nil), nil); !closed {
+		t.Error("stream of the discarded event should be closed")
+	}
+
+	// later values are discarded
+	generator.Send(&AgentEvent{})
+	generator.Close()
+	_, ok := iterator.Next()
+	if ok {
+		t.Error("receive from closed iterator should return ok=false")
+	}
+}
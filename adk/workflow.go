@@ -34,6 +34,7 @@ const (
 	workflowAgentModeSequential
 	workflowAgentModeLoop
 	workflowAgentModeParallel
+	workflowAgentModeRouter
 )
 
 type workflowAgent struct {
@@ -44,6 +45,12 @@ type workflowAgent struct {
 	mode workflowAgentMode
 
 	maxIterations int
+
+	// router
+	route          func(ctx context.Context, input *AgentInput) (*RouteAction, error)
+	parallelRoutes bool
+	// routedAgents are the names of the sub-agents picked, set to the workflow running them
+	routedAgents []string
 }
 
 func (a *workflowAgent) Name(_ context.Context) string {
@@ -55,7 +62,7 @@ func (a *workflowAgent) Description(_ context.Context) string {
 }
 
 func (a *workflowAgent) Run(ctx context.Context, input *AgentInput, opts ...AgentRunOption) *AsyncIterator[*AgentEvent] {
-	iterator, generator := NewAsyncIteratorPair[*AgentEvent]()
+	iterator, generator := newEventIteratorPair(ctx)
 
 	go func() {
 
@@ -80,6 +87,8 @@ func (a *workflowAgent) Run(ctx context.Context, input *AgentInput, opts ...Agen
 			a.runLoop(ctx, input, generator, nil, opts...)
 		case workflowAgentModeParallel:
 			a.runParallel(ctx, input, generator, nil, opts...)
+		case workflowAgentModeRouter:
+			err = a.runRouter(ctx, input, generator, nil, opts...)
 		default:
 			err = errors.New(fmt.Sprintf("unsupported workflow agent mode: %d", a.mode))
 		}
@@ -92,14 +101,14 @@ func (a *workflowAgent) Resume(ctx context.Context, info *ResumeInfo, opts ...Ag
 	wi, ok := info.Data.(*workflowInterruptInfo)
 	if !ok {
 		// unreachable
-		iterator, generator := NewAsyncIteratorPair[*AgentEvent]()
+		iterator, generator := newEventIteratorPair(ctx)
 		generator.Send(&AgentEvent{Err: fmt.Errorf("type of InterruptInfo.Data is expected to %s, actual: %T", reflect.TypeOf((*workflowInterruptInfo)(nil)).String(), info.Data)})
 		generator.Close()
 
 		return iterator
 	}
 
-	iterator, generator := NewAsyncIteratorPair[*AgentEvent]()
+	iterator, generator := newEventIteratorPair(ctx)
 
 	go func() {
 
@@ -124,6 +133,8 @@ func (a *workflowAgent) Resume(ctx context.Context, info *ResumeInfo, opts ...Ag
 			a.runLoop(ctx, wi.OrigInput, generator, wi, opts...)
 		case workflowAgentModeParallel:
 			a.runParallel(ctx, wi.OrigInput, generator, wi, opts...)
+		case workflowAgentModeRouter:
+			err = a.runRouter(ctx, wi.OrigInput, generator, wi, opts...)
 		default:
 			err = errors.New(fmt.Sprintf("unsupported workflow agent mode: %d", a.mode))
 		}
@@ -140,6 +151,8 @@ type workflowInterruptInfo struct {
 	LoopIterations int
 
 	ParallelInterruptInfo map[int] /*index*/ *InterruptInfo
+
+	RoutedAgents []string
 }
 
 func (a *workflowAgent) runSequential(ctx context.Context, input *AgentInput,
@@ -180,7 +193,9 @@ func (a *workflowAgent) runSequential(ctx context.Context, input *AgentInput,
 						SequentialInterruptIndex: i,
 						SequentialInterruptInfo:  event.Action.Interrupted,
 						LoopIterations:           iterations,
+						RoutedAgents:             a.routedAgents,
 					},
+					points: event.Action.Interrupted.Points(),
 				}
 
 				// Reset run ctx,
@@ -307,7 +322,9 @@ func (a *workflowAgent) runParallel(ctx context.Context, input *AgentInput,
 					Data: &workflowInterruptInfo{
 						OrigInput:             input,
 						ParallelInterruptInfo: interruptMap,
+						RoutedAgents:          a.routedAgents,
 					},
+					points: mergeInterruptPoints(interruptMap),
 				},
 			},
 		})
@@ -0,0 +1,181 @@
+/*
+ * Copyright 2025 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package compose
+
+import (
+	"context"
+	"fmt"
+)
+
+// BreakpointCondition decides whether a breakpoint set by WithBreakpointBefore or WithBreakpointAfter fires.
+// value is the input of the node for the breakpoints before the node, or its output for the ones after it,
+// which is concatenated from the stream if the graph runs in stream.
+// state is the state accessible by the node as by ProcessState, nil if there isn't one.
+// The state isn't locked for the condition, so call ProcessState with ctx instead to read it while other nodes may change it.
+type BreakpointCondition func(ctx context.Context, value any, state any) (bool, error)
+
+// WithBreakpointBefore interrupts the run before the node, as WithInterruptBeforeNodes does but for this run only.
+// node is the path of the node, e.g. NewNodePath("sub_graph_node", "node") for a node in the subgraph.
+// The breakpoint fires only if condition returns true, or always if condition is nil.
+// The interrupt is reported and resumed as the others, see ExtractInterruptInfo and WithCheckPointID.
+func WithBreakpointBefore(node *NodePath, condition BreakpointCondition) Option {
+	return Option{
+		breakpoints: []*breakpoint{{path: node.path, condition: condition}},
+	}
+}
+
+// WithBreakpointAfter interrupts the run after the node, as WithInterruptAfterNodes does but for this run only.
+// See WithBreakpointBefore for node and condition.
+func WithBreakpointAfter(node *NodePath, condition BreakpointCondition) Option {
+	return Option{
+		breakpoints: []*breakpoint{{path: node.path, after: true, condition: condition}},
+	}
+}
+
+type breakpoint struct {
+	// path is from the top graph in ctx, and relative to the graph run in the options
+	path      []string
+	after     bool
+	condition BreakpointCondition
+}
+
+type breakpointsKey struct{}
+
+// runBreakpoints are the breakpoints on the nodes of the graph for a run.
+type runBreakpoints struct {
+	before map[string][]BreakpointCondition
+	after  map[string][]BreakpointCondition
+}
+
+// initBreakpoints puts the breakpoints of the run into ctx for the subgraphs, and returns the ones on the nodes of this graph.
+func initBreakpoints(ctx context.Context, opts ...Option) (context.Context, *runBreakpoints) {
+	var (
+		prefix []string
+		bps    []*breakpoint
+	)
+	// the node path is cleared for the graphs run by the nodes, which don't share the breakpoints of the run
+	if path, ok := getNodeKey(ctx); ok && path != nil {
+		prefix = path.path
+		bps, _ = ctx.Value(breakpointsKey{}).([]*breakpoint)
+	}
+
+	added := false
+	for _, opt := range opts {
+		for _, bp := range opt.breakpoints {
+			abs := *bp
+			abs.path = append(prefix[:len(prefix):len(prefix)], bp.path...)
+			bps = append(bps[:len(bps):len(bps)], &abs)
+			added = true
+		}
+	}
+	if len(bps) == 0 {
+		return ctx, nil
+	}
+	if added {
+		ctx = context.WithValue(ctx, breakpointsKey{}, bps)
+	}
+
+	rb := &runBreakpoints{
+		before: map[string][]BreakpointCondition{},
+		after:  map[string][]BreakpointCondition{},
+	}
+	for _, bp := range bps {
+		if len(bp.path) != len(prefix)+1 || !isPathPrefix(prefix, bp.path) {
+			continue
+		}
+		key := bp.path[len(prefix)]
+		if bp.after {
+			rb.after[key] = append(rb.after[key], bp.condition)
+		} else {
+			rb.before[key] = append(rb.before[key], bp.condition)
+		}
+	}
+	return ctx, rb
+}
+
+func isPathPrefix(prefix, path []string) bool {
+	for i := range prefix {
+		if prefix[i] != path[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// hitBefore checks the breakpoints before the node of the task, with its input.
+func (b *runBreakpoints) hitBefore(t *task, isStream bool) (bool, error) {
+	if b == nil {
+		return false, nil
+	}
+	return hitBreakpoint(t.ctx, b.before[t.nodeKey], &t.input, t.call.action.inputStreamConvertPair, isStream)
+}
+
+// hitAfter checks the breakpoints after the node of the completed task, with its output.
+func (b *runBreakpoints) hitAfter(t *task, isStream bool) (bool, error) {
+	if b == nil {
+		return false, nil
+	}
+	return hitBreakpoint(t.ctx, b.after[t.nodeKey], &t.output, t.call.action.outputStreamConvertPair, isStream)
+}
+
+// hitBreakpoint evaluates the conditions with the value, the stream of which is copied
+// to concatenate one copy and to replace the value by the other.
+func hitBreakpoint(ctx context.Context, conditions []BreakpointCondition, value *any, pair streamConvertPair, isStream bool) (bool, error) {
+	if len(conditions) == 0 {
+		return false, nil
+	}
+	for _, cond := range conditions {
+		if cond == nil {
+			return true, nil
+		}
+	}
+
+	v := *value
+	if isStream {
+		sr, ok := v.(streamReader)
+		if !ok {
+			return false, fmt.Errorf("value of breakpoint isn't stream: %T", v)
+		}
+		srs := sr.copy(2)
+		*value = srs[0]
+
+		var err error
+		v, err = pair.concatStream(srs[1])
+		if err != nil {
+			return false, fmt.Errorf("failed to concat stream for breakpoint: %w", err)
+		}
+	}
+
+	// the lock isn't held while evaluating, so that the conditions can call ProcessState
+	var state any
+	if s, ok := ctx.Value(stateKey{}).(*internalState); ok {
+		s.mu.Lock()
+		state = s.state
+		s.mu.Unlock()
+	}
+
+	for _, cond := range conditions {
+		hit, err := cond(ctx, v, state)
+		if err != nil {
+			return false, fmt.Errorf("failed to evaluate breakpoint condition: %w", err)
+		}
+		if hit {
+			return true, nil
+		}
+	}
+	return false, nil
+}
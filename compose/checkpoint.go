@@ -123,6 +123,11 @@ type checkpoint struct {
 
 	ToolsNodeExecutedTools map[string] /*tool node key*/ map[string] /*tool call id*/ string
 
+	// NonIdempotentNodes are the non-idempotent nodes started after the durable checkpoint, see WithDurableCheckPoints.
+	NonIdempotentNodes []string
+	// Completed is set when the durable run has completed, then the run with the checkpoint ID starts over.
+	Completed bool
+
 	SubGraphs map[string]*checkpoint
 }
 
@@ -19,7 +19,9 @@ package compose
 import (
 	"context"
 	"errors"
+	"fmt"
 	"io"
+	"strings"
 	"testing"
 	"time"
 
@@ -984,3 +986,366 @@ func (f *failStore) Set(ctx context.Context, checkPointID string, checkPoint []b
 	f.t.Fatalf("cannot call store")
 	return errors.New("fail")
 }
+
+func TestInterruptHandle(t *testing.T) {
+	ctx := context.Background()
+
+	newGraph := func(h *InterruptHandle) *Graph[string, string] {
+		g := NewGraph[string, string]()
+		assert.NoError(t, g.AddLambdaNode("1", InvokableLambda(func(ctx context.Context, input string) (string, error) {
+			// triggered while the node is running, e.g. by the user pausing the run
+			h.Interrupt()
+			return input + "1", nil
+		})))
+		assert.NoError(t, g.AddLambdaNode("2", InvokableLambda(func(ctx context.Context, input string) (string, error) {
+			return input + "2", nil
+		})))
+		assert.NoError(t, g.AddEdge(START, "1"))
+		assert.NoError(t, g.AddEdge("1", "2"))
+		assert.NoError(t, g.AddEdge("2", END))
+		return g
+	}
+
+	t.Run("graph", func(t *testing.T) {
+		h := NewInterruptHandle()
+		r, err := newGraph(h).Compile(ctx, WithCheckPointStore(newInMemoryStore()))
+		assert.NoError(t, err)
+
+		_, err = r.Invoke(ctx, "start", WithCheckPointID("1"), WithInterruptHandle(h))
+		info, ok := ExtractInterruptInfo(err)
+		assert.True(t, ok)
+		assert.Equal(t, []string{"2"}, info.BeforeNodes)
+		assert.True(t, h.Interrupted())
+
+		result, err := r.Invoke(ctx, "", WithCheckPointID("1"))
+		assert.NoError(t, err)
+		assert.Equal(t, "start12", result)
+	})
+
+	t.Run("subgraph", func(t *testing.T) {
+		h := NewInterruptHandle()
+		g := NewGraph[string, string]()
+		assert.NoError(t, g.AddGraphNode("sub", newGraph(h)))
+		assert.NoError(t, g.AddLambdaNode("3", InvokableLambda(func(ctx context.Context, input string) (string, error) {
+			return input + "3", nil
+		})))
+		assert.NoError(t, g.AddEdge(START, "sub"))
+		assert.NoError(t, g.AddEdge("sub", "3"))
+		assert.NoError(t, g.AddEdge("3", END))
+		r, err := g.Compile(ctx, WithCheckPointStore(newInMemoryStore()))
+		assert.NoError(t, err)
+
+		sr, err := r.Stream(ctx, "start", WithCheckPointID("1"), WithInterruptHandle(h))
+		assert.Nil(t, sr)
+		info, ok := ExtractInterruptInfo(err)
+		assert.True(t, ok)
+		assert.Equal(t, []string{"2"}, info.SubGraphs["sub"].BeforeNodes)
+
+		result, err := r.Invoke(ctx, "", WithCheckPointID("1"))
+		assert.NoError(t, err)
+		assert.Equal(t, "start123", result)
+	})
+
+	t.Run("another run", func(t *testing.T) {
+		r, err := newGraph(NewInterruptHandle()).Compile(ctx)
+		assert.NoError(t, err)
+		result, err := r.Invoke(ctx, "start", WithInterruptHandle(NewInterruptHandle()))
+		assert.NoError(t, err)
+		assert.Equal(t, "start12", result)
+	})
+}
+
+func TestBreakpoints(t *testing.T) {
+	ctx := context.Background()
+	_ = RegisterSerializableType[testStruct]("test_struct")
+
+	appendLambda := func(s string) *Lambda {
+		return InvokableLambda(func(ctx context.Context, input string) (string, error) {
+			return input + s, nil
+		})
+	}
+
+	sub := NewGraph[string, string]()
+	assert.NoError(t, sub.AddLambdaNode("a", appendLambda("a")))
+	assert.NoError(t, sub.AddLambdaNode("b", appendLambda("b")))
+	assert.NoError(t, sub.AddEdge(START, "a"))
+	assert.NoError(t, sub.AddEdge("a", "b"))
+	assert.NoError(t, sub.AddEdge("b", END))
+
+	g := NewGraph[string, string](WithGenLocalState(func(ctx context.Context) *testStruct {
+		return &testStruct{A: "state"}
+	}))
+	assert.NoError(t, g.AddLambdaNode("1", appendLambda("1")))
+	assert.NoError(t, g.AddGraphNode("sub", sub))
+	assert.NoError(t, g.AddLambdaNode("2", appendLambda("2")))
+	assert.NoError(t, g.AddEdge(START, "1"))
+	assert.NoError(t, g.AddEdge("1", "sub"))
+	assert.NoError(t, g.AddEdge("sub", "2"))
+	assert.NoError(t, g.AddEdge("2", END))
+	r, err := g.Compile(ctx, WithCheckPointStore(newInMemoryStore()))
+	assert.NoError(t, err)
+
+	t.Run("conditional before", func(t *testing.T) {
+		var states []any
+		bp := WithBreakpointBefore(NewNodePath("2"), func(ctx context.Context, value any, state any) (bool, error) {
+			states = append(states, state)
+			return strings.HasPrefix(value.(string), "x"), nil
+		})
+
+		result, err := r.Invoke(ctx, "start", bp)
+		assert.NoError(t, err)
+		assert.Equal(t, "start1ab2", result)
+		assert.Equal(t, []any{&testStruct{A: "state"}}, states)
+
+		_, err = r.Invoke(ctx, "x", WithCheckPointID("before"), bp)
+		info, ok := ExtractInterruptInfo(err)
+		assert.True(t, ok)
+		assert.Equal(t, []string{"2"}, info.BeforeNodes)
+
+		result, err = r.Invoke(ctx, "", WithCheckPointID("before"), bp)
+		assert.NoError(t, err)
+		assert.Equal(t, "x1ab2", result)
+	})
+
+	t.Run("after subgraph node in stream", func(t *testing.T) {
+		bp := WithBreakpointAfter(NewNodePath("sub", "a"), func(ctx context.Context, value any, state any) (bool, error) {
+			return value.(string) == "start1a", nil
+		})
+		_, err := r.Stream(ctx, "start", WithCheckPointID("after"), bp)
+		info, ok := ExtractInterruptInfo(err)
+		assert.True(t, ok)
+		assert.Equal(t, []string{"a"}, info.SubGraphs["sub"].AfterNodes)
+
+		sr, err := r.Stream(ctx, "", WithCheckPointID("after"))
+		assert.NoError(t, err)
+		result, err := concatStreamReader(sr)
+		assert.NoError(t, err)
+		assert.Equal(t, "start1ab2", result)
+	})
+
+	t.Run("condition processing state", func(t *testing.T) {
+		result, err := r.Invoke(ctx, "start", WithBreakpointBefore(NewNodePath("2"), func(ctx context.Context, value any, state any) (bool, error) {
+			var hit bool
+			err := ProcessState(ctx, func(_ context.Context, st *testStruct) error {
+				hit = st.A != "state"
+				return nil
+			})
+			return hit, err
+		}))
+		assert.NoError(t, err)
+		assert.Equal(t, "start1ab2", result)
+	})
+
+	t.Run("condition error", func(t *testing.T) {
+		_, err := r.Invoke(ctx, "start", WithBreakpointBefore(NewNodePath("1"), func(ctx context.Context, value any, state any) (bool, error) {
+			return false, errors.New("bad condition")
+		}))
+		assert.ErrorContains(t, err, "bad condition")
+		_, ok := ExtractInterruptInfo(err)
+		assert.False(t, ok)
+	})
+
+	t.Run("unconditional", func(t *testing.T) {
+		_, err := r.Invoke(ctx, "start", WithBreakpointAfter(NewNodePath("1"), nil))
+		info, ok := ExtractInterruptInfo(err)
+		assert.True(t, ok)
+		assert.Equal(t, []string{"1"}, info.AfterNodes)
+	})
+}
+
+func TestBreakpointsOfNestedRun(t *testing.T) {
+	ctx := context.Background()
+
+	inner := NewGraph[string, string]()
+	assert.NoError(t, inner.AddLambdaNode("1", InvokableLambda(func(ctx context.Context, input string) (string, error) {
+		return input + "i", nil
+	})))
+	assert.NoError(t, inner.AddEdge(START, "1"))
+	assert.NoError(t, inner.AddEdge("1", END))
+	ir, err := inner.Compile(ctx)
+	assert.NoError(t, err)
+
+	g := NewGraph[string, string]()
+	assert.NoError(t, g.AddLambdaNode("0", InvokableLambda(func(ctx context.Context, input string) (string, error) {
+		// the graph run by the node doesn't break at node "1" of the outer graph
+		return ir.Invoke(ctx, input)
+	})))
+	assert.NoError(t, g.AddLambdaNode("1", InvokableLambda(func(ctx context.Context, input string) (string, error) {
+		return input + "o", nil
+	})))
+	assert.NoError(t, g.AddEdge(START, "0"))
+	assert.NoError(t, g.AddEdge("0", "1"))
+	assert.NoError(t, g.AddEdge("1", END))
+	r, err := g.Compile(ctx)
+	assert.NoError(t, err)
+
+	_, err = r.Invoke(ctx, "start", WithBreakpointBefore(NewNodePath("1"), func(ctx context.Context, value any, state any) (bool, error) {
+		assert.Equal(t, "starti", value)
+		return true, nil
+	}))
+	info, ok := ExtractInterruptInfo(err)
+	assert.True(t, ok)
+	assert.Equal(t, []string{"1"}, info.BeforeNodes)
+}
+
+func TestDurableCheckPoints(t *testing.T) {
+	ctx := context.Background()
+
+	// newGraph builds the graph of the nodes 1 -> 2 -> ... -> n, the node failing fails the first time it runs,
+	// as if the process crashed
+	newGraph := func(t *testing.T, n int, failing string, opts map[string][]GraphAddNodeOpt) (Runnable[string, string], map[string]int) {
+		counts := map[string]int{}
+		g := NewGraph[string, string]()
+		pre := START
+		for i := 1; i <= n; i++ {
+			key := fmt.Sprint(i)
+			assert.NoError(t, g.AddLambdaNode(key, InvokableLambda(func(ctx context.Context, input string) (string, error) {
+				counts[key]++
+				if key == failing && counts[key] == 1 {
+					return "", errors.New("crashed")
+				}
+				return input + key, nil
+			}), opts[key]...))
+			assert.NoError(t, g.AddEdge(pre, key))
+			pre = key
+		}
+		assert.NoError(t, g.AddEdge(pre, END))
+		r, err := g.Compile(ctx, WithCheckPointStore(newInMemoryStore()))
+		assert.NoError(t, err)
+		return r, counts
+	}
+
+	t.Run("resume after every step", func(t *testing.T) {
+		r, counts := newGraph(t, 3, "2", nil)
+		_, err := r.Invoke(ctx, "start", WithCheckPointID("1"), WithDurableCheckPoints(1))
+		assert.ErrorContains(t, err, "crashed")
+
+		result, err := r.Invoke(ctx, "", WithCheckPointID("1"), WithDurableCheckPoints(1))
+		assert.NoError(t, err)
+		assert.Equal(t, "start123", result)
+		assert.Equal(t, map[string]int{"1": 1, "2": 2, "3": 1}, counts)
+	})
+
+	t.Run("resume in stream", func(t *testing.T) {
+		r, counts := newGraph(t, 3, "3", nil)
+		_, err := r.Stream(ctx, "start", WithCheckPointID("1"), WithDurableCheckPoints(0))
+		assert.ErrorContains(t, err, "crashed")
+
+		sr, err := r.Stream(ctx, "", WithCheckPointID("1"))
+		assert.NoError(t, err)
+		result, err := concatStreamReader(sr)
+		assert.NoError(t, err)
+		assert.Equal(t, "start123", result)
+		assert.Equal(t, map[string]int{"1": 1, "2": 1, "3": 2}, counts)
+	})
+
+	t.Run("interval", func(t *testing.T) {
+		r, counts := newGraph(t, 4, "4", nil)
+		_, err := r.Invoke(ctx, "start", WithCheckPointID("1"), WithDurableCheckPoints(2))
+		assert.ErrorContains(t, err, "crashed")
+
+		result, err := r.Invoke(ctx, "", WithCheckPointID("1"), WithDurableCheckPoints(2))
+		assert.NoError(t, err)
+		assert.Equal(t, "start1234", result)
+		// saved before the node 3
+		assert.Equal(t, map[string]int{"1": 1, "2": 1, "3": 2, "4": 2}, counts)
+	})
+
+	t.Run("non-idempotent", func(t *testing.T) {
+		r, counts := newGraph(t, 4, "2", map[string][]GraphAddNodeOpt{"2": {WithNonIdempotent()}})
+		_, err := r.Invoke(ctx, "start", WithCheckPointID("1"), WithDurableCheckPoints(3))
+		assert.ErrorContains(t, err, "crashed")
+
+		_, err = r.Invoke(ctx, "", WithCheckPointID("1"), WithDurableCheckPoints(3))
+		assert.True(t, errors.Is(err, ErrNonIdempotentNodeBroken))
+		assert.Equal(t, 1, counts["2"])
+
+		result, err := r.Invoke(ctx, "", WithCheckPointID("1"), WithDurableCheckPoints(3), WithRerunNonIdempotentNodes())
+		assert.NoError(t, err)
+		assert.Equal(t, "start1234", result)
+		assert.Equal(t, map[string]int{"1": 1, "2": 2, "3": 1, "4": 1}, counts)
+	})
+
+	t.Run("non-idempotent completed", func(t *testing.T) {
+		r, counts := newGraph(t, 3, "3", map[string][]GraphAddNodeOpt{"2": {WithNonIdempotent()}})
+		_, err := r.Invoke(ctx, "start", WithCheckPointID("1"), WithDurableCheckPoints(5))
+		assert.ErrorContains(t, err, "crashed")
+
+		// the completion of the non-idempotent node has been saved
+		result, err := r.Invoke(ctx, "", WithCheckPointID("1"), WithDurableCheckPoints(5))
+		assert.NoError(t, err)
+		assert.Equal(t, "start123", result)
+		assert.Equal(t, map[string]int{"1": 1, "2": 1, "3": 2}, counts)
+	})
+
+	t.Run("non-idempotent in last step", func(t *testing.T) {
+		r, counts := newGraph(t, 2, "", map[string][]GraphAddNodeOpt{"2": {WithNonIdempotent()}})
+		result, err := r.Invoke(ctx, "s", WithCheckPointID("1"), WithDurableCheckPoints(5))
+		assert.NoError(t, err)
+		assert.Equal(t, "s12", result)
+
+		// the run has completed, so the checkpoint isn't refused, and the run starts over
+		result, err = r.Invoke(ctx, "t", WithCheckPointID("1"), WithDurableCheckPoints(5))
+		assert.NoError(t, err)
+		assert.Equal(t, "t12", result)
+		assert.Equal(t, map[string]int{"1": 2, "2": 2}, counts)
+	})
+
+	t.Run("rerun after completion", func(t *testing.T) {
+		r, counts := newGraph(t, 3, "3", nil)
+		_, err := r.Invoke(ctx, "start", WithCheckPointID("1"), WithDurableCheckPoints(1))
+		assert.ErrorContains(t, err, "crashed")
+
+		result, err := r.Invoke(ctx, "", WithCheckPointID("1"), WithDurableCheckPoints(1))
+		assert.NoError(t, err)
+		assert.Equal(t, "start123", result)
+
+		// the last step isn't executed again from the checkpoint saved before it
+		result, err = r.Invoke(ctx, "again", WithCheckPointID("1"), WithDurableCheckPoints(1))
+		assert.NoError(t, err)
+		assert.Equal(t, "again123", result)
+		assert.Equal(t, map[string]int{"1": 2, "2": 2, "3": 3}, counts)
+	})
+
+	t.Run("waiting predecessors in dag stream", func(t *testing.T) {
+		xCount := 0
+		g := NewGraph[string, map[string]any]()
+		assert.NoError(t, g.AddLambdaNode("a", InvokableLambda(func(ctx context.Context, input string) (string, error) {
+			return input + "a", nil
+		}), WithOutputKey("a")))
+		assert.NoError(t, g.AddLambdaNode("b", InvokableLambda(func(ctx context.Context, input string) (string, error) {
+			return input + "b", nil
+		})))
+		assert.NoError(t, g.AddLambdaNode("x", InvokableLambda(func(ctx context.Context, input string) (string, error) {
+			xCount++
+			if xCount == 1 {
+				return "", errors.New("crashed")
+			}
+			return input + "x", nil
+		}), WithOutputKey("x")))
+		assert.NoError(t, g.AddPassthroughNode("c"))
+		assert.NoError(t, g.AddEdge(START, "a"))
+		assert.NoError(t, g.AddEdge(START, "b"))
+		assert.NoError(t, g.AddEdge("b", "x"))
+		assert.NoError(t, g.AddEdge("a", "c"))
+		assert.NoError(t, g.AddEdge("x", "c"))
+		assert.NoError(t, g.AddEdge("c", END))
+		r, err := g.Compile(ctx, WithCheckPointStore(newInMemoryStore()), WithNodeTriggerMode(AllPredecessor))
+		assert.NoError(t, err)
+
+		_, err = r.Stream(ctx, "start", WithCheckPointID("1"), WithDurableCheckPoints(1))
+		assert.ErrorContains(t, err, "crashed")
+
+		sr, err := r.Stream(ctx, "", WithCheckPointID("1"), WithDurableCheckPoints(1))
+		assert.NoError(t, err)
+		result, err := concatStreamReader(sr)
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]any{"a": "starta", "x": "startbx"}, result)
+	})
+
+	t.Run("without checkpoint id", func(t *testing.T) {
+		r, _ := newGraph(t, 1, "", nil)
+		_, err := r.Invoke(ctx, "start", WithDurableCheckPoints(1))
+		assert.ErrorContains(t, err, "durable checkpoints require checkpoint id")
+	})
+}
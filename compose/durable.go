@@ -0,0 +1,176 @@
+/*
+ * Copyright 2025 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package compose
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrNonIdempotentNodeBroken is returned when resuming a run which broke while the nodes declared by WithNonIdempotent were running,
+// as the nodes aren't run again by default, see WithRerunNonIdempotentNodes.
+var ErrNonIdempotentNodeBroken = errors.New("run broke while non-idempotent nodes were running")
+
+// WithDurableCheckPoints saves the checkpoint after every interval super steps, besides the ones saved on interrupts,
+// so that a run broken by e.g. a crash can be resumed from the last saved step by running the graph with the same checkpoint ID.
+// The outputs of the nodes are saved before their successors start, and the nodes run step by step even in eager mode.
+// In stream mode, the streams are concatenated to be saved, and the nodes of the subgraphs rerun as a whole on resuming.
+// interval less than 1 means 1. It requires the checkpoint store and WithCheckPointID or WithWriteToCheckPointID.
+// The checkpoint is marked completed when the run completes, so running the graph with the same checkpoint ID again
+// starts a new run.
+func WithDurableCheckPoints(interval int) Option {
+	if interval < 1 {
+		interval = 1
+	}
+	return Option{
+		durableInterval: interval,
+	}
+}
+
+// WithRerunNonIdempotentNodes runs again the non-idempotent nodes which were running when the run broke,
+// after the side effects have been checked, see ErrNonIdempotentNodeBroken.
+func WithRerunNonIdempotentNodes() Option {
+	return Option{
+		rerunNonIdempotent: true,
+	}
+}
+
+func getDurableInfo(opts ...Option) (interval int, rerunNonIdempotent bool) {
+	for _, opt := range opts {
+		if opt.durableInterval > 0 {
+			interval = opt.durableInterval
+		}
+		if opt.rerunNonIdempotent {
+			rerunNonIdempotent = true
+		}
+	}
+	return interval, rerunNonIdempotent
+}
+
+// durableCheckPointer saves the checkpoints at the super step boundaries of a run in durable mode.
+type durableCheckPointer struct {
+	interval     int
+	checkPointID string
+
+	// lastNonIdempotent is whether non-idempotent nodes run in the last step, whose completion has to be saved
+	lastNonIdempotent bool
+}
+
+func getNonIdempotentNodes(tasks []*task) []string {
+	var nodes []string
+	for _, t := range tasks {
+		if t.call.action.nodeInfo != nil && t.call.action.nodeInfo.nonIdempotent {
+			nodes = append(nodes, t.nodeKey)
+		}
+	}
+	return nodes
+}
+
+// onStep saves the checkpoint before the step if due, or if the non-idempotent nodes run in the step or have run in the last step.
+func (d *durableCheckPointer) onStep(ctx context.Context, r *runner, step int, nextTasks []*task, cm *channelManager, isStream bool) error {
+	nonIdempotent := getNonIdempotentNodes(nextTasks)
+	due := step > 0 && step%d.interval == 0
+	if !due && len(nonIdempotent) == 0 && !d.lastNonIdempotent {
+		return nil
+	}
+	d.lastNonIdempotent = len(nonIdempotent) > 0
+
+	err := r.saveStepCheckPoint(ctx, nextTasks, cm, isStream, d.checkPointID, nonIdempotent)
+	if err != nil {
+		return fmt.Errorf("failed to save durable checkpoint: %w", err)
+	}
+	return nil
+}
+
+// onEnd marks the checkpoint completed, so that the run with the same checkpoint ID starts over,
+// instead of resuming from the step saved last or being refused by ErrNonIdempotentNodeBroken.
+func (d *durableCheckPointer) onEnd(ctx context.Context, r *runner) error {
+	err := r.checkPointer.set(ctx, d.checkPointID, &checkpoint{Completed: true})
+	if err != nil {
+		return fmt.Errorf("failed to save durable checkpoint: %w", err)
+	}
+	return nil
+}
+
+// saveStepCheckPoint saves the checkpoint without stopping the run.
+// The streams are copied, one copy is concatenated to be saved, and the other is kept for the run.
+func (r *runner) saveStepCheckPoint(ctx context.Context, nextTasks []*task, cm *channelManager, isStream bool,
+	checkPointID string, nonIdempotent []string) error {
+
+	cp := &checkpoint{
+		Channels:           cm.channels,
+		Inputs:             make(map[string]any, len(nextTasks)),
+		SkipPreHandler:     map[string]bool{},
+		NonIdempotentNodes: nonIdempotent,
+	}
+	for _, t := range nextTasks {
+		if sr, ok := t.input.(streamReader); ok && isStream {
+			srs := sr.copy(2)
+			t.input, cp.Inputs[t.nodeKey] = srs[0], srs[1]
+			continue
+		}
+		cp.Inputs[t.nodeKey] = t.input
+	}
+
+	if isStream {
+		// the values of the channels are converted in place, so the kept streams are put back after saving
+		var restores []func()
+		defer func() {
+			for _, restore := range restores {
+				restore()
+			}
+		}()
+		for _, ch := range cm.channels {
+			_ = ch.convertValues(func(m map[string]any) error {
+				kept := make(map[string]any)
+				for k, v := range m {
+					if sr, ok := v.(streamReader); ok {
+						srs := sr.copy(2)
+						m[k], kept[k] = srs[1], srs[0]
+					}
+				}
+				restores = append(restores, func() {
+					for k, v := range kept {
+						m[k] = v
+					}
+				})
+				return nil
+			})
+		}
+	}
+
+	err := r.checkPointer.convertCheckPoint(cp, isStream)
+	if err != nil {
+		return fmt.Errorf("failed to convert checkpoint: %w", err)
+	}
+
+	// the state is copied by serializing it under the lock, and the store is written after unlocking
+	var data []byte
+	if state, ok := ctx.Value(stateKey{}).(*internalState); ok {
+		state.mu.Lock()
+		cp.State = state.state
+		data, err = r.checkPointer.serializer.Marshal(cp)
+		state.mu.Unlock()
+	} else {
+		data, err = r.checkPointer.serializer.Marshal(cp)
+	}
+	if err != nil {
+		return err
+	}
+	return r.checkPointer.store.Set(ctx, checkPointID, data)
+}
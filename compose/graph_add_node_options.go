@@ -44,6 +44,8 @@ type nodeOptions struct {
 	outputKey string
 
 	graphCompileOption []GraphCompileOption // when this node is itself an AnyGraph, this option will be used to compile the node as a nested graph
+
+	nonIdempotent bool
 }
 
 // WithNodeName sets the name of the node.
@@ -89,6 +91,15 @@ func WithGraphCompileOptions(opts ...GraphCompileOption) GraphAddNodeOpt {
 	}
 }
 
+// WithNonIdempotent declares that the node has side effects which mustn't be repeated, e.g. sending an email.
+// When the graph runs with WithDurableCheckPoints, the node isn't run again if the run broke while it was running,
+// instead, resuming the run fails with ErrNonIdempotentNodeBroken.
+func WithNonIdempotent() GraphAddNodeOpt {
+	return func(o *graphAddNodeOpts) {
+		o.nodeOptions.nonIdempotent = true
+	}
+}
+
 // WithStatePreHandler modify node's input of I according to state S and input or store input information into state, and it's thread-safe.
 // notice: this option requires Graph to be created with WithGenLocalState option.
 // I: input type of the Node like ChatModel, Lambda, Retriever etc.
@@ -41,6 +41,10 @@ type Option struct {
 	writeToCheckPointID *string
 	forceNewRun         bool
 	stateModifier       StateModifier
+	interruptHandle     *InterruptHandle
+	breakpoints         []*breakpoint
+	durableInterval     int
+	rerunNonIdempotent  bool
 }
 
 func (o Option) deepCopy() Option {
@@ -55,6 +55,8 @@ type nodeInfo struct {
 	preProcessor, postProcessor *composableRunnable
 
 	compileOption *graphCompileOptions // if the node is an AnyGraph, it will need compile options of its own
+
+	nonIdempotent bool
 }
 
 // graphNode the complete information of the node in graph
@@ -130,7 +132,10 @@ func (gn *graphNode) compileIfNeeded(ctx context.Context) (*composableRunnable,
 		r = cr
 		gn.cr = cr
 	} else if gn.cr != nil {
-		r = gn.cr
+		// the runnable of a component is shared by every compilation, e.g. when the component is added to several graphs,
+		// and may be running in a graph compiled before, so each compilation sets the node info to its own copy
+		cp := *gn.cr
+		r = &cp
 	} else {
 		return nil, errors.New("no graph or component provided")
 	}
@@ -174,5 +179,6 @@ func getNodeInfo(opts ...GraphAddNodeOpt) (*nodeInfo, *graphAddNodeOpts) {
 		preProcessor:  opt.processor.statePreHandler,
 		postProcessor: opt.processor.statePostHandler,
 		compileOption: newGraphCompileOptions(opt.nodeOptions.graphCompileOption...),
+		nonIdempotent: opt.nodeOptions.nonIdempotent,
 	}, opt
 }
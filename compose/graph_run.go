@@ -160,6 +160,20 @@ func (r *runner) run(ctx context.Context, isStream bool, input any, opts ...Opti
 	// Extract subgraph
 	path, isSubGraph := getNodeKey(ctx)
 
+	ctx, interruptHandle := initInterruptHandle(ctx, opts...)
+	ctx, bps := initBreakpoints(ctx, opts...)
+
+	var durable *durableCheckPointer
+	durableInterval, rerunNonIdempotent := getDurableInfo(opts...)
+	if durableInterval > 0 && !isSubGraph {
+		if writeToCheckPointID == nil || r.checkPointer.store == nil {
+			return nil, newGraphRunError(errors.New("durable checkpoints require checkpoint id and checkpoint store"))
+		}
+		durable = &durableCheckPointer{interval: durableInterval, checkPointID: *writeToCheckPointID}
+		// the checkpoints are saved at the boundaries of the super steps
+		tm.needAll = true
+	}
+
 	// load checkpoint from ctx/store or init graph
 	initialized := false
 	var nextTasks []*task
@@ -198,7 +212,11 @@ func (r *runner) run(ctx context.Context, isStream bool, input any, opts ...Opti
 		if err != nil {
 			return nil, newGraphRunError(fmt.Errorf("load checkpoint from store fail: %w", err))
 		}
-		if cp != nil {
+		if cp != nil && !cp.Completed {
+			if len(cp.NonIdempotentNodes) > 0 && !rerunNonIdempotent {
+				return nil, newGraphRunError(fmt.Errorf("%w: %v", ErrNonIdempotentNodeBroken, cp.NonIdempotentNodes))
+			}
+
 			// load checkpoint from store
 			initialized = true
 
@@ -253,7 +271,11 @@ func (r *runner) run(ctx context.Context, isStream bool, input any, opts ...Opti
 			return result, nil
 		}
 
-		if keys := getHitKey(nextTasks, r.interruptBeforeNodes); len(keys) > 0 {
+		keys, err := r.getInterruptBeforeKeys(nextTasks, interruptHandle, bps, isStream)
+		if err != nil {
+			return nil, err // err has been wrapped
+		}
+		if len(keys) > 0 {
 			tempInfo := newInterruptTempInfo()
 			tempInfo.interruptBeforeNodes = append(tempInfo.interruptBeforeNodes, keys...)
 			return nil, r.handleInterrupt(ctx,
@@ -280,6 +302,13 @@ func (r *runner) run(ctx context.Context, isStream bool, input any, opts ...Opti
 			return nil, newGraphRunError(ErrExceedMaxSteps)
 		}
 
+		if durable != nil {
+			err = durable.onStep(ctx, r, step, nextTasks, cm, isStream)
+			if err != nil {
+				return nil, newGraphRunError(err)
+			}
+		}
+
 		// 1. submit next tasks
 		// 2. get completed tasks
 		// 3. calculate next tasks
@@ -293,14 +322,14 @@ func (r *runner) run(ctx context.Context, isStream bool, input any, opts ...Opti
 
 		tempInfo := newInterruptTempInfo()
 
-		err = r.resolveInterruptCompletedTasks(tempInfo, completedTasks)
+		err = r.resolveInterruptCompletedTasks(tempInfo, completedTasks, bps, isStream)
 		if err != nil {
 			return nil, err // err has been wrapped
 		}
 
 		if len(tempInfo.subGraphInterrupts)+len(tempInfo.interruptRerunNodes) > 0 {
 			cpt := tm.waitAll()
-			err = r.resolveInterruptCompletedTasks(tempInfo, cpt)
+			err = r.resolveInterruptCompletedTasks(tempInfo, cpt, bps, isStream)
 			if err != nil {
 				return nil, err // err has been wrapped
 			}
@@ -331,15 +360,23 @@ func (r *runner) run(ctx context.Context, isStream bool, input any, opts ...Opti
 			return nil, newGraphRunError(fmt.Errorf("failed to calculate next tasks: %w", err))
 		}
 		if result != nil {
+			if durable != nil {
+				if err = durable.onEnd(ctx, r); err != nil {
+					return nil, newGraphRunError(err)
+				}
+			}
 			return result, nil
 		}
 
-		tempInfo.interruptBeforeNodes = getHitKey(nextTasks, r.interruptBeforeNodes)
+		tempInfo.interruptBeforeNodes, err = r.getInterruptBeforeKeys(nextTasks, interruptHandle, bps, isStream)
+		if err != nil {
+			return nil, err // err has been wrapped
+		}
 
-		if len(tempInfo.interruptBeforeNodes) > 0 || len(tempInfo.interruptAfterNodes) > 0 {
+		if len(tempInfo.interruptBeforeNodes) > 0 || len(tempInfo.interruptAfterNodes) > 0 || interruptHandle.Interrupted() {
 			newCompletedTasks := tm.waitAll()
 
-			err = r.resolveInterruptCompletedTasks(tempInfo, newCompletedTasks)
+			err = r.resolveInterruptCompletedTasks(tempInfo, newCompletedTasks, bps, isStream)
 			if err != nil {
 				return nil, err // err has been wrapped
 			}
@@ -362,10 +399,19 @@ func (r *runner) run(ctx context.Context, isStream bool, input any, opts ...Opti
 			}
 
 			if result != nil {
+				if durable != nil {
+					if err = durable.onEnd(ctx, r); err != nil {
+						return nil, newGraphRunError(err)
+					}
+				}
 				return result, nil
 			}
 
-			tempInfo.interruptBeforeNodes = append(tempInfo.interruptBeforeNodes, getHitKey(newNextTasks, r.interruptBeforeNodes)...)
+			keys, err := r.getInterruptBeforeKeys(newNextTasks, interruptHandle, bps, isStream)
+			if err != nil {
+				return nil, err // err has been wrapped
+			}
+			tempInfo.interruptBeforeNodes = append(tempInfo.interruptBeforeNodes, keys...)
 
 			// simple interrupt
 			return nil, r.handleInterrupt(ctx, tempInfo, append(nextTasks, newNextTasks...), cm.channels, isStream, isSubGraph, writeToCheckPointID)
@@ -390,7 +436,7 @@ type interruptTempInfo struct {
 	interruptExecutedTools map[string]map[string]string
 }
 
-func (r *runner) resolveInterruptCompletedTasks(tempInfo *interruptTempInfo, completedTasks []*task) (err error) {
+func (r *runner) resolveInterruptCompletedTasks(tempInfo *interruptTempInfo, completedTasks []*task, bps *runBreakpoints, isStream bool) (err error) {
 	for _, completedTask := range completedTasks {
 		if completedTask.err != nil {
 			if info := isSubGraphInterrupt(completedTask.err); info != nil {
@@ -415,16 +461,40 @@ func (r *runner) resolveInterruptCompletedTasks(tempInfo *interruptTempInfo, com
 			return wrapGraphNodeError(completedTask.nodeKey, completedTask.err)
 		}
 
-		for _, key := range r.interruptAfterNodes {
-			if key == completedTask.nodeKey {
-				tempInfo.interruptAfterNodes = append(tempInfo.interruptAfterNodes, key)
-				break
-			}
+		if len(getHitKey([]*task{completedTask}, r.interruptAfterNodes)) > 0 {
+			tempInfo.interruptAfterNodes = append(tempInfo.interruptAfterNodes, completedTask.nodeKey)
+			continue
+		}
+		hit, err := bps.hitAfter(completedTask, isStream)
+		if err != nil {
+			return wrapGraphNodeError(completedTask.nodeKey, err)
+		}
+		if hit {
+			tempInfo.interruptAfterNodes = append(tempInfo.interruptAfterNodes, completedTask.nodeKey)
 		}
 	}
 	return nil
 }
 
+// getInterruptBeforeKeys gets the nodes to interrupt before, by the compile options and the breakpoints of the run,
+// or all the tasks if the run is interrupted by the handle.
+func (r *runner) getInterruptBeforeKeys(tasks []*task, h *InterruptHandle, bps *runBreakpoints, isStream bool) ([]string, error) {
+	var ret []string
+	for _, t := range tasks {
+		hit := h.Interrupted() || len(getHitKey([]*task{t}, r.interruptBeforeNodes)) > 0
+		if !hit {
+			var err error
+			if hit, err = bps.hitBefore(t, isStream); err != nil {
+				return nil, wrapGraphNodeError(t.nodeKey, err)
+			}
+		}
+		if hit {
+			ret = append(ret, t.nodeKey)
+		}
+	}
+	return ret, nil
+}
+
 func getHitKey(tasks []*task, keys []string) []string {
 	var ret []string
 	for _, t := range tasks {
@@ -24,6 +24,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -1965,3 +1966,48 @@ func normalizeLoops(loops [][]string) []string {
 	sort.Strings(result)
 	return result
 }
+
+func TestComponentSharedByGraphs(t *testing.T) {
+	ctx := context.Background()
+	l := InvokableLambda(func(ctx context.Context, input string) (string, error) {
+		return input, nil
+	})
+
+	compile := func(name string) Runnable[string, string] {
+		g := NewGraph[string, string]()
+		assert.NoError(t, g.AddLambdaNode("node", l, WithNodeName(name)))
+		assert.NoError(t, g.AddEdge(START, "node"))
+		assert.NoError(t, g.AddEdge("node", END))
+		r, err := g.Compile(ctx)
+		assert.NoError(t, err)
+		return r
+	}
+	first := compile("first")
+	second := compile("second")
+
+	// the node info of each compilation is kept, even if the graphs run concurrently
+	var wg sync.WaitGroup
+	for _, c := range []struct {
+		r    Runnable[string, string]
+		name string
+	}{{first, "first"}, {second, "second"}, {first, "first"}, {second, "second"}} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var names []string
+			var mu sync.Mutex
+			cb := callbacks.NewHandlerBuilder().OnStartFn(func(ctx context.Context, info *callbacks.RunInfo, input callbacks.CallbackInput) context.Context {
+				if info.Component == ComponentOfLambda {
+					mu.Lock()
+					names = append(names, info.Name)
+					mu.Unlock()
+				}
+				return ctx
+			}).Build()
+			_, err := c.r.Invoke(ctx, "hi", WithCallbacks(cb))
+			assert.NoError(t, err)
+			assert.Equal(t, []string{c.name}, names)
+		}()
+	}
+	wg.Wait()
+}
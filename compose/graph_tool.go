@@ -0,0 +1,72 @@
+/*
+ * Copyright 2025 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package compose
+
+import (
+	"context"
+	"errors"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/components/tool/utils"
+	"github.com/cloudwego/eino/schema"
+)
+
+type graphToolOptions struct {
+	composeOptions []Option
+}
+
+// WithGraphToolOptions passes the options to the runs of the graph behind the tool,
+// see NewInvokableGraphTool and NewStreamableGraphTool.
+// e.g. pass compose.WithChatModelOption to the ChatModel nodes of the graph by a tool node:
+//
+//	toolsNodeOpt := compose.WithToolOption(compose.WithGraphToolOptions(compose.WithChatModelOption(model.WithTemperature(0))))
+func WithGraphToolOptions(opts ...Option) tool.Option {
+	return tool.WrapImplSpecificOptFn(func(o *graphToolOptions) {
+		o.composeOptions = append(o.composeOptions, opts...)
+	})
+}
+
+func getGraphToolComposeOptions(opts []tool.Option) []Option {
+	return tool.GetImplSpecificOptions(&graphToolOptions{}, opts...).composeOptions
+}
+
+// NewInvokableGraphTool wraps a compiled graph as an invokable tool, e.g. a whole retrieval or extraction pipeline,
+// so that it can be given to a ChatModel or an agent as a single tool.
+// The parameters of the tool are inferred from I as InferTool does, and the output O is serialized to JSON as the result unless it's string,
+// use utils.WithSchemaCustomizer, utils.WithUnmarshalArguments and utils.WithMarshalOutput to customize them.
+func NewInvokableGraphTool[I, O any](r Runnable[I, O], toolName, toolDesc string, opts ...utils.Option) (tool.InvokableTool, error) {
+	if r == nil {
+		return nil, errors.New("runnable of graph tool is nil")
+	}
+
+	return utils.InferOptionableTool(toolName, toolDesc, func(ctx context.Context, input I, opts ...tool.Option) (O, error) {
+		return r.Invoke(ctx, input, getGraphToolComposeOptions(opts)...)
+	}, opts...)
+}
+
+// NewStreamableGraphTool wraps a compiled graph as a streamable tool, whose result is the output stream of the graph,
+// with each chunk of O serialized to JSON, or returned as is if O is string.
+// The parameters of the tool are inferred from I as InferStreamTool does.
+func NewStreamableGraphTool[I, O any](r Runnable[I, O], toolName, toolDesc string, opts ...utils.Option) (tool.StreamableTool, error) {
+	if r == nil {
+		return nil, errors.New("runnable of graph tool is nil")
+	}
+
+	return utils.InferOptionableStreamTool(toolName, toolDesc, func(ctx context.Context, input I, opts ...tool.Option) (*schema.StreamReader[O], error) {
+		return r.Stream(ctx, input, getGraphToolComposeOptions(opts)...)
+	}, opts...)
+}
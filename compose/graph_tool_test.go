@@ -0,0 +1,122 @@
+/*
+ * Copyright 2025 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package compose
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/schema"
+)
+
+type graphToolInput struct {
+	Query string `json:"query" jsonschema:"description=the query to search"`
+	TopK  int    `json:"top_k,omitempty"`
+}
+
+type graphToolOutput struct {
+	Docs []string `json:"docs"`
+}
+
+type graphToolLambdaOption struct {
+	prefix string
+}
+
+func TestGraphTool(t *testing.T) {
+	ctx := context.Background()
+
+	search := InvokableLambdaWithOption(func(ctx context.Context, input *graphToolInput, opts ...graphToolLambdaOption) (*graphToolOutput, error) {
+		prefix := "doc"
+		for _, o := range opts {
+			prefix = o.prefix
+		}
+		out := &graphToolOutput{}
+		for i := 0; i < input.TopK; i++ {
+			out.Docs = append(out.Docs, prefix+" of "+input.Query)
+		}
+		return out, nil
+	})
+	r, err := NewChain[*graphToolInput, *graphToolOutput]().AppendLambda(search).Compile(ctx)
+	assert.NoError(t, err)
+
+	t.Run("invokable", func(t *testing.T) {
+		gt, err := NewInvokableGraphTool(r, "search", "search docs")
+		assert.NoError(t, err)
+
+		info, err := gt.Info(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, "search", info.Name)
+		js, err := info.ParamsOneOf.ToOpenAPIV3()
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"query"}, js.Required)
+		assert.Equal(t, "the query to search", js.Properties["query"].Value.Description)
+		assert.Contains(t, js.Properties, "top_k")
+
+		out, err := gt.InvokableRun(ctx, `{"query": "eino", "top_k": 2}`)
+		assert.NoError(t, err)
+		assert.Equal(t, `{"docs":["doc of eino","doc of eino"]}`, out)
+
+		out, err = gt.InvokableRun(ctx, `{"query": "eino", "top_k": 1}`,
+			WithGraphToolOptions(WithLambdaOption(graphToolLambdaOption{prefix: "page"})))
+		assert.NoError(t, err)
+		assert.Equal(t, `{"docs":["page of eino"]}`, out)
+	})
+
+	t.Run("in tools node", func(t *testing.T) {
+		gt, err := NewInvokableGraphTool(r, "search", "search docs")
+		assert.NoError(t, err)
+		tn, err := NewToolNode(ctx, &ToolsNodeConfig{Tools: []tool.BaseTool{gt}})
+		assert.NoError(t, err)
+
+		msgs, err := tn.Invoke(ctx, schema.AssistantMessage("", []schema.ToolCall{
+			{ID: "1", Function: schema.FunctionCall{Name: "search", Arguments: `{"query": "eino", "top_k": 1}`}},
+		}), WithToolOption(WithGraphToolOptions(WithLambdaOption(graphToolLambdaOption{prefix: "page"}))))
+		assert.NoError(t, err)
+		assert.Equal(t, `{"docs":["page of eino"]}`, msgs[0].Content)
+	})
+
+	t.Run("streamable", func(t *testing.T) {
+		words, err := NewChain[string, string]().AppendLambda(StreamableLambda(func(ctx context.Context, input string) (*schema.StreamReader[string], error) {
+			return schema.StreamReaderFromArray(strings.Fields(input)), nil
+		})).Compile(ctx)
+		assert.NoError(t, err)
+
+		gt, err := NewStreamableGraphTool(words, "split", "split words")
+		assert.NoError(t, err)
+
+		sr, err := gt.StreamableRun(ctx, `"hello graph tool"`)
+		assert.NoError(t, err)
+		var chunks []string
+		for {
+			chunk, err := sr.Recv()
+			if err != nil {
+				break
+			}
+			chunks = append(chunks, chunk)
+		}
+		assert.Equal(t, []string{"hello", "graph", "tool"}, chunks)
+	})
+
+	t.Run("nil runnable", func(t *testing.T) {
+		_, err := NewInvokableGraphTool[string, string](nil, "search", "search docs")
+		assert.Error(t, err)
+	})
+}
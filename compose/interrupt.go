@@ -17,8 +17,10 @@
 package compose
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"sync/atomic"
 )
 
 func WithInterruptBeforeNodes(nodes []string) GraphCompileOption {
@@ -33,6 +35,54 @@ func WithInterruptAfterNodes(nodes []string) GraphCompileOption {
 	}
 }
 
+// InterruptHandle interrupts a running graph from outside, e.g. when the user pauses the run.
+// Pass it to a run by WithInterruptHandle, one handle for each run.
+type InterruptHandle struct {
+	interrupted uint32
+}
+
+func NewInterruptHandle() *InterruptHandle {
+	return &InterruptHandle{}
+}
+
+// Interrupt makes the run stop at the next super step boundary, after the running nodes complete,
+// and return an interrupt error with the nodes to run next as InterruptInfo.BeforeNodes.
+// The checkpoint is saved as for the other interrupts, so the run can be resumed as usual.
+// The subgraphs, and the graphs run by the nodes with the ctx, are interrupted as well.
+func (h *InterruptHandle) Interrupt() {
+	atomic.StoreUint32(&h.interrupted, 1)
+}
+
+// Interrupted reports whether Interrupt has been called.
+func (h *InterruptHandle) Interrupted() bool {
+	if h == nil {
+		return false
+	}
+	return atomic.LoadUint32(&h.interrupted) == 1
+}
+
+// WithInterruptHandle sets the handle to interrupt the run from outside, see InterruptHandle.
+// Interrupt can't be undone, so a handle which has interrupted a run interrupts the runs it's passed to right away,
+// and resuming the run needs a new handle.
+func WithInterruptHandle(h *InterruptHandle) Option {
+	return Option{
+		interruptHandle: h,
+	}
+}
+
+type interruptHandleKey struct{}
+
+// initInterruptHandle puts the handle of the run into ctx, for the subgraphs and the graphs run by the nodes.
+func initInterruptHandle(ctx context.Context, opts ...Option) (context.Context, *InterruptHandle) {
+	for i := len(opts) - 1; i >= 0; i-- {
+		if opts[i].interruptHandle != nil {
+			return context.WithValue(ctx, interruptHandleKey{}, opts[i].interruptHandle), opts[i].interruptHandle
+		}
+	}
+	h, _ := ctx.Value(interruptHandleKey{}).(*InterruptHandle)
+	return ctx, h
+}
+
 var InterruptAndRerun = errors.New("interrupt and rerun")
 
 func NewInterruptAndRerunErr(extra any) error {
@@ -0,0 +1,86 @@
+/*
+ * Copyright 2025 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package react
+
+import (
+	"context"
+
+	"github.com/cloudwego/eino/compose"
+	"github.com/cloudwego/eino/schema"
+)
+
+const (
+	defaultMaxStepAnswerPrompt = "You have run out of steps and cannot call tools any more. " +
+		"Summarize what you have found so far and give the best final answer you can."
+
+	maxStepExhaustedExtraKey = "_eino_react_max_step_exhausted"
+
+	// maxStepAnswerReservedSteps are the steps after MaxStep for the final answer:
+	// one to drop the tool calls that can't run, and one for the model.
+	maxStepAnswerReservedSteps = 2
+)
+
+// MaxStepAnswerConfig makes the agent give a final answer when MaxStep is exhausted, instead of failing.
+// The model is called once more with the prompt and with tool calling disabled,
+// and its answer is the output of the agent, flagged as checked by IsMaxStepExhausted.
+// It takes effect only if MaxStep is set.
+type MaxStepAnswerConfig struct {
+	// Prompt asks the model for the final answer with what it has found, optional.
+	Prompt string
+}
+
+func (conf *MaxStepAnswerConfig) prompt() string {
+	if conf.Prompt != "" {
+		return conf.Prompt
+	}
+	return defaultMaxStepAnswerPrompt
+}
+
+// IsMaxStepExhausted checks whether the message is the final answer given after MaxStep is exhausted,
+// see AgentConfig.MaxStepAnswer. For the streaming output, the flag is on the first chunk.
+func IsMaxStepExhausted(msg *schema.Message) bool {
+	if msg == nil {
+		return false
+	}
+	exhausted, _ := msg.Extra[maxStepExhaustedExtraKey].(bool)
+	return exhausted
+}
+
+func flagMaxStepExhausted(msg *schema.Message) *schema.Message {
+	if msg == nil {
+		return nil
+	}
+	cp := *msg
+	cp.Extra = make(map[string]any, len(msg.Extra)+1)
+	for k, v := range msg.Extra {
+		cp.Extra[k] = v
+	}
+	cp.Extra[maxStepExhaustedExtraKey] = true
+	return &cp
+}
+
+// isToolCallingForbidden disables tool calling for the model calls asking for the final answer,
+// on a loop of tool calls or when MaxStep is exhausted, and flags the answer in the latter case,
+// see toolloop.ToolsForbiddenChatModel.
+func isToolCallingForbidden(ctx context.Context) (forbidden, flag bool) {
+	_ = compose.ProcessState(ctx, func(_ context.Context, st *state) error {
+		forbidden = st.ToolCallLoop != nil || st.MaxStepExhausted
+		flag = st.MaxStepExhausted
+		return nil
+	})
+	return forbidden, flag
+}
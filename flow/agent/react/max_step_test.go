@@ -0,0 +1,107 @@
+/*
+ * Copyright 2025 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package react
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/compose"
+	mockModel "github.com/cloudwego/eino/internal/mock/components/model"
+	"github.com/cloudwego/eino/schema"
+)
+
+func TestMaxStepAnswer(t *testing.T) {
+	ctx := context.Background()
+
+	// newAgent calls the tool until the tool calling is forbidden, or always if ignoreForbidden
+	newAgent := func(t *testing.T, maxStep int, conf *MaxStepAnswerConfig, ignoreForbidden bool) (*Agent, *[][]*schema.Message) {
+		cm := mockModel.NewMockToolCallingChatModel(gomock.NewController(t))
+		cm.EXPECT().WithTools(gomock.Any()).Return(cm, nil).AnyTimes()
+		inputs := new([][]*schema.Message)
+		next := func(input []*schema.Message, opts []model.Option) *schema.Message {
+			*inputs = append(*inputs, input)
+			o := model.GetCommonOptions(nil, opts...)
+			if ignoreForbidden || o.ToolChoice == nil || *o.ToolChoice != schema.ToolChoiceForbidden {
+				return schema.AssistantMessage("", []schema.ToolCall{{ID: randStr(), Function: schema.FunctionCall{Name: "greet", Arguments: `{"name": "tom"}`}}})
+			}
+			return schema.AssistantMessage("summary", nil)
+		}
+		cm.EXPECT().Generate(gomock.Any(), gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+				return next(input, opts), nil
+			}).AnyTimes()
+		cm.EXPECT().Stream(gomock.Any(), gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+				return schema.StreamReaderFromArray([]*schema.Message{next(input, opts)}), nil
+			}).AnyTimes()
+
+		a, err := NewAgent(ctx, &AgentConfig{
+			ToolCallingModel: cm,
+			ToolsConfig:      compose.ToolsNodeConfig{Tools: []tool.BaseTool{&fakeToolGreetForTest{tarCount: 100}}},
+			MaxStep:          maxStep,
+			MaxStepAnswer:    conf,
+		})
+		assert.NoError(t, err)
+		return a, inputs
+	}
+
+	t.Run("answer", func(t *testing.T) {
+		a, inputs := newAgent(t, 4, &MaxStepAnswerConfig{}, false)
+		msg, err := a.Generate(ctx, []*schema.Message{schema.UserMessage("hi")})
+		assert.NoError(t, err)
+		assert.Equal(t, "summary", msg.Content)
+		assert.True(t, IsMaxStepExhausted(msg))
+		assert.Len(t, *inputs, 3)
+		lastInput := (*inputs)[2]
+		assert.Equal(t, defaultMaxStepAnswerPrompt, lastInput[len(lastInput)-1].Content)
+		assert.Equal(t, schema.Tool, lastInput[len(lastInput)-2].Role)
+	})
+
+	t.Run("answer in stream", func(t *testing.T) {
+		a, inputs := newAgent(t, 3, &MaxStepAnswerConfig{Prompt: "wrap up"}, false)
+		sr, err := a.Stream(ctx, []*schema.Message{schema.UserMessage("hi")})
+		assert.NoError(t, err)
+		msg, err := schema.ConcatMessageStream(sr)
+		assert.NoError(t, err)
+		assert.Equal(t, "summary", msg.Content)
+		assert.True(t, IsMaxStepExhausted(msg))
+		lastInput := (*inputs)[len(*inputs)-1]
+		assert.Equal(t, "wrap up", lastInput[len(lastInput)-1].Content)
+		// the tool calls which can't run are dropped
+		assert.Equal(t, schema.Tool, lastInput[len(lastInput)-2].Role)
+	})
+
+	t.Run("still calling tools", func(t *testing.T) {
+		a, _ := newAgent(t, 3, &MaxStepAnswerConfig{}, true)
+		_, err := a.Generate(ctx, []*schema.Message{schema.UserMessage("hi")})
+		assert.True(t, errors.Is(err, compose.ErrExceedMaxSteps))
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		a, _ := newAgent(t, 3, nil, false)
+		_, err := a.Generate(ctx, []*schema.Message{schema.UserMessage("hi")})
+		assert.ErrorContains(t, err, "exceeds max steps")
+		assert.False(t, IsMaxStepExhausted(schema.AssistantMessage("hi", nil)))
+	})
+}
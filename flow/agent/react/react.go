@@ -18,23 +18,36 @@ package react
 
 import (
 	"context"
+	"fmt"
 	"io"
 	"sync"
 
 	"github.com/cloudwego/eino/components/model"
 	"github.com/cloudwego/eino/compose"
 	"github.com/cloudwego/eino/flow/agent"
+	"github.com/cloudwego/eino/internal/toolloop"
 	"github.com/cloudwego/eino/schema"
 )
 
 type state struct {
 	Messages                 []*schema.Message
 	ReturnDirectlyToolCallID string
+
+	// ToolCallHistory is the tool calls of the steps since the last loop, only used when ToolCallLoop is configured.
+	ToolCallHistory []string
+	// ToolCallLoop is the loop detected whose reaction is pending.
+	ToolCallLoop *ToolCallLoop
+
+	// Steps is the number of nodes run, only counted when MaxStepAnswer is configured.
+	Steps int
+	// MaxStepExhausted is set when the model is asked for the final answer after MaxStep is exhausted.
+	MaxStepExhausted bool
 }
 
 const (
-	nodeKeyTools = "tools"
-	nodeKeyModel = "chat"
+	nodeKeyTools         = "tools"
+	nodeKeyModel         = "chat"
+	nodeKeyMaxStepAnswer = "max_step_answer"
 )
 
 // MessageModifier modify the input messages before the model is called.
@@ -85,6 +98,12 @@ type AgentConfig struct {
 	ModelNodeName string
 	// the node name of the tools node in the graph. If empty, will be filled with default value "Tools".
 	ToolsNodeName string
+
+	// ToolCallLoop detects and breaks the loops of repeated tool calls, optional.
+	ToolCallLoop *ToolCallLoopConfig
+
+	// MaxStepAnswer gives the final answer when MaxStep is exhausted instead of failing, optional.
+	MaxStepAnswer *MaxStepAnswerConfig
 }
 
 // Deprecated: This approach of adding persona involves unnecessary slice copying overhead.
@@ -187,10 +206,19 @@ func NewAgent(ctx context.Context, config *AgentConfig) (_ *Agent, err error) {
 		toolInfos       []*schema.ToolInfo
 		toolCallChecker = config.StreamToolCallChecker
 		messageModifier = config.MessageModifier
+		toolCallLoop    = config.ToolCallLoop
+		maxStepAnswer   = config.MaxStepAnswer
 	)
 
+	// the steps are counted by the agent to give the final answer before the graph fails for exceeding them
+	countSteps := maxStepAnswer != nil && config.MaxStep > 0
+
 	registerStateOnce.Do(func() {
 		err = compose.RegisterSerializableType[state]("_eino_react_state")
+		if err != nil {
+			return
+		}
+		err = compose.RegisterSerializableType[ToolCallLoop]("_eino_react_tool_call_loop")
 	})
 	if err != nil {
 		return
@@ -215,6 +243,12 @@ func NewAgent(ctx context.Context, config *AgentConfig) (_ *Agent, err error) {
 		toolCallChecker = firstChunkStreamToolCallChecker
 	}
 
+	if toolCallLoop != nil {
+		if err = toolloop.Validate(toolCallLoop); err != nil {
+			return nil, err
+		}
+	}
+
 	if toolInfos, err = genToolInfos(ctx, config.ToolsConfig); err != nil {
 		return nil, err
 	}
@@ -222,6 +256,9 @@ func NewAgent(ctx context.Context, config *AgentConfig) (_ *Agent, err error) {
 	if chatModel, err = agent.ChatModelWithTools(config.Model, config.ToolCallingModel, toolInfos); err != nil {
 		return nil, err
 	}
+	if toolCallLoop != nil || countSteps {
+		chatModel = &toolloop.ToolsForbiddenChatModel{Inner: chatModel, Forbidden: isToolCallingForbidden, Flag: flagMaxStepExhausted}
+	}
 
 	if toolsNode, err = compose.NewToolNode(ctx, &config.ToolsConfig); err != nil {
 		return nil, err
@@ -233,6 +270,19 @@ func NewAgent(ctx context.Context, config *AgentConfig) (_ *Agent, err error) {
 
 	modelPreHandle := func(ctx context.Context, input []*schema.Message, state *state) ([]*schema.Message, error) {
 		state.Messages = append(state.Messages, input...)
+		if state.ToolCallLoop != nil {
+			state.Messages = append(state.Messages, schema.UserMessage(toolloop.GetMessage(toolCallLoop)))
+			if state.ToolCallLoop.Reaction == ToolCallLoopCorrect {
+				state.ToolCallLoop = nil
+			}
+		}
+		if countSteps {
+			state.Steps++
+			if state.Steps > config.MaxStep {
+				state.MaxStepExhausted = true
+				state.Messages = append(state.Messages, schema.UserMessage(maxStepAnswer.prompt()))
+			}
+		}
 
 		if messageModifier == nil {
 			return state.Messages, nil
@@ -256,7 +306,19 @@ func NewAgent(ctx context.Context, config *AgentConfig) (_ *Agent, err error) {
 			return state.Messages[len(state.Messages)-1], nil // used for rerun interrupt resume
 		}
 		state.Messages = append(state.Messages, input)
+		if countSteps {
+			state.Steps++
+		}
 		state.ReturnDirectlyToolCallID = getReturnDirectlyToolCallID(input, config.ToolReturnDirectly)
+
+		if toolCallLoop != nil {
+			if loop := toolloop.DetectLoop(ctx, toolCallLoop, "", &state.ToolCallHistory, input.ToolCalls); loop != nil {
+				if loop.Reaction == ToolCallLoopFail {
+					return nil, &ToolCallLoopError{Loop: loop}
+				}
+				state.ToolCallLoop = loop
+			}
+		}
 		return input, nil
 	}
 	if err = graph.AddToolsNode(nodeKeyTools, toolsNode, compose.WithStatePreHandler(toolsNodePreHandle), compose.WithNodeName(toolsNodeName)); err != nil {
@@ -264,15 +326,50 @@ func NewAgent(ctx context.Context, config *AgentConfig) (_ *Agent, err error) {
 	}
 
 	modelPostBranchCondition := func(ctx context.Context, sr *schema.StreamReader[*schema.Message]) (endNode string, err error) {
+		var (
+			loop                  *ToolCallLoop
+			exhausted, outOfSteps bool
+		)
+		if toolCallLoop != nil || countSteps {
+			err = compose.ProcessState(ctx, func(_ context.Context, state *state) error {
+				loop, state.ToolCallLoop = state.ToolCallLoop, nil
+				exhausted = state.MaxStepExhausted
+				// the tools node would be the last step, leaving no step for the model to answer with the tool results
+				outOfSteps = countSteps && state.Steps+1 > config.MaxStep
+				return nil
+			})
+			if err != nil {
+				sr.Close()
+				return "", err
+			}
+		}
+
 		if isToolCall, err := toolCallChecker(ctx, sr); err != nil {
 			return "", err
 		} else if isToolCall {
+			if loop != nil {
+				// the model is still calling tools when asked for the final answer
+				return "", &ToolCallLoopError{Loop: loop}
+			}
+			if exhausted {
+				return "", fmt.Errorf("model is still calling tools after max step is exhausted: %w", compose.ErrExceedMaxSteps)
+			}
+			if outOfSteps {
+				return nodeKeyMaxStepAnswer, nil
+			}
 			return nodeKeyTools, nil
 		}
 		return compose.END, nil
 	}
 
-	if err = graph.AddBranch(nodeKeyModel, compose.NewStreamGraphBranch(modelPostBranchCondition, map[string]bool{nodeKeyTools: true, compose.END: true})); err != nil {
+	branchEnds := map[string]bool{nodeKeyTools: true, compose.END: true}
+	if countSteps {
+		if err = buildMaxStepAnswer(graph); err != nil {
+			return nil, err
+		}
+		branchEnds[nodeKeyMaxStepAnswer] = true
+	}
+	if err = graph.AddBranch(nodeKeyModel, compose.NewStreamGraphBranch(modelPostBranchCondition, branchEnds)); err != nil {
 		return nil, err
 	}
 
@@ -280,7 +377,11 @@ func NewAgent(ctx context.Context, config *AgentConfig) (_ *Agent, err error) {
 		return nil, err
 	}
 
-	compileOpts := []compose.GraphCompileOption{compose.WithMaxRunSteps(config.MaxStep), compose.WithNodeTriggerMode(compose.AnyPredecessor), compose.WithGraphName(graphName)}
+	maxRunSteps := config.MaxStep
+	if countSteps {
+		maxRunSteps += maxStepAnswerReservedSteps
+	}
+	compileOpts := []compose.GraphCompileOption{compose.WithMaxRunSteps(maxRunSteps), compose.WithNodeTriggerMode(compose.AnyPredecessor), compose.WithGraphName(graphName)}
 	runnable, err := graph.Compile(ctx, compileOpts...)
 	if err != nil {
 		return nil, err
@@ -293,6 +394,21 @@ func NewAgent(ctx context.Context, config *AgentConfig) (_ *Agent, err error) {
 	}, nil
 }
 
+// buildMaxStepAnswer drops the tool calls which can't run, and asks the model for the final answer.
+func buildMaxStepAnswer(graph *compose.Graph[[]*schema.Message, *schema.Message]) (err error) {
+	answer := func(ctx context.Context, _ *schema.Message) ([]*schema.Message, error) {
+		err := compose.ProcessState[*state](ctx, func(_ context.Context, state *state) error {
+			state.Steps++
+			return nil
+		})
+		return nil, err
+	}
+	if err = graph.AddLambdaNode(nodeKeyMaxStepAnswer, compose.InvokableLambda(answer)); err != nil {
+		return err
+	}
+	return graph.AddEdge(nodeKeyMaxStepAnswer, nodeKeyModel)
+}
+
 func buildReturnDirectly(graph *compose.Graph[[]*schema.Message, *schema.Message]) (err error) {
 	directReturn := func(ctx context.Context, msgs *schema.StreamReader[[]*schema.Message]) (*schema.StreamReader[*schema.Message], error) {
 		return schema.StreamReaderWithConvert(msgs, func(msgs []*schema.Message) (*schema.Message, error) {
@@ -0,0 +1,51 @@
+/*
+ * Copyright 2025 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package react
+
+import (
+	"github.com/cloudwego/eino/internal/toolloop"
+)
+
+// ComponentOfToolCallLoopDetector is the component in the RunInfo of the callbacks reporting the loops detected by ToolCallLoopConfig.
+// The callback input is *ToolCallLoopCallbackInput, and the callback output is *ToolCallLoop.
+const ComponentOfToolCallLoopDetector = toolloop.ComponentOfDetector
+
+// ToolCallLoopReaction is how the agent reacts to a loop of tool calls.
+type ToolCallLoopReaction = toolloop.Reaction
+
+const (
+	// ToolCallLoopCorrect runs the looping tool calls, then asks the model to change its approach with a corrective message.
+	ToolCallLoopCorrect = toolloop.ReactionCorrect
+	// ToolCallLoopFinalAnswer runs the looping tool calls, then asks the model for the final answer with tool calling disabled.
+	// The agent fails with *ToolCallLoopError if the model still calls tools.
+	ToolCallLoopFinalAnswer = toolloop.ReactionFinalAnswer
+	// ToolCallLoopFail fails the agent with *ToolCallLoopError, without running the looping tool calls.
+	ToolCallLoopFail = toolloop.ReactionFail
+)
+
+// ToolCallLoopConfig detects the react agent repeating the same tool calls without making progress,
+// which otherwise goes on until MaxStep is exceeded.
+type ToolCallLoopConfig = toolloop.Config
+
+// ToolCallLoopCallbackInput is the callback input of ComponentOfToolCallLoopDetector.
+type ToolCallLoopCallbackInput = toolloop.CallbackInput
+
+// ToolCallLoop is a loop detected in the tool calls.
+type ToolCallLoop = toolloop.Loop
+
+// ToolCallLoopError fails the agent on a loop of tool calls, see ToolCallLoopConfig.Reaction.
+type ToolCallLoopError = toolloop.Error
@@ -0,0 +1,117 @@
+/*
+ * Copyright 2025 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package react
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/compose"
+	mockModel "github.com/cloudwego/eino/internal/mock/components/model"
+	"github.com/cloudwego/eino/schema"
+)
+
+func TestToolCallLoop(t *testing.T) {
+	ctx := context.Background()
+
+	greet := func(id string) *schema.Message {
+		return schema.AssistantMessage("", []schema.ToolCall{{ID: id, Function: schema.FunctionCall{Name: "greet", Arguments: `{"name": "tom"}`}}})
+	}
+
+	newAgent := func(t *testing.T, conf *ToolCallLoopConfig, stream bool,
+		respond func(i int, input []*schema.Message, opts *model.Options) *schema.Message) *Agent {
+
+		cm := mockModel.NewMockToolCallingChatModel(gomock.NewController(t))
+		cm.EXPECT().WithTools(gomock.Any()).Return(cm, nil).AnyTimes()
+		i := 0
+		next := func(input []*schema.Message, opts []model.Option) *schema.Message {
+			i++
+			if msg := respond(i, input, model.GetCommonOptions(nil, opts...)); msg != nil {
+				return msg
+			}
+			return schema.AssistantMessage("bye", nil)
+		}
+		if stream {
+			cm.EXPECT().Stream(gomock.Any(), gomock.Any(), gomock.Any()).
+				DoAndReturn(func(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+					return schema.StreamReaderFromArray([]*schema.Message{next(input, opts)}), nil
+				}).AnyTimes()
+		} else {
+			cm.EXPECT().Generate(gomock.Any(), gomock.Any(), gomock.Any()).
+				DoAndReturn(func(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+					return next(input, opts), nil
+				}).AnyTimes()
+		}
+
+		a, err := NewAgent(ctx, &AgentConfig{
+			ToolCallingModel: cm,
+			ToolsConfig:      compose.ToolsNodeConfig{Tools: []tool.BaseTool{&fakeToolGreetForTest{tarCount: 100}}},
+			MaxStep:          40,
+			ToolCallLoop:     conf,
+		})
+		assert.NoError(t, err)
+		return a
+	}
+
+	t.Run("final answer in stream", func(t *testing.T) {
+		var loops []*ToolCallLoop
+		a := newAgent(t, &ToolCallLoopConfig{
+			Reaction: ToolCallLoopFinalAnswer,
+			OnDetected: func(ctx context.Context, loop *ToolCallLoop) {
+				loops = append(loops, loop)
+			},
+		}, true, func(i int, input []*schema.Message, opts *model.Options) *schema.Message {
+			if i <= 3 {
+				return greet(randStr())
+			}
+			assert.Contains(t, input[len(input)-1].Content, "give the final answer now")
+			assert.Equal(t, schema.ToolChoiceForbidden, *opts.ToolChoice)
+			return nil
+		})
+
+		sr, err := a.Stream(ctx, []*schema.Message{schema.UserMessage("hi")})
+		assert.NoError(t, err)
+		msg, err := schema.ConcatMessageStream(sr)
+		assert.NoError(t, err)
+		assert.Equal(t, "bye", msg.Content)
+		assert.Equal(t, []*ToolCallLoop{{Pattern: []string{`greet({"name":"tom"})`}, Repeats: 3, Reaction: ToolCallLoopFinalAnswer}}, loops)
+	})
+
+	t.Run("correct then fail", func(t *testing.T) {
+		a := newAgent(t, &ToolCallLoopConfig{MaxRepeats: 2}, false, func(i int, input []*schema.Message, opts *model.Options) *schema.Message {
+			return greet("1")
+		})
+		_, err := a.Generate(ctx, []*schema.Message{schema.UserMessage("hi")})
+		// corrected every 2 steps, until MaxStep is exceeded
+		assert.ErrorContains(t, err, "exceeds max steps")
+
+		a = newAgent(t, &ToolCallLoopConfig{MaxRepeats: 2, Reaction: ToolCallLoopFail}, false, func(i int, input []*schema.Message, opts *model.Options) *schema.Message {
+			return greet("1")
+		})
+		_, err = a.Generate(ctx, []*schema.Message{schema.UserMessage("hi")})
+		var loopErr *ToolCallLoopError
+		assert.True(t, errors.As(err, &loopErr))
+		assert.Equal(t, 2, loopErr.Loop.Repeats)
+	})
+
+}
@@ -0,0 +1,78 @@
+/*
+ * Copyright 2025 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package toolloop
+
+import (
+	"context"
+
+	"github.com/cloudwego/eino/components"
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+)
+
+// ToolsForbiddenChatModel disables tool calling for the model calls asking for the final answer,
+// on a loop of tool calls or when the max step is exhausted.
+type ToolsForbiddenChatModel struct {
+	Inner model.BaseChatModel
+	// Forbidden reports whether tool calling is disabled for the call, and whether the answer is to be flagged.
+	Forbidden func(ctx context.Context) (forbidden, flag bool)
+	// Flag marks the answer, optional. In streaming, it's applied to the first chunk.
+	Flag func(msg *schema.Message) *schema.Message
+}
+
+func (m *ToolsForbiddenChatModel) GetType() string {
+	typ, _ := components.GetType(m.Inner)
+	return typ
+}
+
+func (m *ToolsForbiddenChatModel) IsCallbacksEnabled() bool {
+	return components.IsCallbacksEnabled(m.Inner)
+}
+
+func (m *ToolsForbiddenChatModel) Generate(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+	opts, flag := m.options(ctx, opts)
+	msg, err := m.Inner.Generate(ctx, input, opts...)
+	if err != nil || !flag {
+		return msg, err
+	}
+	return m.Flag(msg), nil
+}
+
+func (m *ToolsForbiddenChatModel) Stream(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	opts, flag := m.options(ctx, opts)
+	sr, err := m.Inner.Stream(ctx, input, opts...)
+	if err != nil || !flag {
+		return sr, err
+	}
+
+	first := true
+	return schema.StreamReaderWithConvert(sr, func(msg *schema.Message) (*schema.Message, error) {
+		if !first {
+			return msg, nil
+		}
+		first = false
+		return m.Flag(msg), nil
+	}), nil
+}
+
+func (m *ToolsForbiddenChatModel) options(ctx context.Context, opts []model.Option) (_ []model.Option, flag bool) {
+	forbidden, flag := m.Forbidden(ctx)
+	if !forbidden {
+		return opts, false
+	}
+	return append(opts[:len(opts):len(opts)], model.WithToolChoice(schema.ToolChoiceForbidden)), flag && m.Flag != nil
+}
@@ -0,0 +1,230 @@
+/*
+ * Copyright 2025 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package toolloop detects the loops of tool calls, and disables tool calling for the final answer,
+// shared by the ReAct agents of adk and flow/agent/react.
+package toolloop
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/cloudwego/eino/callbacks"
+	"github.com/cloudwego/eino/components"
+	"github.com/cloudwego/eino/schema"
+)
+
+// ComponentOfDetector is the component in the RunInfo of the callbacks reporting the loops detected.
+// The callback input is *CallbackInput, and the callback output is *Loop.
+const ComponentOfDetector components.Component = "ToolCallLoopDetector"
+
+// Reaction is how the agent reacts to a loop of tool calls.
+type Reaction string
+
+const (
+	// ReactionCorrect runs the looping tool calls, then asks the model to change its approach with a corrective message.
+	ReactionCorrect Reaction = "correct"
+	// ReactionFinalAnswer runs the looping tool calls, then asks the model for the final answer with tool calling disabled.
+	// The agent fails with *Error if the model still calls tools.
+	ReactionFinalAnswer Reaction = "final_answer"
+	// ReactionFail fails the agent with *Error, without running the looping tool calls.
+	ReactionFail Reaction = "fail"
+)
+
+// Config is the config to detect the agent repeating the same tool calls without making progress,
+// which otherwise goes on until MaxStep is exceeded.
+type Config struct {
+	// MaxRepeats is the times a pattern of steps repeats in a row to be a loop, optional, defaults to 3.
+	// A step is all the tool calls of a model response, compared by the tool names and the arguments.
+	MaxRepeats int
+	// MaxCycleLength is the max number of steps in the pattern, optional, defaults to 2,
+	// i.e. both A A A and the oscillating A B A B A B are loops by default.
+	MaxCycleLength int
+
+	// Reaction to the loops, optional, defaults to ReactionCorrect.
+	Reaction Reaction
+	// Message is the corrective message for ReactionCorrect or ReactionFinalAnswer, optional.
+	Message string
+
+	// OnDetected is called when a loop is detected, before the reaction, optional.
+	// The loops are reported to the callbacks as well, see ComponentOfDetector.
+	OnDetected func(ctx context.Context, loop *Loop)
+}
+
+// CallbackInput is the callback input of ComponentOfDetector.
+type CallbackInput struct {
+	// Steps are the steps the loop is detected in, each of which is the tool calls formatted as `name(arguments)`.
+	Steps []string
+}
+
+// Loop is a loop detected in the tool calls.
+type Loop struct {
+	// AgentName is the name of the agent, empty if the agent has no name.
+	AgentName string
+	// Pattern is the repeated steps, each of which is the tool calls formatted as `name(arguments)`.
+	Pattern  []string
+	Repeats  int
+	Reaction Reaction
+}
+
+// Error fails the agent on a loop of tool calls, see Config.Reaction.
+type Error struct {
+	Loop *Loop
+}
+
+func (e *Error) Error() string {
+	agent := "agent"
+	if e.Loop.AgentName != "" {
+		agent = fmt.Sprintf("agent '%s'", e.Loop.AgentName)
+	}
+	return fmt.Sprintf("%s is repeating tool calls [%s] for %d times", agent, strings.Join(e.Loop.Pattern, " -> "), e.Loop.Repeats)
+}
+
+// Validate checks the reaction of conf.
+func Validate(conf *Config) error {
+	switch conf.Reaction {
+	case "", ReactionCorrect, ReactionFinalAnswer, ReactionFail:
+		return nil
+	default:
+		return fmt.Errorf("unknown tool call loop reaction: %s", conf.Reaction)
+	}
+}
+
+// GetReaction returns the reaction of conf, ReactionCorrect by default.
+func GetReaction(conf *Config) Reaction {
+	if conf.Reaction == "" {
+		return ReactionCorrect
+	}
+	return conf.Reaction
+}
+
+// GetMessage returns the message sent to the model for the reaction of conf.
+func GetMessage(conf *Config) string {
+	if conf.Message != "" {
+		return conf.Message
+	}
+	if GetReaction(conf) == ReactionFinalAnswer {
+		return DefaultFinalAnswerRequest
+	}
+	return DefaultCorrection
+}
+
+// DetectLoop records the tool calls of the step in history, and returns the loop if they complete one.
+// The loop is reported to the callbacks and OnDetected, and history is reset for the model to get out of the loop.
+func DetectLoop(ctx context.Context, conf *Config, agentName string, history *[]string, calls []schema.ToolCall) *Loop {
+	*history = append(*history, Signature(calls))
+
+	maxRepeats := conf.MaxRepeats
+	if maxRepeats <= 0 {
+		maxRepeats = DefaultMaxRepeats
+	}
+	pattern := Detect(*history, maxRepeats, conf.MaxCycleLength)
+	if pattern == nil {
+		return nil
+	}
+
+	loop := &Loop{
+		AgentName: agentName,
+		Pattern:   pattern,
+		Repeats:   maxRepeats,
+		Reaction:  GetReaction(conf),
+	}
+	report(ctx, *history, loop)
+	if conf.OnDetected != nil {
+		conf.OnDetected(ctx, loop)
+	}
+
+	// the model gets another MaxRepeats steps to get out of the loop after the reaction
+	*history = nil
+	return loop
+}
+
+func report(ctx context.Context, steps []string, loop *Loop) {
+	name := string(ComponentOfDetector)
+	ctx = callbacks.ReuseHandlers(ctx, &callbacks.RunInfo{Name: name, Type: name, Component: ComponentOfDetector})
+	ctx = callbacks.OnStart(ctx, &CallbackInput{Steps: steps})
+	callbacks.OnEnd(ctx, loop)
+}
+
+const (
+	DefaultMaxRepeats     = 3
+	DefaultMaxCycleLength = 2
+
+	DefaultCorrection = "You have been repeating the same tool calls without making progress. " +
+		"Do not call the tools with the same arguments again. Change your approach, or give the final answer with what you have."
+	DefaultFinalAnswerRequest = "You have been repeating the same tool calls without making progress. " +
+		"Stop calling tools and give the final answer now with what you have."
+)
+
+// Signature identifies the tool calls of a step, regardless of their order and the formatting of the arguments,
+// e.g. `search({"query":"eino"}); weather({"city":"Beijing"})`.
+func Signature(calls []schema.ToolCall) string {
+	sigs := make([]string, 0, len(calls))
+	for _, tc := range calls {
+		sigs = append(sigs, tc.Function.Name+"("+normalizeArguments(tc.Function.Arguments)+")")
+	}
+	sort.Strings(sigs)
+	return strings.Join(sigs, "; ")
+}
+
+// normalizeArguments re-encodes the JSON arguments with sorted keys and without spaces, and keeps the invalid ones as is.
+func normalizeArguments(args string) string {
+	var v any
+	if err := json.Unmarshal([]byte(args), &v); err != nil {
+		return strings.TrimSpace(args)
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return strings.TrimSpace(args)
+	}
+	return string(b)
+}
+
+// Detect finds the pattern of steps repeated at least maxRepeats times in a row at the end of history,
+// trying the patterns of 1 to maxCycleLength steps, e.g. [A] of A A A, or [A B] of A B A B A B.
+// It returns nil if there is no loop.
+func Detect(history []string, maxRepeats, maxCycleLength int) []string {
+	if maxRepeats <= 0 {
+		maxRepeats = DefaultMaxRepeats
+	}
+	if maxCycleLength <= 0 {
+		maxCycleLength = DefaultMaxCycleLength
+	}
+
+	for l := 1; l <= maxCycleLength; l++ {
+		n := l * maxRepeats
+		if len(history) < n {
+			break
+		}
+
+		tail := history[len(history)-n:]
+		repeated := true
+		for i := l; i < n; i++ {
+			if tail[i] != tail[i-l] {
+				repeated = false
+				break
+			}
+		}
+		if repeated {
+			return append([]string{}, tail[:l]...)
+		}
+	}
+
+	return nil
+}
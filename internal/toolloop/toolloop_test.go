@@ -0,0 +1,105 @@
+/*
+ * Copyright 2025 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package toolloop
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cloudwego/eino/callbacks"
+	"github.com/cloudwego/eino/schema"
+)
+
+func TestSignature(t *testing.T) {
+	call := func(name, args string) schema.ToolCall {
+		return schema.ToolCall{Function: schema.FunctionCall{Name: name, Arguments: args}}
+	}
+
+	assert.Equal(t,
+		Signature([]schema.ToolCall{call("b", `{"x": 1, "a": "v"}`), call("a", "not json ")}),
+		Signature([]schema.ToolCall{call("a", "not json"), call("b", `{"a":"v","x":1}`)}))
+	assert.Equal(t, `a(not json); b({"a":"v","x":1})`, Signature([]schema.ToolCall{call("b", `{"x": 1, "a": "v"}`), call("a", "not json")}))
+	assert.NotEqual(t, Signature([]schema.ToolCall{call("a", `{"x": 1}`)}), Signature([]schema.ToolCall{call("a", `{"x": 2}`)}))
+}
+
+func TestDetect(t *testing.T) {
+	assert.Nil(t, Detect([]string{"a", "a"}, 3, 2))
+	assert.Equal(t, []string{"a"}, Detect([]string{"b", "a", "a", "a"}, 3, 2))
+	assert.Equal(t, []string{"a", "b"}, Detect([]string{"a", "b", "a", "b", "a", "b"}, 3, 2))
+	assert.Nil(t, Detect([]string{"a", "b", "a", "b", "a", "b"}, 3, 1))
+	assert.Nil(t, Detect([]string{"a", "b", "a", "b", "a", "c"}, 3, 2))
+	assert.Equal(t, []string{"c", "a", "b"}, Detect([]string{"a", "b", "c", "a", "b", "c", "a", "b"}, 2, 3))
+	assert.Equal(t, []string{"a"}, Detect([]string{"a", "a", "a"}, 0, 0))
+}
+
+func TestDetectLoop(t *testing.T) {
+	var steps []string
+	var reported *Loop
+	handler := callbacks.NewHandlerBuilder().
+		OnStartFn(func(ctx context.Context, info *callbacks.RunInfo, input callbacks.CallbackInput) context.Context {
+			if info.Component == ComponentOfDetector {
+				steps = input.(*CallbackInput).Steps
+			}
+			return ctx
+		}).
+		OnEndFn(func(ctx context.Context, info *callbacks.RunInfo, output callbacks.CallbackOutput) context.Context {
+			if info.Component == ComponentOfDetector {
+				reported = output.(*Loop)
+			}
+			return ctx
+		}).Build()
+	ctx := callbacks.InitCallbacks(context.Background(), &callbacks.RunInfo{}, handler)
+
+	var detected *Loop
+	conf := &Config{MaxRepeats: 2, OnDetected: func(ctx context.Context, loop *Loop) {
+		detected = loop
+	}}
+	calls := []schema.ToolCall{{Function: schema.FunctionCall{Name: "greet", Arguments: `{"name": "tom"}`}}}
+
+	var history []string
+	assert.Nil(t, DetectLoop(ctx, conf, "looper", &history, calls))
+	loop := DetectLoop(ctx, conf, "looper", &history, calls)
+	assert.Equal(t, &Loop{
+		AgentName: "looper",
+		Pattern:   []string{`greet({"name":"tom"})`},
+		Repeats:   2,
+		Reaction:  ReactionCorrect,
+	}, loop)
+	assert.Equal(t, []string{`greet({"name":"tom"})`, `greet({"name":"tom"})`}, steps)
+	assert.Same(t, loop, reported)
+	assert.Same(t, loop, detected)
+	// the history restarts after a loop
+	assert.Nil(t, history)
+	assert.Nil(t, DetectLoop(ctx, conf, "looper", &history, calls))
+
+	assert.EqualError(t, &Error{Loop: loop}, `agent 'looper' is repeating tool calls [greet({"name":"tom"})] for 2 times`)
+	loop.AgentName = ""
+	assert.EqualError(t, &Error{Loop: loop}, `agent is repeating tool calls [greet({"name":"tom"})] for 2 times`)
+}
+
+func TestConfig(t *testing.T) {
+	assert.NoError(t, Validate(&Config{}))
+	assert.NoError(t, Validate(&Config{Reaction: ReactionFail}))
+	assert.Error(t, Validate(&Config{Reaction: "retry"}))
+
+	assert.Equal(t, ReactionCorrect, GetReaction(&Config{}))
+	assert.Equal(t, DefaultCorrection, GetMessage(&Config{}))
+	assert.Equal(t, DefaultFinalAnswerRequest, GetMessage(&Config{Reaction: ReactionFinalAnswer}))
+	assert.Equal(t, "answer now", GetMessage(&Config{Reaction: ReactionFinalAnswer, Message: "answer now"}))
+}